@@ -0,0 +1,169 @@
+package bluesky
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+const (
+	// Public AppView that serves unauthenticated reads
+	appViewURL      = "https://public.api.bsky.app/xrpc/"
+	plcDirectoryURL = "https://plc.directory/"
+
+	pdsServiceID = "#atproto_pds"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type API interface {
+	GetPost(actor string, rkey string) (*Post, error)
+	GetVideoURL(did string, cid string) (string, error)
+}
+
+type BaseAPI struct{}
+
+var (
+	client *retryablehttp.Client
+
+	apiSetupOnce sync.Once
+)
+
+func NewBaseAPI() *BaseAPI {
+	apiSetupOnce.Do(func() {
+		client = retryablehttp.NewClient()
+		client.HTTPClient.Timeout = 30 * time.Second
+		client.Logger = nil
+	})
+
+	return &BaseAPI{}
+}
+
+// GetPost returns the post with its quoted post and the post it replies to.
+// The actor is either a handle or a DID.
+func (a *BaseAPI) GetPost(actor string, rkey string) (*Post, error) {
+	did, err := a.resolveDID(actor)
+	if err != nil {
+		return nil, err
+	}
+
+	rawResp := &getPostThreadResponse{}
+	if err := a.getXRPC("app.bsky.feed.getPostThread", url.Values{
+		"uri":          {fmt.Sprintf("at://%s/app.bsky.feed.post/%s", did, rkey)},
+		"depth":        {"0"},
+		"parentHeight": {"1"},
+	}, rawResp); err != nil {
+		return nil, err
+	}
+
+	post := rawResp.Thread.ToDTO()
+	if post == nil {
+		return nil, ErrNotFound
+	}
+
+	return post, nil
+}
+
+// GetVideoURL returns the URL of the originally uploaded video, which is served by the PDS of its author
+func (a *BaseAPI) GetVideoURL(did string, cid string) (string, error) {
+	pdsURL, err := a.getPDSURL(did)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/xrpc/com.atproto.sync.getBlob?%s", strings.TrimSuffix(pdsURL, "/"), url.Values{
+		"did": {did},
+		"cid": {cid},
+	}.Encode()), nil
+}
+
+func (a *BaseAPI) resolveDID(actor string) (string, error) {
+	if strings.HasPrefix(actor, "did:") {
+		return actor, nil
+	}
+
+	rawResp := &resolveHandleResponse{}
+	if err := a.getXRPC("com.atproto.identity.resolveHandle", url.Values{"handle": {actor}}, rawResp); err != nil {
+		var xrpcErr *xrpcError
+		if errors.As(err, &xrpcErr) {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, xrpcErr.Message)
+		}
+		return "", err
+	}
+
+	return rawResp.DID, nil
+}
+
+func (a *BaseAPI) getPDSURL(did string) (string, error) {
+	var docURL string
+	if strings.HasPrefix(did, "did:plc:") {
+		docURL = plcDirectoryURL + did
+	} else if strings.HasPrefix(did, "did:web:") {
+		docURL = fmt.Sprintf("https://%s/.well-known/did.json", strings.TrimPrefix(did, "did:web:"))
+	} else {
+		return "", fmt.Errorf("unsupported DID %s", did)
+	}
+
+	resp, err := client.Get(docURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP request to %s returned status %v", docURL, resp.StatusCode)
+	}
+
+	doc := &struct {
+		Service []struct {
+			ID              string `json:"id"`
+			ServiceEndpoint string `json:"serviceEndpoint"`
+		} `json:"service"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(doc); err != nil {
+		return "", err
+	}
+
+	for _, service := range doc.Service {
+		if service.ID == pdsServiceID {
+			return service.ServiceEndpoint, nil
+		}
+	}
+
+	return "", fmt.Errorf("no PDS found for %s", did)
+}
+
+func (a *BaseAPI) getXRPC(method string, params url.Values, v any) error {
+	resp, err := client.Get(appViewURL + method + "?" + params.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		xrpcErr := &xrpcError{}
+		if err := json.Unmarshal(bytes, xrpcErr); err != nil || xrpcErr.Name == "" {
+			return fmt.Errorf("HTTP request to %s returned status %v", method, resp.StatusCode)
+		}
+
+		if xrpcErr.Name == "NotFound" {
+			return fmt.Errorf("%w: %s", ErrNotFound, xrpcErr.Message)
+		}
+		return xrpcErr
+	}
+
+	return json.Unmarshal(bytes, v)
+}
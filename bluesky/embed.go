@@ -0,0 +1,150 @@
+package bluesky
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/xIceArcher/go-leah/consts"
+	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/utils"
+)
+
+var blueskyEmbedFooter = &discordgo.MessageEmbedFooter{
+	Text:    "Bluesky",
+	IconURL: "https://bsky.app/static/apple-touch-icon.png",
+}
+
+func (p *Post) GetEmbeds() []*discordgo.MessageEmbed {
+	var mainEmbed *discordgo.MessageEmbed
+	var relevantPhotos []*Media
+
+	if p.IsReply {
+		mainEmbed = p.replyMainEmbed()
+		relevantPhotos = p.Photos()
+	} else if p.IsQuoted {
+		mainEmbed = p.quotedMainEmbed()
+
+		if p.HasPhotos() {
+			relevantPhotos = p.Photos()
+		} else if p.QuotedPost.HasPhotos() {
+			relevantPhotos = p.QuotedPost.Photos()
+		}
+	} else {
+		mainEmbed = p.standardMainEmbed()
+		relevantPhotos = p.Photos()
+	}
+
+	altTextField := &discordgo.MessageEmbedField{
+		Name: "Alt Text",
+	}
+
+	otherEmbeds := make([]*discordgo.MessageEmbed, 0)
+	for i, photo := range relevantPhotos {
+		if i == 0 {
+			mainEmbed.Image = &discordgo.MessageEmbedImage{
+				URL: photo.URL,
+			}
+		} else {
+			photoEmbed := photo.GetEmbed()
+			photoEmbed.URL = p.URL()
+
+			otherEmbeds = append(otherEmbeds, photoEmbed)
+		}
+
+		if photo.AltText != "" {
+			altTextField.Value += discord.GetNamedLink(fmt.Sprintf("Image %v", i+1), photo.URL) + "\n" + photo.AltText + "\n\n"
+		}
+	}
+
+	altTextField.Value = strings.TrimSpace(altTextField.Value)
+	if altTextField.Value != "" {
+		mainEmbed.Fields = append(mainEmbed.Fields, altTextField)
+	}
+
+	mainEmbed.Author = p.Author.GetEmbed()
+
+	mainEmbed.Footer = blueskyEmbedFooter
+	if !p.Timestamp.IsZero() {
+		mainEmbed.Timestamp = p.Timestamp.Format(time.RFC3339)
+	}
+
+	embeds := []*discordgo.MessageEmbed{mainEmbed}
+	embeds = append(embeds, otherEmbeds...)
+	return embeds
+}
+
+func (p *Post) standardMainEmbed() *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		URL:         p.URL(),
+		Title:       fmt.Sprintf("Post by %s", p.Author.Name),
+		Description: p.GetTextWithEmbeds(4096),
+		Color:       utils.ParseHexColor(consts.ColorBluesky),
+	}
+}
+
+func (p *Post) quotedMainEmbed() *discordgo.MessageEmbed {
+	embed := p.standardMainEmbed()
+
+	embed.Fields = []*discordgo.MessageEmbedField{
+		p.QuotedPost.getContextField("Quote", "Quoted post"),
+	}
+
+	return embed
+}
+
+func (p *Post) replyMainEmbed() *discordgo.MessageEmbed {
+	embed := p.standardMainEmbed()
+	if p.ReplyPost == nil {
+		embed.Title = "Reply to a deleted post"
+		return embed
+	}
+
+	embed.Title = fmt.Sprintf("Reply to %s (@%s)", p.ReplyPost.Author.Name, p.ReplyPost.Author.Handle)
+	embed.Fields = []*discordgo.MessageEmbedField{
+		p.ReplyPost.getContextField("Replying to", "Post"),
+	}
+
+	return embed
+}
+
+// getContextField links to the post and shows its text, for posts that are quoted or replied to
+func (p *Post) getContextField(name string, linkPrefix string) *discordgo.MessageEmbedField {
+	link := discord.GetNamedLink(fmt.Sprintf("%s by %s (@%s)", linkPrefix, p.Author.Name, p.Author.Handle), p.URL())
+
+	// Fields are limited to 1024 characters
+	return &discordgo.MessageEmbedField{
+		Name:  name,
+		Value: link + "\n" + p.GetTextWithEmbeds(1024-len(link)-1),
+	}
+}
+
+// GetTextWithEmbeds returns the text with its facets linked, cut to at most maxBytes
+func (p *Post) GetTextWithEmbeds(maxBytes int) string {
+	// The text is only trimmed after replacing, since the facets are offsets into the original text
+	textWithEntities := &utils.TextWithEntities{
+		Text:     p.Text,
+		Entities: p.Facets,
+	}
+
+	return strings.TrimSpace(textWithEntities.GetReplacedText(maxBytes, 1)[0])
+}
+
+func (m *Media) GetEmbed() *discordgo.MessageEmbed {
+	// Only works for photos
+	return &discordgo.MessageEmbed{
+		Image: &discordgo.MessageEmbedImage{
+			URL: m.URL,
+		},
+		Color: utils.ParseHexColor(consts.ColorBluesky),
+	}
+}
+
+func (u *User) GetEmbed() *discordgo.MessageEmbedAuthor {
+	return &discordgo.MessageEmbedAuthor{
+		Name:    fmt.Sprintf("%s (@%s)", u.Name, u.Handle),
+		URL:     u.URL(),
+		IconURL: u.AvatarURL,
+	}
+}
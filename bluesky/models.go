@@ -0,0 +1,102 @@
+package bluesky
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xIceArcher/go-leah/utils"
+)
+
+type MediaType string
+
+const (
+	MediaTypePhoto MediaType = "photo"
+	MediaTypeVideo MediaType = "video"
+)
+
+type Post struct {
+	ID        string
+	URI       string
+	Author    *User
+	Text      string
+	Timestamp time.Time
+
+	// Links, mentions and hashtags in the text, already replaced with Discord links
+	Facets []*utils.Entity
+
+	Medias []*Media
+
+	IsQuoted   bool
+	QuotedPost *Post
+
+	// ReplyPost is nil if the post being replied to was deleted or is hidden
+	IsReply   bool
+	ReplyPost *Post
+}
+
+func (p *Post) URL() string {
+	return fmt.Sprintf("https://bsky.app/profile/%s/post/%s", p.Author.Handle, p.ID)
+}
+
+func (p *Post) HasPhotos() bool {
+	return len(p.Photos()) > 0
+}
+
+func (p *Post) Photos() []*Media {
+	ret := make([]*Media, 0)
+
+	for _, m := range p.Medias {
+		if m.Type == MediaTypePhoto {
+			ret = append(ret, m)
+		}
+	}
+
+	return ret
+}
+
+func (p *Post) HasVideos() bool {
+	return len(p.Videos()) > 0
+}
+
+func (p *Post) Videos() []*Media {
+	ret := make([]*Media, 0)
+
+	for _, m := range p.Medias {
+		if m.Type == MediaTypeVideo {
+			ret = append(ret, m)
+		}
+	}
+
+	return ret
+}
+
+type User struct {
+	DID       string
+	Handle    string
+	Name      string
+	AvatarURL string
+}
+
+func (u *User) URL() string {
+	return fmt.Sprintf("https://bsky.app/profile/%s", u.Handle)
+}
+
+type Media struct {
+	Type    MediaType
+	URL     string
+	AltText string
+
+	// Videos are only available as HLS playlists from the AppView, so the original is fetched by its blob CID
+	CID string
+}
+
+// ParsePostPath splits the <handle or DID>/post/<rkey> part of a post link
+func ParsePostPath(path string) (actor string, rkey string, ok bool) {
+	actor, rkey, ok = strings.Cut(strings.Trim(path, "/"), "/post/")
+	if !ok || actor == "" || rkey == "" || strings.Contains(rkey, "/") {
+		return "", "", false
+	}
+
+	return actor, rkey, true
+}
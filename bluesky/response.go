@@ -0,0 +1,283 @@
+package bluesky
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/utils"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	rawThreadTypeThreadViewPost = "app.bsky.feed.defs#threadViewPost"
+
+	rawEmbedTypeImages          = "app.bsky.embed.images#view"
+	rawEmbedTypeVideo           = "app.bsky.embed.video#view"
+	rawEmbedTypeRecord          = "app.bsky.embed.record#view"
+	rawEmbedTypeRecordWithMedia = "app.bsky.embed.recordWithMedia#view"
+
+	rawRecordTypeViewRecord = "app.bsky.embed.record#viewRecord"
+
+	rawFacetTypeLink    = "app.bsky.richtext.facet#link"
+	rawFacetTypeMention = "app.bsky.richtext.facet#mention"
+	rawFacetTypeTag     = "app.bsky.richtext.facet#tag"
+)
+
+type xrpcError struct {
+	Name    string `json:"error"`
+	Message string `json:"message"`
+}
+
+func (e *xrpcError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Name, e.Message)
+}
+
+type resolveHandleResponse struct {
+	DID string `json:"did"`
+}
+
+type getPostThreadResponse struct {
+	Thread *rawThread `json:"thread"`
+}
+
+type rawThread struct {
+	Type   string       `json:"$type"`
+	Post   *rawPostView `json:"post"`
+	Parent *rawThread   `json:"parent"`
+}
+
+type rawPostView struct {
+	URI    string         `json:"uri"`
+	Author *rawProfile    `json:"author"`
+	Record *rawPostRecord `json:"record"`
+	Embed  *rawEmbedView  `json:"embed"`
+}
+
+type rawProfile struct {
+	DID         string `json:"did"`
+	Handle      string `json:"handle"`
+	DisplayName string `json:"displayName"`
+	Avatar      string `json:"avatar"`
+}
+
+type rawPostRecord struct {
+	Text      string      `json:"text"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Facets    []*rawFacet `json:"facets"`
+	Reply     *struct {
+		Parent struct {
+			URI string `json:"uri"`
+		} `json:"parent"`
+	} `json:"reply"`
+}
+
+type rawFacet struct {
+	Index struct {
+		ByteStart int `json:"byteStart"`
+		ByteEnd   int `json:"byteEnd"`
+	} `json:"index"`
+	Features []*rawFacetFeature `json:"features"`
+}
+
+type rawFacetFeature struct {
+	Type string `json:"$type"`
+	URI  string `json:"uri"`
+	DID  string `json:"did"`
+	Tag  string `json:"tag"`
+}
+
+type rawEmbedView struct {
+	Type string `json:"$type"`
+
+	// app.bsky.embed.images#view
+	Images []*rawImage `json:"images"`
+
+	// app.bsky.embed.video#view
+	CID       string `json:"cid"`
+	Playlist  string `json:"playlist"`
+	Thumbnail string `json:"thumbnail"`
+	Alt       string `json:"alt"`
+
+	// app.bsky.embed.record#view and app.bsky.embed.recordWithMedia#view
+	Record *rawViewRecord `json:"record"`
+	Media  *rawEmbedView  `json:"media"`
+}
+
+type rawImage struct {
+	Thumb    string `json:"thumb"`
+	Fullsize string `json:"fullsize"`
+	Alt      string `json:"alt"`
+}
+
+// rawViewRecord is either the quoted record itself, or an app.bsky.embed.record#view wrapping it
+type rawViewRecord struct {
+	Type   string          `json:"$type"`
+	URI    string          `json:"uri"`
+	Author *rawProfile     `json:"author"`
+	Value  *rawPostRecord  `json:"value"`
+	Embeds []*rawEmbedView `json:"embeds"`
+
+	Record *rawViewRecord `json:"record"`
+}
+
+func (t *rawThread) ToDTO() *Post {
+	if t == nil || t.Type != rawThreadTypeThreadViewPost || t.Post == nil {
+		return nil
+	}
+
+	post := t.Post.ToDTO()
+	if post != nil && post.IsReply {
+		post.ReplyPost = t.Parent.ToDTO()
+	}
+
+	return post
+}
+
+func (p *rawPostView) ToDTO() *Post {
+	if p == nil || p.Record == nil {
+		return nil
+	}
+
+	return parsePost(p.URI, p.Author, p.Record, p.Embed)
+}
+
+func (r *rawViewRecord) ToDTO() *Post {
+	if r == nil {
+		return nil
+	}
+
+	if r.Type == rawEmbedTypeRecord {
+		return r.Record.ToDTO()
+	}
+
+	// Quoted feeds, lists and deleted posts are not shown
+	if r.Type != rawRecordTypeViewRecord || r.Value == nil {
+		return nil
+	}
+
+	var embed *rawEmbedView
+	if len(r.Embeds) > 0 {
+		embed = r.Embeds[0]
+	}
+
+	return parsePost(r.URI, r.Author, r.Value, embed)
+}
+
+func (p *rawProfile) ToDTO() *User {
+	if p == nil {
+		return &User{}
+	}
+
+	name := p.DisplayName
+	if name == "" {
+		name = p.Handle
+	}
+
+	return &User{
+		DID:       p.DID,
+		Handle:    p.Handle,
+		Name:      name,
+		AvatarURL: p.Avatar,
+	}
+}
+
+func parsePost(uri string, author *rawProfile, record *rawPostRecord, embed *rawEmbedView) *Post {
+	post := &Post{
+		ID:        uri[strings.LastIndex(uri, "/")+1:],
+		URI:       uri,
+		Author:    author.ToDTO(),
+		Text:      record.Text,
+		Timestamp: record.CreatedAt,
+
+		Facets: parseFacets(record.Text, record.Facets),
+
+		IsReply: record.Reply != nil,
+	}
+
+	post.Medias, post.QuotedPost = parseEmbed(embed)
+	post.IsQuoted = post.QuotedPost != nil
+
+	return post
+}
+
+func parseEmbed(embed *rawEmbedView) (medias []*Media, quotedPost *Post) {
+	if embed == nil {
+		return nil, nil
+	}
+
+	switch embed.Type {
+	case rawEmbedTypeImages:
+		for _, image := range embed.Images {
+			medias = append(medias, &Media{
+				Type:    MediaTypePhoto,
+				URL:     image.Fullsize,
+				AltText: image.Alt,
+			})
+		}
+	case rawEmbedTypeVideo:
+		medias = append(medias, &Media{
+			Type:    MediaTypeVideo,
+			URL:     embed.Playlist,
+			AltText: embed.Alt,
+			CID:     embed.CID,
+		})
+	case rawEmbedTypeRecord:
+		quotedPost = embed.Record.ToDTO()
+	case rawEmbedTypeRecordWithMedia:
+		medias, _ = parseEmbed(embed.Media)
+		quotedPost = embed.Record.ToDTO()
+	}
+
+	return medias, quotedPost
+}
+
+// parseFacets converts the facets of the text into entities, which both use byte offsets
+func parseFacets(text string, facets []*rawFacet) []*utils.Entity {
+	entities := make([]*utils.Entity, 0, len(facets))
+
+	for _, facet := range facets {
+		start, end := facet.Index.ByteStart, facet.Index.ByteEnd
+		if start < 0 || end > len(text) || start >= end {
+			continue
+		}
+
+		match := text[start:end]
+		for _, feature := range facet.Features {
+			var link string
+			switch feature.Type {
+			case rawFacetTypeLink:
+				link = feature.URI
+			case rawFacetTypeMention:
+				link = fmt.Sprintf("https://bsky.app/profile/%s", feature.DID)
+			case rawFacetTypeTag:
+				link = fmt.Sprintf("https://bsky.app/hashtag/%s", url.PathEscape(feature.Tag))
+			default:
+				continue
+			}
+
+			entities = append(entities, utils.NewEntityWithReplacement(start, match, discord.GetNamedLink(match, link)))
+			break
+		}
+	}
+
+	// Overlapping facets cannot both be replaced, so keep the first one
+	slices.SortFunc(entities, func(a, b *utils.Entity) bool {
+		return a.Start() < b.Start()
+	})
+
+	nonOverlapping := entities[:0]
+	lastEnd := 0
+	for _, entity := range entities {
+		if entity.Start() < lastEnd {
+			continue
+		}
+
+		nonOverlapping = append(nonOverlapping, entity)
+		lastEnd = entity.End()
+	}
+
+	return nonOverlapping
+}
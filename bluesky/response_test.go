@@ -0,0 +1,108 @@
+package bluesky
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rawThreadJSON = `{
+	"thread": {
+		"$type": "app.bsky.feed.defs#threadViewPost",
+		"post": {
+			"uri": "at://did:plc:alice/app.bsky.feed.post/3kabc",
+			"author": {"did": "did:plc:alice", "handle": "alice.bsky.social", "displayName": "Alice", "avatar": "https://cdn/alice.jpg"},
+			"record": {
+				"text": "héllo @bob.bsky.social see example.com #tag",
+				"createdAt": "2024-05-01T12:00:00.000Z",
+				"facets": [
+					{"index": {"byteStart": 7, "byteEnd": 23}, "features": [{"$type": "app.bsky.richtext.facet#mention", "did": "did:plc:bob"}]},
+					{"index": {"byteStart": 28, "byteEnd": 39}, "features": [{"$type": "app.bsky.richtext.facet#link", "uri": "https://example.com/"}]},
+					{"index": {"byteStart": 40, "byteEnd": 44}, "features": [{"$type": "app.bsky.richtext.facet#tag", "tag": "tag"}]},
+					{"index": {"byteStart": 40, "byteEnd": 100}, "features": [{"$type": "app.bsky.richtext.facet#tag", "tag": "out of range"}]}
+				],
+				"reply": {"parent": {"uri": "at://did:plc:bob/app.bsky.feed.post/3kparent"}}
+			},
+			"embed": {
+				"$type": "app.bsky.embed.recordWithMedia#view",
+				"media": {
+					"$type": "app.bsky.embed.images#view",
+					"images": [
+						{"thumb": "https://cdn/1_thumb.jpg", "fullsize": "https://cdn/1.jpg", "alt": "first"},
+						{"thumb": "https://cdn/2_thumb.jpg", "fullsize": "https://cdn/2.jpg", "alt": ""}
+					]
+				},
+				"record": {
+					"$type": "app.bsky.embed.record#view",
+					"record": {
+						"$type": "app.bsky.embed.record#viewRecord",
+						"uri": "at://did:plc:carol/app.bsky.feed.post/3kquoted",
+						"author": {"did": "did:plc:carol", "handle": "carol.bsky.social"},
+						"value": {"text": "quoted", "createdAt": "2024-04-01T00:00:00Z"},
+						"embeds": [{"$type": "app.bsky.embed.video#view", "cid": "bafyvideo", "playlist": "https://video/playlist.m3u8"}]
+					}
+				}
+			}
+		},
+		"parent": {
+			"$type": "app.bsky.feed.defs#threadViewPost",
+			"post": {
+				"uri": "at://did:plc:bob/app.bsky.feed.post/3kparent",
+				"author": {"did": "did:plc:bob", "handle": "bob.bsky.social", "displayName": "Bob"},
+				"record": {"text": "parent", "createdAt": "2024-04-30T00:00:00Z"}
+			}
+		}
+	}
+}`
+
+func TestParseThread(t *testing.T) {
+	rawResp := &getPostThreadResponse{}
+	require.NoError(t, json.Unmarshal([]byte(rawThreadJSON), rawResp))
+
+	post := rawResp.Thread.ToDTO()
+	require.NotNil(t, post)
+
+	assert.Equal(t, "3kabc", post.ID)
+	assert.Equal(t, "https://bsky.app/profile/alice.bsky.social/post/3kabc", post.URL())
+	assert.Len(t, post.Facets, 3)
+	assert.Equal(t, "héllo [@bob.bsky.social](https://bsky.app/profile/did:plc:bob) see [example.com](https://example.com/) [#tag](https://bsky.app/hashtag/tag)", post.GetTextWithEmbeds(4096))
+
+	require.Len(t, post.Photos(), 2)
+	assert.Equal(t, "first", post.Photos()[0].AltText)
+
+	require.True(t, post.IsQuoted)
+	assert.Equal(t, "carol.bsky.social", post.QuotedPost.Author.Name)
+	require.Len(t, post.QuotedPost.Videos(), 1)
+	assert.Equal(t, "bafyvideo", post.QuotedPost.Videos()[0].CID)
+
+	require.True(t, post.IsReply)
+	require.NotNil(t, post.ReplyPost)
+	assert.Equal(t, "Bob", post.ReplyPost.Author.Name)
+}
+
+func TestGetEmbeds(t *testing.T) {
+	rawResp := &getPostThreadResponse{}
+	require.NoError(t, json.Unmarshal([]byte(rawThreadJSON), rawResp))
+
+	embeds := rawResp.Thread.ToDTO().GetEmbeds()
+	require.Len(t, embeds, 2)
+
+	assert.Equal(t, "Reply to Bob (@bob.bsky.social)", embeds[0].Title)
+	assert.Equal(t, "https://cdn/1.jpg", embeds[0].Image.URL)
+	assert.Equal(t, "Replying to", embeds[0].Fields[0].Name)
+	assert.Equal(t, "Alt Text", embeds[0].Fields[1].Name)
+	assert.Equal(t, "2024-05-01T12:00:00Z", embeds[0].Timestamp)
+	assert.Equal(t, embeds[0].URL, embeds[1].URL)
+}
+
+func TestParsePostPath(t *testing.T) {
+	actor, rkey, ok := ParsePostPath("alice.bsky.social/post/3kabc")
+	assert.True(t, ok)
+	assert.Equal(t, "alice.bsky.social", actor)
+	assert.Equal(t, "3kabc", rkey)
+
+	_, _, ok = ParsePostPath("alice.bsky.social")
+	assert.False(t, ok)
+}
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"regexp"
 	"runtime/debug"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -23,6 +24,7 @@ type Bot struct {
 	cancel context.CancelFunc
 
 	messageHandlers          handler.MessageHandlers
+	messageHandlersMu        sync.RWMutex
 	messageHandlerCancelFunc func()
 
 	// Global settings
@@ -56,20 +58,26 @@ func New(cfg *config.Config, intents discordgo.Intent, logger *zap.SugaredLogger
 		session.Client.Transport = &http.Transport{Proxy: http.ProxyURL(proxyURL)}
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return &Bot{
-		Session: discord.NewSession(session, logger),
+		Session: discord.NewSession(session, logger).WithContext(ctx),
+
+		ctx:    ctx,
+		cancel: cancel,
 
 		filterRegexes: filterRegexes,
 	}, nil
 }
 
 func (b *Bot) Start() error {
-	b.ctx, b.cancel = context.WithCancel(context.Background())
-
 	return b.Session.Open()
 }
 
 func (b *Bot) AddHandler(h handler.MessageHandler) {
+	b.messageHandlersMu.Lock()
+	defer b.messageHandlersMu.Unlock()
+
 	b.messageHandlers = append(b.messageHandlers, h)
 
 	if b.messageHandlerCancelFunc != nil {
@@ -109,10 +117,30 @@ func (b *Bot) AddHandler(h handler.MessageHandler) {
 			}
 		}()
 
-		b.messageHandlers.HandleOne(b.ctx, discord.NewMessageSession(s, m.Message, logger))
+		b.messageHandlersMu.RLock()
+		messageHandlers := b.messageHandlers
+		b.messageHandlersMu.RUnlock()
+
+		messageHandlers.HandleOne(b.ctx, b.Session.WithLogger(logger).WithMessage(m.Message))
 	})
 }
 
+// RemoveHandlers detaches all message handlers from the bot and returns them without stopping them
+func (b *Bot) RemoveHandlers() handler.MessageHandlers {
+	b.messageHandlersMu.Lock()
+	defer b.messageHandlersMu.Unlock()
+
+	if b.messageHandlerCancelFunc != nil {
+		b.messageHandlerCancelFunc()
+		b.messageHandlerCancelFunc = nil
+	}
+
+	messageHandlers := b.messageHandlers
+	b.messageHandlers = nil
+
+	return messageHandlers
+}
+
 func (b *Bot) Stop() {
 	b.cancel()
 }
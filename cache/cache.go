@@ -10,8 +10,14 @@ import (
 	"github.com/xIceArcher/go-leah/config"
 )
 
+const (
+	TypeRedis  = "redis"
+	TypeMemory = "memory"
+)
+
 var (
-	ErrNotFound error = fmt.Errorf("not found")
+	ErrNotFound    error = fmt.Errorf("not found")
+	ErrUnknownType error = fmt.Errorf("unknown cache type")
 )
 
 type Cache interface {
@@ -33,6 +39,21 @@ type ValueWithTTL struct {
 	TTL   time.Duration
 }
 
+func New(cfg *config.Config) (Cache, error) {
+	if cfg.Cache == nil || cfg.Cache.Type == "" {
+		return NewRedisCache(cfg.Redis)
+	}
+
+	switch cfg.Cache.Type {
+	case TypeRedis:
+		return NewRedisCache(cfg.Redis)
+	case TypeMemory:
+		return NewMemoryCache(cfg.Cache)
+	default:
+		return nil, fmt.Errorf("%w %s", ErrUnknownType, cfg.Cache.Type)
+	}
+}
+
 var (
 	redisCache          *redis.Client
 	redisCacheSetupOnce sync.Once
@@ -0,0 +1,377 @@
+package cache
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xIceArcher/go-leah/config"
+	"go.uber.org/zap"
+)
+
+const (
+	memoryCacheCleanupInterval = time.Minute
+
+	// Changes are written to the snapshot at most this often, and on Flush
+	memoryCacheSnapshotInterval = 5 * time.Second
+
+	// Same value Redis returns as the TTL of a key without an expiry
+	ttlNoExpiry = time.Duration(-1)
+)
+
+var (
+	memoryCache   *MemoryCache
+	memoryCacheMu sync.Mutex
+)
+
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]*memoryEntry
+
+	// Guarded by snapshotMu since it changes when the config is reloaded
+	snapshotPath string
+	snapshotMu   sync.Mutex
+
+	// Whether there are changes that are not in the snapshot yet
+	isDirty atomic.Bool
+}
+
+type memoryEntry struct {
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (e *memoryEntry) isExpired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+func (e *memoryEntry) ttl(now time.Time) time.Duration {
+	if e.ExpiresAt.IsZero() {
+		return ttlNoExpiry
+	}
+	return e.ExpiresAt.Sub(now)
+}
+
+// NewMemoryCache returns a cache that lives in process memory.
+// Like the Redis cache, every caller shares the same underlying store.
+// If the snapshot path changed since the store was created, the store is written to the new path from then on.
+func NewMemoryCache(cfg *config.CacheConfig) (Cache, error) {
+	memoryCacheMu.Lock()
+	defer memoryCacheMu.Unlock()
+
+	if memoryCache != nil {
+		if err := memoryCache.setSnapshotPath(cfg.SnapshotPath); err != nil {
+			return nil, err
+		}
+		return memoryCache, nil
+	}
+
+	c, err := newMemoryCache(cfg.SnapshotPath)
+	if err != nil {
+		return nil, err
+	}
+
+	memoryCache = c
+	go memoryCache.backgroundTask()
+
+	return memoryCache, nil
+}
+
+// FlushMemoryCache writes pending changes of the memory cache to its snapshot, if the memory cache is in use
+func FlushMemoryCache() error {
+	memoryCacheMu.Lock()
+	defer memoryCacheMu.Unlock()
+
+	if memoryCache == nil {
+		return nil
+	}
+	return memoryCache.Flush()
+}
+
+func newMemoryCache(snapshotPath string) (*MemoryCache, error) {
+	c := &MemoryCache{
+		entries:      make(map[string]*memoryEntry),
+		snapshotPath: snapshotPath,
+	}
+
+	if err := c.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, val interface{}) error {
+	return c.set(key, val, 0, false)
+}
+
+func (c *MemoryCache) SetWithExpiry(ctx context.Context, key string, val interface{}, expiration time.Duration) error {
+	return c.set(key, val, expiration, false)
+}
+
+func (c *MemoryCache) SetKeepTTL(ctx context.Context, key string, val interface{}) error {
+	return c.set(key, val, 0, true)
+}
+
+func (c *MemoryCache) set(key string, val interface{}, expiration time.Duration, keepTTL bool) error {
+	valStr, err := formatValue(val)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	entry := &memoryEntry{Value: valStr}
+	if expiration > 0 {
+		entry.ExpiresAt = now.Add(expiration)
+	}
+
+	c.mu.Lock()
+	if oldEntry, ok := c.entries[key]; ok && keepTTL && !oldEntry.isExpired(now) {
+		entry.ExpiresAt = oldEntry.ExpiresAt
+	}
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	c.isDirty.Store(true)
+	return nil
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string) (interface{}, error) {
+	val, err := c.GetWithTTL(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return val.Value, nil
+}
+
+func (c *MemoryCache) GetWithTTL(ctx context.Context, key string) (*ValueWithTTL, error) {
+	now := time.Now()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[key]
+	if !ok || entry.isExpired(now) {
+		return nil, ErrNotFound
+	}
+
+	return &ValueWithTTL{
+		Value: entry.Value,
+		TTL:   entry.ttl(now),
+	}, nil
+}
+
+func (c *MemoryCache) GetByPrefix(ctx context.Context, prefix string) (map[string]interface{}, error) {
+	mp, err := c.GetByPrefixWithTTL(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]interface{}, len(mp))
+	for key, val := range mp {
+		ret[key] = val.Value
+	}
+
+	return ret, nil
+}
+
+func (c *MemoryCache) GetByPrefixWithTTL(ctx context.Context, prefix string) (map[string]*ValueWithTTL, error) {
+	now := time.Now()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ret := make(map[string]*ValueWithTTL)
+	for key, entry := range c.entries {
+		if !strings.HasPrefix(key, prefix) || entry.isExpired(now) {
+			continue
+		}
+
+		ret[key] = &ValueWithTTL{
+			Value: entry.Value,
+			TTL:   entry.ttl(now),
+		}
+	}
+
+	return ret, nil
+}
+
+func (c *MemoryCache) Clear(ctx context.Context, key ...string) error {
+	c.mu.Lock()
+	for _, k := range key {
+		delete(c.entries, k)
+	}
+	c.mu.Unlock()
+
+	c.isDirty.Store(true)
+	return nil
+}
+
+// Flush writes the cache to its snapshot if it changed since the last snapshot
+func (c *MemoryCache) Flush() error {
+	if !c.isDirty.Swap(false) {
+		return nil
+	}
+
+	if err := c.saveSnapshot(); err != nil {
+		c.isDirty.Store(true)
+		return err
+	}
+
+	return nil
+}
+
+func (c *MemoryCache) backgroundTask() {
+	cleanupTicker := time.NewTicker(memoryCacheCleanupInterval)
+	defer cleanupTicker.Stop()
+
+	snapshotTicker := time.NewTicker(memoryCacheSnapshotInterval)
+	defer snapshotTicker.Stop()
+
+	for {
+		select {
+		case <-cleanupTicker.C:
+			if c.removeExpired() > 0 {
+				c.isDirty.Store(true)
+			}
+		case <-snapshotTicker.C:
+			if err := c.Flush(); err != nil {
+				zap.S().With(zap.Error(err)).Warn("Failed to save cache snapshot")
+			}
+		}
+	}
+}
+
+func (c *MemoryCache) setSnapshotPath(snapshotPath string) error {
+	c.snapshotMu.Lock()
+	if c.snapshotPath == snapshotPath {
+		c.snapshotMu.Unlock()
+		return nil
+	}
+	c.snapshotPath = snapshotPath
+	c.snapshotMu.Unlock()
+
+	// Write everything to the new path right away
+	c.isDirty.Store(true)
+	return c.Flush()
+}
+
+func (c *MemoryCache) removeExpired() (numRemoved int) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		if entry.isExpired(now) {
+			delete(c.entries, key)
+			numRemoved++
+		}
+	}
+
+	return numRemoved
+}
+
+func (c *MemoryCache) loadSnapshot() error {
+	if c.snapshotPath == "" {
+		return nil
+	}
+
+	snapshotBytes, err := os.ReadFile(c.snapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	entries := make(map[string]*memoryEntry)
+	if err := json.Unmarshal(snapshotBytes, &entries); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for key, entry := range entries {
+		if !entry.isExpired(now) {
+			c.entries[key] = entry
+		}
+	}
+
+	return nil
+}
+
+func (c *MemoryCache) saveSnapshot() error {
+	// Serialize snapshots so that an older state never overwrites a newer one
+	c.snapshotMu.Lock()
+	defer c.snapshotMu.Unlock()
+
+	if c.snapshotPath == "" {
+		return nil
+	}
+
+	c.mu.RLock()
+	snapshotBytes, err := json.Marshal(c.entries)
+	c.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a half-written snapshot behind
+	tmpFile, err := os.CreateTemp(filepath.Dir(c.snapshotPath), filepath.Base(c.snapshotPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(snapshotBytes); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), c.snapshotPath)
+}
+
+// formatValue converts values to strings the same way the Redis client does,
+// so callers see the same types regardless of which cache is in use
+func formatValue(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
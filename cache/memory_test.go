@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCacheSetGet(t *testing.T) {
+	c, err := newMemoryCache("")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "a", "1"))
+	require.NoError(t, c.Set(ctx, "b", []byte("2")))
+	require.NoError(t, c.Set(ctx, "c", 3))
+
+	for key, expected := range map[string]string{"a": "1", "b": "2", "c": "3"} {
+		val, err := c.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, expected, val)
+	}
+
+	_, err = c.Get(ctx, "d")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, c.Clear(ctx, "a", "b"))
+	_, err = c.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryCacheTTL(t *testing.T) {
+	c, err := newMemoryCache("")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.NoError(t, c.SetWithExpiry(ctx, "expired", "x", time.Nanosecond))
+	require.NoError(t, c.SetWithExpiry(ctx, "expiring", "x", time.Hour))
+	require.NoError(t, c.Set(ctx, "permanent", "x"))
+	time.Sleep(time.Millisecond)
+
+	_, err = c.Get(ctx, "expired")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	val, err := c.GetWithTTL(ctx, "expiring")
+	require.NoError(t, err)
+	assert.InDelta(t, time.Hour, val.TTL, float64(time.Second))
+
+	val, err = c.GetWithTTL(ctx, "permanent")
+	require.NoError(t, err)
+	assert.Equal(t, ttlNoExpiry, val.TTL)
+
+	require.NoError(t, c.SetKeepTTL(ctx, "expiring", "y"))
+	val, err = c.GetWithTTL(ctx, "expiring")
+	require.NoError(t, err)
+	assert.Equal(t, "y", val.Value)
+	assert.InDelta(t, time.Hour, val.TTL, float64(time.Second))
+
+	require.NoError(t, c.SetKeepTTL(ctx, "new", "z"))
+	val, err = c.GetWithTTL(ctx, "new")
+	require.NoError(t, err)
+	assert.Equal(t, ttlNoExpiry, val.TTL)
+}
+
+func TestMemoryCacheGetByPrefix(t *testing.T) {
+	c, err := newMemoryCache("")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "prefix/a", "1"))
+	require.NoError(t, c.Set(ctx, "prefix/b", "2"))
+	require.NoError(t, c.SetWithExpiry(ctx, "prefix/c", "3", time.Nanosecond))
+	require.NoError(t, c.Set(ctx, "other/a", "4"))
+	time.Sleep(time.Millisecond)
+
+	vals, err := c.GetByPrefix(ctx, "prefix/")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"prefix/a": "1", "prefix/b": "2"}, vals)
+
+	valsWithTTL, err := c.GetByPrefixWithTTL(ctx, "other/")
+	require.NoError(t, err)
+	assert.Len(t, valsWithTTL, 1)
+	assert.Equal(t, "4", valsWithTTL["other/a"].Value)
+}
+
+func TestMemoryCacheSnapshot(t *testing.T) {
+	snapshotPath := filepath.Join(t.TempDir(), "cache.json")
+	ctx := context.Background()
+
+	c, err := newMemoryCache(snapshotPath)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set(ctx, "a", "1"))
+	require.NoError(t, c.SetWithExpiry(ctx, "b", "2", time.Hour))
+	require.NoError(t, c.SetWithExpiry(ctx, "c", "3", time.Nanosecond))
+	time.Sleep(time.Millisecond)
+
+	// Changes are only written when the cache is flushed
+	assert.NoFileExists(t, snapshotPath)
+	require.NoError(t, c.Flush())
+
+	restored, err := newMemoryCache(snapshotPath)
+	require.NoError(t, err)
+
+	vals, err := restored.GetByPrefix(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "1", "b": "2"}, vals)
+
+	val, err := restored.GetWithTTL(ctx, "b")
+	require.NoError(t, err)
+	assert.InDelta(t, time.Hour, val.TTL, float64(time.Second))
+}
+
+func TestMemoryCacheSetSnapshotPath(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	c, err := newMemoryCache(filepath.Join(dir, "old.json"))
+	require.NoError(t, err)
+	require.NoError(t, c.Set(ctx, "a", "1"))
+
+	newSnapshotPath := filepath.Join(dir, "new.json")
+	require.NoError(t, c.setSnapshotPath(newSnapshotPath))
+	assert.NoFileExists(t, filepath.Join(dir, "old.json"))
+
+	restored, err := newMemoryCache(newSnapshotPath)
+	require.NoError(t, err)
+
+	val, err := restored.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "1", val)
+}
@@ -3,9 +3,11 @@ package cog
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/consts"
 	"github.com/xIceArcher/go-leah/discord"
 )
 
@@ -18,6 +20,8 @@ func NewAdminCog(cfg *config.Config, s *discord.Session) (Cog, error) {
 
 	c.allCommands = map[string]CommandFunc{
 		"servers": c.Servers,
+		"restart": c.Restart,
+		"reload":  c.Reload,
 	}
 
 	return c, nil
@@ -31,3 +35,29 @@ func (c *AdminCog) Servers(ctx context.Context, s *discord.MessageSession, args
 
 	s.SendMessage(fmt.Sprintf("Active servers: %s", strings.Join(guilds, ", ")))
 }
+
+func (c *AdminCog) Restart(ctx context.Context, s *discord.MessageSession, args []string) {
+	s.SendMessage("Restarting...")
+
+	if err := signalSelf(consts.SignalRestart); err != nil {
+		s.SendInternalError(err)
+	}
+}
+
+func (c *AdminCog) Reload(ctx context.Context, s *discord.MessageSession, args []string) {
+	s.SendMessage("Reloading config...")
+
+	if err := signalSelf(consts.SignalReload); err != nil {
+		s.SendInternalError(err)
+	}
+}
+
+// signalSelf hands the request over to the main goroutine, since handlers cannot stop themselves
+func signalSelf(sig os.Signal) error {
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		return err
+	}
+
+	return p.Signal(sig)
+}
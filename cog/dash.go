@@ -0,0 +1,447 @@
+package cog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/jessevdk/go-flags"
+	"github.com/xIceArcher/go-leah/consts"
+	"github.com/xIceArcher/go-leah/dash"
+	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/hls"
+	httpclient "github.com/xIceArcher/go-leah/http"
+	"github.com/xIceArcher/go-leah/utils"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	dashDefaultFormat    = "mp4"
+	dashMinUpdatePeriod  = 2 * time.Second
+	dashManifestMaxError = 10
+)
+
+type DashOptions struct {
+	FileName  string
+	Format    string
+	Delete    bool
+	MaxHeight int
+
+	Limits DownloadLimits
+}
+
+// dashRun is everything downloaded from one period of a DASH manifest
+type dashRun struct {
+	PeriodID string
+	Tracks   []*dashTrack
+}
+
+type dashTrack struct {
+	ContentType      string
+	RepresentationID string
+	Extension        string
+
+	InitFileName string
+	Segments     map[int64]string
+}
+
+// sortedFileNames returns the initialization segment followed by the segments that finished downloading in order
+func (t *dashTrack) sortedFileNames() []string {
+	keys := make([]int64, 0, len(t.Segments))
+	for key := range t.Segments {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	fileNames := make([]string, 0, len(keys)+1)
+	if t.InitFileName != "" {
+		fileNames = append(fileNames, t.InitFileName)
+	}
+
+	for _, key := range keys {
+		if _, err := os.Stat(t.Segments[key]); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		fileNames = append(fileNames, t.Segments[key])
+	}
+
+	return fileNames
+}
+
+func (c *DownloadCog) Dash(ctx context.Context, s *discord.MessageSession, args []string) {
+	type Args struct {
+		HTTPHeader  string `short:"h" long:"header"`
+		HTTPCookies string `short:"c" long:"cookie"`
+		Delete      bool   `short:"d" long:"delete"`
+		Remux       string `short:"r" long:"remux" choice:"mp4" choice:"mkv"`
+		MaxHeight   int    `long:"max-height"`
+
+		Workers        int    `long:"workers"`
+		BandwidthLimit string `long:"limit"`
+		SkipHead       bool   `long:"skip-head"`
+
+		Args struct {
+			MPDURLStr string `required:"yes"`
+			FileName  string
+		} `positional-args:"yes"`
+	}
+
+	commandArgs := &Args{}
+	_, err := flags.NewParser(commandArgs, flags.IgnoreUnknown).ParseArgs(args)
+	if err != nil {
+		s.SendError(err)
+		return
+	}
+
+	if commandArgs.Args.FileName == "" {
+		s.SendErrorf("Usage: dash [options] <MPD URL> <file name>\nUnlike streamlink downloads, DASH downloads are not saved and are not resumed after a restart")
+		return
+	}
+
+	limits, err := parseDownloadLimits(commandArgs.Workers, commandArgs.BandwidthLimit, commandArgs.SkipHead)
+	if err != nil {
+		s.SendError(err)
+		return
+	}
+
+	mpdUrl, err := url.Parse(commandArgs.Args.MPDURLStr)
+	if err != nil {
+		s.SendError(err)
+		return
+	}
+
+	client := httpclient.NewClientWithHeadersAndCookiesStr(commandArgs.HTTPHeader, commandArgs.HTTPCookies)
+	c.startDashJob(s, client, mpdUrl, &DashOptions{
+		FileName:  commandArgs.Args.FileName,
+		Format:    commandArgs.Remux,
+		Delete:    commandArgs.Delete,
+		MaxHeight: commandArgs.MaxHeight,
+
+		Limits: limits,
+	})
+}
+
+func isDashURL(u *url.URL) bool {
+	return path.Ext(u.Path) == ".mpd"
+}
+
+// startDashJob downloads the DASH manifest at mpdUrl and muxes its video and audio into a single file per period
+func (c *DownloadCog) startDashJob(s *discord.MessageSession, client *retryablehttp.Client, mpdUrl *url.URL, opts *DashOptions) {
+	if opts.Format == "" {
+		opts.Format = dashDefaultFormat
+	}
+
+	mpd, err := getManifest(client, mpdUrl)
+	if err != nil {
+		s.SendError(err)
+		return
+	}
+
+	if len(mpd.Periods) == 0 {
+		s.SendErrorf("Manifest has no periods!")
+		return
+	}
+
+	// Fail early if nothing in the manifest can be downloaded
+	if _, _, err := mpd.Periods[len(mpd.Periods)-1].SelectTracks(opts.MaxHeight); err != nil {
+		s.SendError(err)
+		return
+	}
+
+	uploadFileName := replaceExtension(opts.FileName, opts.Format)
+	if c.storage != nil {
+		exists, err := checkFileExists(c.storage, uploadFileName)
+		if err != nil {
+			s.SendError(err)
+			return
+		}
+
+		if exists {
+			s.SendMessage("File %s already exists!", uploadFileName)
+			return
+		}
+	}
+
+	dir := fmt.Sprintf("%s-%s", time.Now().Format(consts.TimeFormatYYMMDDHHMMSS), s.ChannelID)
+	if err := os.Mkdir(dir, os.ModePerm); err != nil {
+		s.SendError(err)
+		return
+	}
+
+	downloadJob := c.jobs.add(c.ctx, DownloadJobTypeDash, opts.FileName, s.Author.ID, s.ChannelID)
+	s.SendMessage("Starting to download %s as job #%v", opts.FileName, downloadJob.ID)
+
+	c.wg.Add(1)
+	c.runDashJob(s, client, mpdUrl, dir, opts, downloadJob)
+}
+
+func (c *DownloadCog) runDashJob(s *discord.MessageSession, client *retryablehttp.Client, mpdUrl *url.URL, dir string, opts *DashOptions, downloadJob *DownloadJob) {
+	defer c.wg.Done()
+	defer c.jobs.remove(downloadJob)
+
+	bar, err := s.SendBytesProgressBar(1, "Downloading")
+	if err != nil {
+		s.SendError(err)
+		return
+	}
+	downloadJob.SetProgressBar(bar)
+
+	runs, err := handleDashManifest(downloadJob.ctx, s, client, mpdUrl, dir, opts.MaxHeight, c.downloaderOptions(&opts.Limits), bar)
+	if downloadJob.ctx.Err() != nil {
+		c.interruptDashJob(s, opts, downloadJob)
+		return
+	} else if err != nil {
+		s.SendError(err)
+		return
+	}
+
+	downloadJob.SetStatus(DownloadJobStatusRemuxing)
+	s.SendMessage("Muxing %s...", opts.FileName)
+
+	filePaths, err := muxDashRuns(downloadJob.ctx, runs, dir, opts.Format)
+	if downloadJob.ctx.Err() != nil {
+		c.interruptDashJob(s, opts, downloadJob)
+		return
+	} else if err != nil {
+		s.SendError(err)
+		return
+	}
+
+	if c.storage != nil {
+		uploadRuns := make([][]string, 0, len(filePaths))
+		for _, filePath := range filePaths {
+			uploadRuns = append(uploadRuns, []string{filePath})
+		}
+
+		downloadJob.SetStatus(DownloadJobStatusUploading)
+		if _, err := handleUpload(c.storage, s, replaceExtension(opts.FileName, opts.Format), uploadRuns); err != nil {
+			s.SendError(err)
+			return
+		}
+
+		if opts.Delete {
+			s.SendMessage("Clearing disk space...")
+			if err := os.RemoveAll(dir); err != nil {
+				s.SendError(err)
+				return
+			}
+		}
+	}
+
+	c.Disk(c.ctx, s, []string{})
+}
+
+// interruptDashJob reports why the job stopped. DASH jobs are not resumed after a restart.
+func (c *DownloadCog) interruptDashJob(s *discord.MessageSession, opts *DashOptions, downloadJob *DownloadJob) {
+	if downloadJob.IsCancelled() {
+		s.SendMessage("Cancelled download of %s", opts.FileName)
+		return
+	}
+
+	s.SendMessage("Download of %s stopped since the bot is shutting down", opts.FileName)
+}
+
+// handleDashManifest downloads the best video and audio of every period in the manifest,
+// refreshing live manifests until they end or ctx is cancelled
+func handleDashManifest(ctx context.Context, s *discord.MessageSession, client *retryablehttp.Client, mpdUrl *url.URL, dir string, maxHeight int, downloaderOpts *hls.DownloaderOptions, bar *discord.ProgressBar) ([]*dashRun, error) {
+	downloader := hls.NewDownloader(ctx, client, downloaderOpts, bar, s.Logger)
+	defer func() {
+		downloader.Close()
+		bar.Add(1)
+	}()
+
+	runs := make([]*dashRun, 0)
+	runsByPeriodID := make(map[string]*dashRun)
+
+	var sleepTime time.Duration
+	errCount := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.Logger.Info("Context cancelled, download aborted")
+			return runs, ctx.Err()
+		case <-time.After(sleepTime):
+			isEnded, err := func() (bool, error) {
+				mpd, err := getManifest(client, mpdUrl)
+				if err != nil {
+					return false, err
+				}
+
+				now := time.Now()
+				for i, period := range mpd.Periods {
+					periodID := period.ID
+					if periodID == "" {
+						periodID = strconv.Itoa(i)
+					}
+
+					run, ok := runsByPeriodID[periodID]
+					if !ok {
+						video, audio, err := period.SelectTracks(maxHeight)
+						if err != nil {
+							return false, err
+						}
+
+						run = &dashRun{PeriodID: periodID}
+						for _, track := range []*dash.Track{video, audio} {
+							if track == nil {
+								continue
+							}
+
+							run.Tracks = append(run.Tracks, &dashTrack{
+								ContentType:      track.ContentType(),
+								RepresentationID: track.Representation.ID,
+								Extension:        track.Extension(),
+								Segments:         make(map[int64]string),
+							})
+						}
+
+						runs = append(runs, run)
+						runsByPeriodID[periodID] = run
+					}
+
+					runNo := slices.Index(runs, run)
+					for _, track := range run.Tracks {
+						mpdTrack, ok := period.Track(track.RepresentationID)
+						if !ok {
+							continue
+						}
+
+						initSegment, segments, err := mpd.Segments(mpdUrl, mpdTrack, now)
+						if err != nil {
+							return false, err
+						}
+
+						if initSegment != nil && track.InitFileName == "" {
+							fileName := filepath.Join(dir, fmt.Sprintf("%s_%v_init%s", track.ContentType, runNo, track.Extension))
+							if err := hls.DownloadRange(ctx, client, initSegment.URL, initSegment.Offset, initSegment.Limit, fileName); err != nil {
+								return false, err
+							}
+							track.InitFileName = fileName
+						}
+
+						for _, segment := range segments {
+							if _, ok := track.Segments[segment.Key]; ok {
+								continue
+							}
+
+							fileName := filepath.Join(dir, fmt.Sprintf("%s_%v_%v%s", track.ContentType, runNo, segment.Key, path.Ext(segment.URL.Path)))
+							downloader.Add(&hls.Segment{
+								FileName: fileName,
+								URL:      segment.URL,
+							})
+
+							track.Segments[segment.Key] = fileName
+						}
+					}
+				}
+
+				if !mpd.IsDynamic() {
+					return true, nil
+				}
+
+				sleepTime = dashMinUpdatePeriod
+				if minimumUpdatePeriod, ok := utils.ParseISODuration(mpd.MinimumUpdatePeriod); ok && minimumUpdatePeriod > sleepTime {
+					sleepTime = minimumUpdatePeriod
+				}
+
+				return false, nil
+			}()
+			if err != nil {
+				if errors.Is(err, dash.ErrProtected) || errors.Is(err, dash.ErrUnsupportedSegments) {
+					return runs, err
+				}
+
+				s.Logger.With(zap.Int("errCount", errCount)).Error(err)
+				errCount++
+
+				if errCount >= dashManifestMaxError {
+					s.Logger.Error("Too many errors when getting MPD, aborting...")
+					return runs, nil
+				}
+
+				sleepTime = dashMinUpdatePeriod
+				break
+			}
+
+			errCount = 0
+
+			if isEnded {
+				s.Logger.Info("Stream closed")
+				return runs, nil
+			}
+		}
+	}
+}
+
+// muxDashRuns muxes the tracks of each run into a single file of the given format
+func muxDashRuns(ctx context.Context, runs []*dashRun, dir string, format string) ([]string, error) {
+	filePaths := make([]string, 0, len(runs))
+	for runNo, run := range runs {
+		trackFilePaths := make(map[string]string)
+		for _, track := range run.Tracks {
+			fileNames := track.sortedFileNames()
+			if len(fileNames) == 0 {
+				continue
+			}
+
+			trackFilePath := filepath.Join(dir, fmt.Sprintf("%s_%v%s", track.ContentType, runNo, track.Extension))
+			if err := utils.ConcatFiles(trackFilePath, fileNames); err != nil {
+				return nil, err
+			}
+			trackFilePaths[track.ContentType] = trackFilePath
+		}
+
+		if len(trackFilePaths) == 0 {
+			continue
+		}
+
+		outPath := filepath.Join(dir, fmt.Sprintf("run_%v.%s", runNo+1, format))
+
+		videoFilePath, hasVideo := trackFilePaths[dash.ContentTypeVideo]
+		audioFilePath, hasAudio := trackFilePaths[dash.ContentTypeAudio]
+
+		var err error
+		if hasVideo && hasAudio {
+			err = utils.MuxVideoAndAudio(ctx, videoFilePath, audioFilePath, outPath)
+		} else if hasVideo {
+			err = utils.RemuxVideo(ctx, videoFilePath, outPath)
+		} else {
+			err = utils.RemuxVideo(ctx, audioFilePath, outPath)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		filePaths = append(filePaths, outPath)
+	}
+
+	if len(filePaths) == 0 {
+		return nil, fmt.Errorf("No segments were downloaded!")
+	}
+
+	return filePaths, nil
+}
+
+func getManifest(client *retryablehttp.Client, mpdUrl *url.URL) (*dash.MPD, error) {
+	resp, err := client.Get(mpdUrl.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP request to %s returned status %v", resp.Request.URL.String(), resp.StatusCode)
+	}
+
+	return dash.Parse(resp.Body)
+}
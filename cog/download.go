@@ -2,33 +2,31 @@ package cog
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"encoding/binary"
+	"errors"
 	"fmt"
-	"io"
-	"math/big"
-	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/docker/go-units"
 	"github.com/grafov/m3u8"
-	"github.com/hashicorp/go-retryablehttp"
 	"github.com/jessevdk/go-flags"
 	"github.com/ricochet2200/go-disk-usage/du"
+	"github.com/xIceArcher/go-leah/cache"
 	"github.com/xIceArcher/go-leah/config"
 	"github.com/xIceArcher/go-leah/consts"
 	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/hls"
 	httpclient "github.com/xIceArcher/go-leah/http"
-	"github.com/xIceArcher/go-leah/qnap"
+	"github.com/xIceArcher/go-leah/matcher"
+	"github.com/xIceArcher/go-leah/storage"
+	"github.com/xIceArcher/go-leah/throttle"
 	"github.com/xIceArcher/go-leah/weibo"
+	"github.com/xIceArcher/go-leah/youtube"
 	"go.uber.org/zap"
 	"golang.org/x/exp/slices"
 )
@@ -36,20 +34,76 @@ import (
 type DownloadCog struct {
 	GenericCog
 
-	qnapConfig *config.QNAPConfig
+	storage storage.Storage
+	adminID string
+	cache   cache.Cache
+	session *discord.Session
+	jobs    *downloadJobs
+
+	// Nil if YouTube is not configured
+	youtubeRecorder *matcher.YoutubeLiveStreamMatcher
+
+	// Shared by all jobs so that they are limited by the configured bandwidth together
+	downloaderOpts *hls.DownloaderOptions
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 func NewDownloadCog(cfg *config.Config, s *discord.Session) (Cog, error) {
+	cache, err := cache.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a storage, downloaded files are left in the working directory
+	downloadStorage, err := storage.New(cfg, s.Logger)
+	if errors.Is(err, storage.ErrNotConfigured) {
+		s.Logger.Info("No storage configured, downloads will not be uploaded")
+	} else if err != nil {
+		return nil, err
+	}
+
+	downloaderOpts, err := hls.NewDownloaderOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
 	c := &DownloadCog{
-		qnapConfig: cfg.QNAP,
+		storage: downloadStorage,
+		adminID: cfg.Discord.AdminID,
+		cache:   cache,
+		session: s,
+		jobs:    newDownloadJobs(),
+
+		downloaderOpts: downloaderOpts,
+
+		ctx:    ctx,
+		cancel: cancel,
 	}
 
 	c.allCommands = map[string]CommandFunc{
 		"disk":       c.Disk,
 		"streamlink": c.Streamlink,
+		"dash":       c.Dash,
 		"weibo":      c.Weibo,
+		"ytrecord":   c.YoutubeRecord,
+		"jobs":       c.Jobs,
+		"job":        c.Job,
+		"cancel":     c.Cancel,
+	}
+
+	if cfg.Google != nil {
+		c.youtubeRecorder, err = matcher.NewYoutubeRecorder(cfg, s)
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	c.resumeStreamlinkJobs()
 	return c, nil
 }
 
@@ -64,10 +118,24 @@ func (c *DownloadCog) Streamlink(ctx context.Context, s *discord.MessageSession,
 		HTTPHeader  string `short:"h" long:"header"`
 		HTTPCookies string `short:"c" long:"cookie"`
 		Delete      bool   `short:"d" long:"delete"`
+		Remux       string `short:"r" long:"remux" choice:"mp4" choice:"mkv"`
+		Merge       bool   `short:"m" long:"merge"`
+		Report      bool   `long:"report"`
+
+		MaxHeight int     `long:"max-height"`
+		Codec     string  `long:"codec"`
+		MaxFPS    float64 `long:"fps"`
+		Variant   string  `long:"variant"`
+		AudioOnly bool    `long:"audio-only"`
+		List      bool    `long:"list"`
+
+		Workers        int    `long:"workers"`
+		BandwidthLimit string `long:"limit"`
+		SkipHead       bool   `long:"skip-head"`
 
 		Args struct {
 			M3U8URLStr string `required:"yes"`
-			FileName   string `required:"yes"`
+			FileName   string
 		} `positional-args:"yes"`
 	}
 
@@ -78,421 +146,211 @@ func (c *DownloadCog) Streamlink(ctx context.Context, s *discord.MessageSession,
 		return
 	}
 
+	if commandArgs.Args.FileName == "" && !commandArgs.List {
+		s.SendErrorf("Usage: streamlink [options] <m3u8 URL> <file name>")
+		return
+	}
+
+	if commandArgs.Merge && commandArgs.Remux == "" {
+		s.SendErrorf("Runs can only be merged when remuxing!")
+		return
+	}
+
+	limits, err := parseDownloadLimits(commandArgs.Workers, commandArgs.BandwidthLimit, commandArgs.SkipHead)
+	if err != nil {
+		s.SendError(err)
+		return
+	}
+
 	client := httpclient.NewClientWithHeadersAndCookiesStr(commandArgs.HTTPHeader, commandArgs.HTTPCookies)
-	resp, err := client.Get(commandArgs.Args.M3U8URLStr)
+
+	m3u8Url, err := url.Parse(commandArgs.Args.M3U8URLStr)
 	if err != nil {
 		s.SendError(err)
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		s.SendErrorf("HTTP request to %s returned status %v", resp.Request.URL.String(), resp.StatusCode)
+	if isDashURL(m3u8Url) {
+		if commandArgs.List {
+			s.SendErrorf("Variants of DASH manifests cannot be listed!")
+			return
+		}
+
+		c.startDashJob(s, client, m3u8Url, &DashOptions{
+			FileName:  commandArgs.Args.FileName,
+			Format:    commandArgs.Remux,
+			Delete:    commandArgs.Delete,
+			MaxHeight: commandArgs.MaxHeight,
+
+			Limits: limits,
+		})
 		return
 	}
 
-	genericPlaylist, _, err := m3u8.DecodeFrom(resp.Body, true)
+	genericPlaylist, err := hls.GetPlaylist(client, m3u8Url)
 	if err != nil {
 		s.SendError(err)
 		return
 	}
 
-	switch playlist := genericPlaylist.(type) {
-	case *m3u8.MasterPlaylist:
-		var bestVariant *m3u8.Variant
-		for _, variant := range playlist.Variants {
-			if bestVariant == nil || variant.Bandwidth > bestVariant.Bandwidth {
-				bestVariant = variant
-			}
+	if master, ok := genericPlaylist.(*m3u8.MasterPlaylist); ok {
+		if commandArgs.List {
+			s.SendMessage(formatVariants(master))
+			return
 		}
 
-		m3u8Url, err := url.Parse(commandArgs.Args.M3U8URLStr)
+		mediaURI, err := selectMediaURI(master, &VariantOptions{
+			MaxHeight: commandArgs.MaxHeight,
+			Codec:     commandArgs.Codec,
+			MaxFPS:    commandArgs.MaxFPS,
+			Name:      commandArgs.Variant,
+			AudioOnly: commandArgs.AudioOnly,
+		})
 		if err != nil {
 			s.SendError(err)
 			return
 		}
 
-		mediaUrl, err := m3u8Url.Parse(bestVariant.URI)
+		m3u8Url, err = m3u8Url.Parse(mediaURI)
 		if err != nil {
 			s.SendError(err)
 			return
 		}
 
-		args[len(args)-2] = mediaUrl.String()
-		c.Streamlink(ctx, s, args)
-	case *m3u8.MediaPlaylist:
-		dir := fmt.Sprintf("%s-%s", time.Now().Format(consts.TimeFormatYYMMDDHHMMSS), s.ChannelID)
-		if err := os.Mkdir(dir, os.ModePerm); err != nil {
+		genericPlaylist, err = hls.GetPlaylist(client, m3u8Url)
+		if err != nil {
 			s.SendError(err)
 			return
 		}
+	} else if commandArgs.List {
+		s.SendErrorf("Not a master playlist, there are no variants to list!")
+		return
+	}
 
-		if c.qnapConfig.IsEnabled {
-			exists, err := checkFileExists(ctx, s, c.qnapConfig, commandArgs.Args.FileName)
-			if err != nil {
-				s.SendError(err)
-				return
-			}
+	_, ok := genericPlaylist.(*m3u8.MediaPlaylist)
+	if !ok {
+		s.SendErrorf("Not a media playlist!")
+		return
+	}
 
-			if exists {
-				s.SendMessage("File %s already exists!", commandArgs.Args.FileName)
-				return
-			}
-		}
+	dir := fmt.Sprintf("%s-%s", time.Now().Format(consts.TimeFormatYYMMDDHHMMSS), s.ChannelID)
+	if err := os.Mkdir(dir, os.ModePerm); err != nil {
+		s.SendError(err)
+		return
+	}
 
-		s.SendMessage("Starting to download %s", commandArgs.Args.FileName)
+	if c.storage != nil {
+		uploadFileName := commandArgs.Args.FileName
+		if commandArgs.Remux != "" {
+			uploadFileName = replaceExtension(uploadFileName, commandArgs.Remux)
+		}
 
-		downloadedRuns, err := handleMediaPlaylist(ctx, s, client, commandArgs.Args.M3U8URLStr, playlist.Key, dir)
+		exists, err := checkFileExists(c.storage, uploadFileName)
 		if err != nil {
 			s.SendError(err)
 			return
 		}
 
-		if c.qnapConfig.IsEnabled {
-			if _, err := handleUpload(c.qnapConfig, s, commandArgs.Args.FileName, downloadedRuns); err != nil {
-				s.SendError(err)
-				return
-			}
-
-			if commandArgs.Delete {
-				s.SendMessage("Clearing disk space...")
-				if err := os.RemoveAll(dir); err != nil {
-					s.SendError(err)
-					return
-				}
-			}
+		if exists {
+			s.SendMessage("File %s already exists!", uploadFileName)
+			return
 		}
-
-		c.Disk(ctx, s, []string{})
 	}
-}
 
-type DownloadedFile struct {
-	Name  string
-	SeqNo int
-}
+	job := &StreamlinkJob{
+		Recording: hls.Recording{
+			M3U8URL:   m3u8Url.String(),
+			Directory: dir,
+		},
 
-type PlaylistConfig struct {
-	Directory   string
-	IsEncrypted bool
-	Block       cipher.Block
-}
+		GuildID:     s.GuildID,
+		ChannelID:   s.ChannelID,
+		MessageID:   s.Message.ID,
+		RequesterID: s.Author.ID,
 
-type Segment struct {
-	FileName string
-	URL      *url.URL
-	IV       []byte
-}
+		HTTPHeader:  commandArgs.HTTPHeader,
+		HTTPCookies: commandArgs.HTTPCookies,
 
-func checkFileExists(ctx context.Context, s *discord.MessageSession, qnapConfig *config.QNAPConfig, fileNameStr string) (bool, error) {
-	extension := path.Ext(fileNameStr)
-	fileName := strings.TrimSuffix(fileNameStr, extension)
+		FileName: commandArgs.Args.FileName,
+		Delete:   commandArgs.Delete,
 
-	if extension == "" {
-		extension = ".ts"
-	}
+		RemuxFormat: commandArgs.Remux,
+		MergeRuns:   commandArgs.Merge,
 
-	qnapAPI, err := qnap.New(qnapConfig.URL, s.Logger)
-	if err != nil {
-		return false, err
+		UploadReport: commandArgs.Report,
+
+		DownloadLimits: limits,
 	}
 
-	if err := qnapAPI.Login(qnapConfig.Username, qnapConfig.Password); err != nil {
-		return false, err
+	if err := c.saveStreamlinkJob(ctx, job); err != nil {
+		s.Logger.With(zap.Error(err)).Warn("Failed to save job, it will not be resumed after a restart")
 	}
-	defer qnapAPI.Logout()
 
-	return qnapAPI.Exists(qnapConfig.DownloadBasePath, fmt.Sprintf("%s%s", fileName, extension))
+	downloadJob := c.jobs.add(c.ctx, DownloadJobTypeStreamlink, job.FileName, job.RequesterID, job.ChannelID)
+	s.SendMessage("Starting to download %s as job #%v", commandArgs.Args.FileName, downloadJob.ID)
+
+	c.wg.Add(1)
+	c.runStreamlinkJob(s, job, downloadJob)
 }
 
-func handleMediaPlaylist(ctx context.Context, s *discord.MessageSession, client *retryablehttp.Client, m3u8UrlStr string, key *m3u8.Key, dir string) (downloadRuns [][]string, err error) {
-	isEncrypted := key != nil
-	currRunNo := 0
+// DownloadLimits overrides the configured download options for a single job
+type DownloadLimits struct {
+	NumWorkers int `json:"numWorkers,omitempty"`
 
-	m3u8Url, err := url.Parse(m3u8UrlStr)
-	if err != nil {
-		return nil, err
-	}
+	// In bytes per second, on top of the bandwidth limit shared by all jobs
+	BandwidthLimit int64 `json:"bandwidthLimit,omitempty"`
 
-	var block cipher.Block
-	if isEncrypted {
-		keyBytes, err := downloadKey(ctx, client, m3u8Url, key)
-		if err != nil {
-			return nil, err
-		}
+	SkipHead bool `json:"skipHead,omitempty"`
+}
 
-		block, err = aes.NewCipher(keyBytes)
-		if err != nil {
-			return nil, err
-		}
+func parseDownloadLimits(numWorkers int, bandwidthLimitStr string, skipHead bool) (DownloadLimits, error) {
+	if numWorkers < 0 {
+		return DownloadLimits{}, fmt.Errorf("number of workers must not be negative")
 	}
 
-	bar, err := s.SendBytesProgressBar(1, "Downloading")
+	bandwidthLimit, err := throttle.ParseBytesPerSecond(bandwidthLimitStr)
 	if err != nil {
-		return nil, err
+		return DownloadLimits{}, err
 	}
 
-	var wg sync.WaitGroup
-	toHeadChan := make(chan *Segment, 10000)
-	toGetChan := make(chan *Segment, 10000)
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		getSegmentSize(ctx, client, toHeadChan, toGetChan, bar, s.Logger)
-	}()
-
-	for i := 0; i < 2; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			downloadSegment(ctx, &PlaylistConfig{
-				Directory:   dir,
-				IsEncrypted: isEncrypted,
-				Block:       block,
-			}, client, toGetChan, bar, s.Logger)
-		}()
-	}
-
-	defer func() {
-		close(toHeadChan)
-		wg.Wait()
-		bar.Add(1)
-	}()
-
-	downloadedRuns := make([]map[int]string, 0)
-	downloadedRuns = append(downloadedRuns, make(map[int]string))
-
-	doneCh := make(chan int, 1)
-
-	var sleepTime time.Duration
-	errCount := 0
-
-	for {
-		select {
-		case <-ctx.Done():
-			s.Logger.Info("Context cancelled, download aborted")
-			return nil, nil
-		case <-doneCh:
-			s.Logger.Info("Stream closed")
-			runs := make([][]*DownloadedFile, 0, len(downloadedRuns))
-			for runNo, runSegments := range downloadedRuns {
-				runs = append(runs, make([]*DownloadedFile, 0, len(runSegments)))
-				for seqNo, filePath := range runSegments {
-					runs[runNo] = append(runs[runNo], &DownloadedFile{
-						Name:  filePath,
-						SeqNo: seqNo,
-					})
-				}
-
-				slices.SortFunc(runs[runNo], func(i, j *DownloadedFile) bool {
-					return i.SeqNo < j.SeqNo
-				})
-			}
-
-			downloadRuns = make([][]string, 0, len(runs))
-			for runNo, run := range runs {
-				downloadRuns = append(downloadRuns, make([]string, 0, len(runs)))
-				for _, file := range run {
-					downloadRuns[runNo] = append(downloadRuns[runNo], file.Name)
-				}
-			}
-
-			return downloadRuns, nil
-		case <-time.After(sleepTime):
-			if err := func() error {
-				resp, err := client.Get(m3u8Url.String())
-				if err != nil {
-					return err
-				}
-				defer resp.Body.Close()
-
-				if resp.StatusCode != http.StatusOK {
-					return fmt.Errorf("HTTP request to %s returned status %v", resp.Request.URL.String(), resp.StatusCode)
-				}
-
-				playlist, _, err := m3u8.DecodeFrom(resp.Body, true)
-				if err != nil {
-					return err
-				}
-
-				mediaList, ok := playlist.(*m3u8.MediaPlaylist)
-				if !ok {
-					return fmt.Errorf("not a media playlist")
-				}
-				sleepTime = time.Duration(mediaList.TargetDuration) * time.Second
-
-				for i, segment := range mediaList.Segments {
-					if segment == nil {
-						continue
-					}
-
-					segmentUrl, err := m3u8Url.Parse(segment.URI)
-					if err != nil {
-						return err
-					}
-
-					fileName := filepath.Join(dir, path.Base(segmentUrl.Path))
-
-					seqNo := i + int(mediaList.SeqNo)
-					currRunSegments := downloadedRuns[currRunNo]
-					if existingFileName, ok := currRunSegments[seqNo]; ok {
-						if fileName == existingFileName {
-							continue
-						}
-
-						currRunNo++
-						downloadedRuns = append(downloadedRuns, make(map[int]string))
-					}
-
-					var iv []byte
-					if isEncrypted {
-						iv = make([]byte, 16)
-
-						if mediaList.Key.IV == "" {
-							binary.BigEndian.PutUint16(iv, uint16(i+int(mediaList.SeqNo)))
-						} else {
-							ivParsed := new(big.Int)
-							ivParsed.SetString(mediaList.Key.IV, 16)
-
-							ivParsed.FillBytes(iv)
-						}
-					}
-
-					toHeadChan <- &Segment{
-						FileName: fileName,
-						URL:      segmentUrl,
-						IV:       iv,
-					}
-
-					downloadedRuns[currRunNo][seqNo] = fileName
-				}
-
-				if mediaList.Closed {
-					doneCh <- 1
-				}
-
-				return nil
-			}(); err != nil {
-				s.Logger.With(zap.Int("errCount", errCount)).Error(err)
-				errCount++
-
-				if errCount >= 10 {
-					s.Logger.Error("Too many errors when getting M3U8, aborting...")
-					doneCh <- 1
-				}
-
-				break
-			}
-
-			errCount = 0
-		}
-	}
+	return DownloadLimits{
+		NumWorkers:     numWorkers,
+		BandwidthLimit: bandwidthLimit,
+		SkipHead:       skipHead,
+	}, nil
 }
 
-func downloadKey(ctx context.Context, client *retryablehttp.Client, m3u8Url *url.URL, key *m3u8.Key) ([]byte, error) {
-	keyUrl, err := m3u8Url.Parse(key.URI)
-	if err != nil {
-		return nil, err
+// downloaderOptions applies the limits of a job to the configured download options
+func (c *DownloadCog) downloaderOptions(limits *DownloadLimits) *hls.DownloaderOptions {
+	opts := &hls.DownloaderOptions{
+		NumWorkers: c.downloaderOpts.NumWorkers,
+		SkipHead:   c.downloaderOpts.SkipHead || limits.SkipHead,
+		Limiters:   slices.Clone(c.downloaderOpts.Limiters),
 	}
 
-	resp, err := client.Get(keyUrl.String())
-	if err != nil {
-		return nil, err
+	if limits.NumWorkers > 0 {
+		opts.NumWorkers = limits.NumWorkers
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP request to %s returned status %v", resp.Request.URL.String(), resp.StatusCode)
+	if limiter := throttle.NewLimiter(limits.BandwidthLimit); limiter != nil {
+		opts.Limiters = append(opts.Limiters, limiter)
 	}
 
-	return io.ReadAll(resp.Body)
+	return opts
 }
 
-func getSegmentSize(ctx context.Context, client *retryablehttp.Client, in <-chan *Segment, out chan<- *Segment, bar *discord.ProgressBar, logger *zap.SugaredLogger) {
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case segment, ok := <-in:
-			if !ok {
-				close(out)
-				return
-			}
-
-			resp, err := client.Head(segment.URL.String())
-			if err != nil || resp.StatusCode != http.StatusOK {
-				logger.With(zap.Error(err)).Warn("Failed to HEAD segment URL")
-			} else {
-				bar.AddMax(resp.ContentLength)
-			}
+func checkFileExists(storage storage.Storage, fileNameStr string) (bool, error) {
+	extension := path.Ext(fileNameStr)
+	fileName := strings.TrimSuffix(fileNameStr, extension)
 
-			out <- segment
-		}
+	if extension == "" {
+		extension = ".ts"
 	}
-}
-
-func downloadSegment(ctx context.Context, cfg *PlaylistConfig, client *retryablehttp.Client, segmentChan <-chan *Segment, bar *discord.ProgressBar, logger *zap.SugaredLogger) {
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case segment, ok := <-segmentChan:
-			if !ok {
-				return
-			}
 
-			segmentLogger := logger.With(zap.String("url", segment.URL.String()))
-
-			numRetries := 0
-			for numRetries < 5 {
-				if err := func() error {
-					out, err := os.Create(segment.FileName)
-					if err != nil {
-						return err
-					}
-					defer out.Close()
-
-					resp, err := client.Get(segment.URL.String())
-					if err != nil {
-						return err
-					}
-					defer resp.Body.Close()
-
-					if resp.StatusCode != http.StatusOK {
-						return fmt.Errorf("HTTP request to %s returned status %v", resp.Request.URL.String(), resp.StatusCode)
-					}
-
-					bytes, err := io.ReadAll(resp.Body)
-					if err != nil {
-						return err
-					}
-					bar.Add(int64(len(bytes)))
-
-					if cfg.IsEncrypted {
-						mode := cipher.NewCBCDecrypter(cfg.Block, segment.IV)
-						mode.CryptBlocks(bytes, bytes)
-					}
-
-					if _, err = out.Write(bytes); err != nil {
-						return err
-					}
-
-					segmentLogger.Info("Downloaded")
-					return nil
-				}(); err != nil {
-					segmentLogger.With(zap.Int("numRetries", numRetries)).Error(err)
-					numRetries++
-					continue
-				}
-
-				break
-			}
-		}
-	}
+	return storage.Exists(fmt.Sprintf("%s%s", fileName, extension))
 }
 
-func handleUpload(qnapConfig *config.QNAPConfig, s *discord.MessageSession, fileNameStr string, downloadedRuns [][]string) (int64, error) {
+func handleUpload(storage storage.Storage, s *discord.MessageSession, fileNameStr string, downloadedRuns [][]string) (int64, error) {
 	extension := path.Ext(fileNameStr)
 	fileName := strings.TrimSuffix(fileNameStr, extension)
 
@@ -501,12 +359,12 @@ func handleUpload(qnapConfig *config.QNAPConfig, s *discord.MessageSession, file
 	}
 
 	if len(downloadedRuns) == 1 {
-		return uploadAndConcatFiles(qnapConfig, s, fmt.Sprintf("%s%s", fileName, extension), downloadedRuns[0])
+		return uploadAndConcatFiles(storage, s, fmt.Sprintf("%s%s", fileName, extension), downloadedRuns[0])
 	} else {
 		var totalFileSize int64
 		for runNo, run := range downloadedRuns {
 			runFileName := fmt.Sprintf("%s_%v%s", fileName, runNo+1, extension)
-			currFileSize, err := uploadAndConcatFiles(qnapConfig, s, runFileName, run)
+			currFileSize, err := uploadAndConcatFiles(storage, s, runFileName, run)
 			if err != nil {
 				return totalFileSize, err
 			}
@@ -516,7 +374,7 @@ func handleUpload(qnapConfig *config.QNAPConfig, s *discord.MessageSession, file
 	}
 }
 
-func uploadAndConcatFiles(qnapConfig *config.QNAPConfig, s *discord.MessageSession, fileName string, filePaths []string) (int64, error) {
+func uploadAndConcatFiles(storage storage.Storage, s *discord.MessageSession, fileName string, filePaths []string) (int64, error) {
 	bar, err := s.SendBytesProgressBar(1*units.TiB, fmt.Sprintf("Uploading %s", fileName))
 	if err != nil {
 		msg := "Failed to initialize progress bar"
@@ -524,17 +382,32 @@ func uploadAndConcatFiles(qnapConfig *config.QNAPConfig, s *discord.MessageSessi
 		s.SendError(fmt.Errorf(msg))
 	}
 
-	qnapAPI, err := qnap.New(qnapConfig.URL, s.Logger)
-	if err != nil {
-		return 0, err
+	return storage.UploadAndConcat(fileName, filePaths, bar)
+}
+
+// YoutubeRecord watches a YouTube live stream and records it as soon as it goes live
+func (c *DownloadCog) YoutubeRecord(ctx context.Context, s *discord.MessageSession, args []string) {
+	if len(args) != 1 {
+		s.SendErrorf("Usage: ytrecord <YouTube URL>")
+		return
+	}
+
+	if c.youtubeRecorder == nil {
+		s.SendErrorf("YouTube is not configured!")
+		return
 	}
 
-	if err := qnapAPI.Login(qnapConfig.Username, qnapConfig.Password); err != nil {
-		return 0, err
+	videoID, ok := youtube.ParseVideoID(args[0])
+	if !ok {
+		s.SendErrorf("Invalid YouTube URL!")
+		return
 	}
-	defer qnapAPI.Logout()
 
-	return qnapAPI.UploadAndConcat(qnapConfig.DownloadBasePath, fileName, filePaths, bar)
+	if err := c.youtubeRecorder.Record(ctx, s, videoID); errors.Is(err, youtube.ErrNotFound) {
+		s.SendErrorf("Video %s not found!", videoID)
+	} else if err != nil {
+		s.SendError(err)
+	}
 }
 
 func (c *DownloadCog) Weibo(ctx context.Context, s *discord.MessageSession, args []string) {
@@ -542,17 +415,19 @@ func (c *DownloadCog) Weibo(ctx context.Context, s *discord.MessageSession, args
 
 	postIDs := make([]string, 0, len(links))
 	for _, link := range links {
-		regex := regexp.MustCompile(`http[s]?://(?:w{3}\.)?weibo.com/[0-9]+/([A-Za-z0-9]+)`)
-		matches := regex.FindStringSubmatch(link)
-		if len(matches) <= 1 {
-			continue
+		if postID, ok := weibo.ParsePostID(link); ok {
+			postIDs = append(postIDs, postID)
 		}
-		postIDs = append(postIDs, matches[1])
 	}
 
+	downloadJob := c.jobs.add(c.ctx, DownloadJobTypeWeibo, dirName, s.Author.ID, s.ChannelID)
+	defer c.jobs.remove(downloadJob)
+	s.SendMessage("Starting to download %s as job #%v", dirName, downloadJob.ID)
+
 	weiboAPI := weibo.NewAPI()
 
-	photos := make([]*weibo.PhotoVariant, 0)
+	mediaFiles := make([]*weiboMediaFile, 0)
+	nextFileNo := 1
 	for _, id := range postIDs {
 		post, err := weiboAPI.GetPost(id)
 		if err != nil {
@@ -560,9 +435,9 @@ func (c *DownloadCog) Weibo(ctx context.Context, s *discord.MessageSession, args
 			continue
 		}
 
-		for _, photo := range post.Photos {
-			photos = append(photos, photo.BestVariant())
-		}
+		var postMediaFiles []*weiboMediaFile
+		postMediaFiles, nextFileNo = getWeiboMediaFiles(post.MediaPost(), nextFileNo)
+		mediaFiles = append(mediaFiles, postMediaFiles...)
 	}
 
 	bar, err := s.SendBytesProgressBar(0, fmt.Sprintf("Downloading %s", dirName))
@@ -570,15 +445,16 @@ func (c *DownloadCog) Weibo(ctx context.Context, s *discord.MessageSession, args
 		s.SendError(err)
 		return
 	}
+	downloadJob.SetProgressBar(bar)
 
-	for _, photo := range photos {
-		photoSize, err := weiboAPI.GetPhotoVariantSize(photo)
+	for _, mediaFile := range mediaFiles {
+		mediaSize, err := weiboAPI.GetMediaSize(mediaFile.URL)
 		if err != nil {
 			s.SendError(err)
 			continue
 		}
 
-		bar.AddMax(photoSize)
+		bar.AddMax(mediaSize)
 	}
 
 	tempDir, err := os.MkdirTemp("", "")
@@ -588,10 +464,15 @@ func (c *DownloadCog) Weibo(ctx context.Context, s *discord.MessageSession, args
 	}
 	defer os.RemoveAll(tempDir)
 
-	filePaths := make([]string, 0, len(photos))
+	filePaths := make([]string, 0, len(mediaFiles))
+
+	for _, mediaFile := range mediaFiles {
+		if downloadJob.ctx.Err() != nil {
+			s.SendMessage("Cancelled download of %s", dirName)
+			return
+		}
 
-	for i, photo := range photos {
-		filePath := filepath.Join(tempDir, fmt.Sprintf("%v.jpg", i+1))
+		filePath := filepath.Join(tempDir, mediaFile.FileName)
 
 		f, err := os.Create(filePath)
 		if err != nil {
@@ -599,30 +480,75 @@ func (c *DownloadCog) Weibo(ctx context.Context, s *discord.MessageSession, args
 			continue
 		}
 
-		photoBytes, err := weiboAPI.DownloadPhotoVariant(photo)
+		mediaBytes, err := weiboAPI.DownloadMedia(mediaFile.URL)
 		if err != nil {
 			s.SendError(err)
 			continue
 		}
 
-		if _, err := f.Write(photoBytes); err != nil {
+		if _, err := f.Write(mediaBytes); err != nil {
 			s.SendError(err)
 			continue
 		}
 
 		filePaths = append(filePaths, filePath)
-		bar.Add(int64(len(photoBytes)))
+		bar.Add(int64(len(mediaBytes)))
 		f.Close()
 	}
 
-	if c.qnapConfig.IsEnabled {
-		if err := uploadFiles(c.qnapConfig, s, dirName, filePaths); err != nil {
+	if c.storage != nil {
+		downloadJob.SetStatus(DownloadJobStatusUploading)
+		if err := uploadFiles(c.storage, s, dirName, filePaths); err != nil {
 			s.SendError(err)
 		}
 	}
 }
 
-func uploadFiles(qnapConfig *config.QNAPConfig, s *discord.MessageSession, dirName string, filePaths []string) error {
+type weiboMediaFile struct {
+	URL      string
+	FileName string
+}
+
+// getWeiboMediaFiles numbers the photos and videos of the post starting from fileNo, and returns the next number to use.
+// The video of a live photo shares the number of the photo.
+func getWeiboMediaFiles(post *weibo.Post, fileNo int) ([]*weiboMediaFile, int) {
+	mediaFiles := make([]*weiboMediaFile, 0, len(post.Photos)+len(post.Videos))
+
+	for _, photo := range post.Photos {
+		if variant := photo.BestVariant(); variant != nil {
+			mediaFiles = append(mediaFiles, &weiboMediaFile{
+				URL:      variant.URL,
+				FileName: fmt.Sprintf("%v.jpg", fileNo),
+			})
+		}
+
+		if photo.IsLivePhoto() {
+			extension := ".mov"
+			if u, err := url.Parse(photo.LivePhotoVideoURL); err == nil && path.Ext(u.Path) != "" {
+				extension = path.Ext(u.Path)
+			}
+
+			mediaFiles = append(mediaFiles, &weiboMediaFile{
+				URL:      photo.LivePhotoVideoURL,
+				FileName: fmt.Sprintf("%v%s", fileNo, extension),
+			})
+		}
+
+		fileNo++
+	}
+
+	for _, video := range post.Videos {
+		mediaFiles = append(mediaFiles, &weiboMediaFile{
+			URL:      video.URL,
+			FileName: fmt.Sprintf("%v.mp4", fileNo),
+		})
+		fileNo++
+	}
+
+	return mediaFiles, fileNo
+}
+
+func uploadFiles(storage storage.Storage, s *discord.MessageSession, dirName string, filePaths []string) error {
 	bar, err := s.SendBytesProgressBar(1*units.TiB, fmt.Sprintf("Uploading %s", dirName))
 	if err != nil {
 		msg := "Failed to initialize progress bar"
@@ -630,19 +556,9 @@ func uploadFiles(qnapConfig *config.QNAPConfig, s *discord.MessageSession, dirNa
 		s.SendError(fmt.Errorf(msg))
 	}
 
-	qnapAPI, err := qnap.New(qnapConfig.URL, s.Logger)
-	if err != nil {
-		return err
-	}
-
-	if err := qnapAPI.Login(qnapConfig.Username, qnapConfig.Password); err != nil {
-		return err
-	}
-	defer qnapAPI.Logout()
-
-	if err := qnapAPI.CreateDir(qnapConfig.DownloadBasePath, dirName); err != nil {
+	if err := storage.CreateDir(dirName); err != nil {
 		return err
 	}
 
-	return qnapAPI.UploadMany(filepath.Join(qnapConfig.DownloadBasePath, dirName), filePaths, bar)
+	return storage.UploadMany(dirName, filePaths, bar)
 }
@@ -0,0 +1,30 @@
+package cog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xIceArcher/go-leah/weibo"
+)
+
+func TestGetWeiboMediaFiles(t *testing.T) {
+	post := &weibo.Post{
+		Photos: []*weibo.Photo{
+			{Variants: []*weibo.PhotoVariant{{URL: "https://wx1.sinaimg.cn/large/a.jpg"}}},
+			{
+				Variants:          []*weibo.PhotoVariant{{URL: "https://wx1.sinaimg.cn/large/b.jpg"}},
+				LivePhotoVideoURL: "https://livephoto.us.sinaimg.cn/b.mov?x=1",
+			},
+		},
+		Videos: []*weibo.Video{{URL: "https://f.video.weibocdn.com/c.mp4?auth=1"}},
+	}
+
+	mediaFiles, nextFileNo := getWeiboMediaFiles(post, 3)
+	assert.Equal(t, 6, nextFileNo)
+	assert.Equal(t, []*weiboMediaFile{
+		{URL: "https://wx1.sinaimg.cn/large/a.jpg", FileName: "3.jpg"},
+		{URL: "https://wx1.sinaimg.cn/large/b.jpg", FileName: "4.jpg"},
+		{URL: "https://livephoto.us.sinaimg.cn/b.mov?x=1", FileName: "4.mov"},
+		{URL: "https://f.video.weibocdn.com/c.mp4?auth=1", FileName: "5.mp4"},
+	}, mediaFiles)
+}
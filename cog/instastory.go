@@ -0,0 +1,279 @@
+package cog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/xIceArcher/go-leah/cache"
+	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/consts"
+	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/instagram"
+	"github.com/xIceArcher/go-leah/storage"
+	"github.com/xIceArcher/go-leah/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	// Set once the story has been archived
+	CacheKeyInstagramStoryArchiveFormat = "go-leah/instagramStoryArchive/%s/%s"
+
+	instagramStoryArchivePollInterval = 15 * time.Minute
+
+	// Stories expire after 24 hours, so there is no need to remember them for much longer
+	instagramStoryArchiveExpiry = 48 * time.Hour
+
+	instagramStoryArchiveDirFormat = "instagram-stories-%s"
+	instagramStoryMaxBytes         = 1 * units.GiB
+)
+
+var (
+	ErrInstagramStoryArchiveNotConfigured error = fmt.Errorf("no usernames configured for the story archive")
+)
+
+type InstagramStoryArchiveCog struct {
+	GenericCog
+
+	api     *instagram.API
+	cache   cache.Cache
+	storage storage.Storage
+	session *discord.Session
+
+	usernames []string
+	channelID string
+
+	// Serializes scheduled and requested archiving so that stories are not uploaded twice
+	archiveMu sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewInstagramStoryArchiveCog(cfg *config.Config, s *discord.Session) (Cog, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cog := &InstagramStoryArchiveCog{
+		session: s,
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	cog.allCommands = map[string]CommandFunc{
+		"archivestories": cog.ArchiveStories,
+	}
+
+	if cfg.Instagram == nil || cfg.Instagram.StoryArchive == nil || len(cfg.Instagram.StoryArchive.Usernames) == 0 {
+		// Not an error so that the cog can be enabled before any usernames are added
+		s.Logger.Warn("No usernames configured for the story archive, stories will not be archived")
+		return cog, nil
+	}
+
+	c, err := cache.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := instagram.NewAPI(cfg.Instagram)
+	if err != nil {
+		return nil, err
+	}
+
+	archiveStorage, err := storage.New(cfg, s.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	cog.api = api
+	cog.cache = c
+	cog.storage = archiveStorage
+
+	cog.usernames = cfg.Instagram.StoryArchive.Usernames
+	cog.channelID = cfg.Instagram.StoryArchive.DiscordChannelID
+
+	cog.wg.Add(1)
+	go cog.archiveTask()
+
+	return cog, nil
+}
+
+func (c *InstagramStoryArchiveCog) ArchiveStories(ctx context.Context, s *discord.MessageSession, args []string) {
+	if len(c.usernames) == 0 {
+		s.SendError(ErrInstagramStoryArchiveNotConfigured)
+		return
+	}
+
+	numArchived := c.archive()
+	s.SendMessage("Archived %v new stories", numArchived)
+}
+
+func (c *InstagramStoryArchiveCog) archiveTask() {
+	defer c.wg.Done()
+
+	// Archive immediately since stories may have expired while the bot was down
+	c.archive()
+
+	ticker := time.NewTicker(instagramStoryArchivePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.archive()
+		}
+	}
+}
+
+// archive uploads the stories of every user that have not been archived yet, and returns how many were uploaded
+func (c *InstagramStoryArchiveCog) archive() (numArchived int) {
+	c.archiveMu.Lock()
+	defer c.archiveMu.Unlock()
+
+	for _, username := range c.usernames {
+		if c.ctx.Err() != nil {
+			return
+		}
+
+		logger := c.session.Logger.With(zap.String("username", username))
+
+		stories, err := c.api.GetStories(username)
+		if errors.Is(err, instagram.ErrNoStories) {
+			continue
+		} else if err != nil {
+			logger.With(zap.Error(err)).Warn("Failed to get stories")
+			continue
+		}
+
+		newStories := c.filterNewStories(username, stories)
+		if len(newStories) == 0 {
+			continue
+		}
+
+		dirName := fmt.Sprintf(instagramStoryArchiveDirFormat, username)
+		if err := c.storage.CreateDir(dirName); err != nil {
+			logger.With(zap.Error(err)).Error("Failed to create archive directory")
+			continue
+		}
+
+		// Each story is archived on its own so that one that fails does not hold back the rest
+		numUserArchived := 0
+		for _, story := range newStories {
+			if c.ctx.Err() != nil {
+				break
+			}
+
+			if err := c.uploadStory(dirName, story); err != nil {
+				// The story is tried again in the next poll
+				logger.With(zap.Error(err), zap.String("storyID", story.ID)).Error("Failed to archive story")
+				continue
+			}
+
+			c.markStoryArchived(username, story)
+			if c.channelID != "" {
+				c.sendStory(username, story)
+			}
+
+			numUserArchived++
+		}
+
+		logger.With(zap.Int("numStories", numUserArchived)).Info("Archived stories")
+		numArchived += numUserArchived
+	}
+
+	return
+}
+
+// filterNewStories returns the stories that have not been archived yet
+func (c *InstagramStoryArchiveCog) filterNewStories(username string, stories []*instagram.Story) []*instagram.Story {
+	newStories := make([]*instagram.Story, 0, len(stories))
+	for _, story := range stories {
+		_, err := c.cache.Get(c.ctx, fmt.Sprintf(CacheKeyInstagramStoryArchiveFormat, username, story.ID))
+		if errors.Is(err, cache.ErrNotFound) {
+			newStories = append(newStories, story)
+		} else if err != nil {
+			c.session.Logger.With(zap.Error(err), zap.String("username", username), zap.String("storyID", story.ID)).Warn("Failed to check if story was archived")
+		}
+	}
+
+	return newStories
+}
+
+func (c *InstagramStoryArchiveCog) markStoryArchived(username string, story *instagram.Story) {
+	// Cannot use c.ctx here since the story has already been uploaded
+	cacheKey := fmt.Sprintf(CacheKeyInstagramStoryArchiveFormat, username, story.ID)
+	if err := c.cache.SetWithExpiry(context.Background(), cacheKey, story.ID, instagramStoryArchiveExpiry); err != nil {
+		c.session.Logger.With(zap.Error(err), zap.String("username", username), zap.String("storyID", story.ID)).Error("Failed to write to cache")
+	}
+}
+
+func (c *InstagramStoryArchiveCog) uploadStory(dirName string, story *instagram.Story) error {
+	tempDir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	filePath := filepath.Join(tempDir, instagramStoryFileName(story))
+	if err := downloadInstagramStory(story, filePath); err != nil {
+		return err
+	}
+
+	return c.storage.UploadMany(dirName, []string{filePath})
+}
+
+func (c *InstagramStoryArchiveCog) sendStory(username string, story *instagram.Story) {
+	if _, err := c.session.DownloadImageAndSendEmbed(c.channelID, story.GetEmbed(), username); err != nil {
+		return
+	}
+
+	if story.IsVideo() {
+		c.session.SendVideoURL(c.channelID, story.MediaURL, username)
+	}
+}
+
+func (c *InstagramStoryArchiveCog) Stop() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+func downloadInstagramStory(story *instagram.Story, filePath string) error {
+	media, _, err := utils.Download(story.MediaURL, instagramStoryMaxBytes)
+	if err != nil {
+		return err
+	}
+	if closer, ok := media.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, media); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// instagramStoryFileName names the file by when the story was posted, so that the archive sorts chronologically
+func instagramStoryFileName(story *instagram.Story) string {
+	extension := ".jpg"
+	if story.IsVideo() {
+		extension = ".mp4"
+	}
+
+	return fmt.Sprintf("%s_%s%s", story.Timestamp.Format(consts.TimeFormatYYMMDDHHMMSS), story.ID, extension)
+}
@@ -0,0 +1,54 @@
+package cog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xIceArcher/go-leah/cache"
+	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/instagram"
+	"go.uber.org/zap"
+)
+
+func TestInstagramStoryFileName(t *testing.T) {
+	timestamp := time.Date(2024, 3, 5, 6, 7, 8, 0, time.Local)
+
+	assert.Equal(t, "240305060708_123.jpg", instagramStoryFileName(&instagram.Story{
+		ID:        "123",
+		Timestamp: timestamp,
+		MediaType: instagram.MediaTypeImage,
+	}))
+	assert.Equal(t, "240305060708_456.mp4", instagramStoryFileName(&instagram.Story{
+		ID:        "456",
+		Timestamp: timestamp,
+		MediaType: instagram.MediaTypeVideo,
+	}))
+}
+
+func TestInstagramStoryArchiveDedup(t *testing.T) {
+	c, err := cache.NewMemoryCache(&config.CacheConfig{})
+	require.NoError(t, err)
+
+	cog := &InstagramStoryArchiveCog{
+		cache:   c,
+		session: discord.NewSession(nil, zap.NewNop().Sugar()),
+		ctx:     context.Background(),
+	}
+
+	stories := []*instagram.Story{{ID: "dedup1"}, {ID: "dedup2"}, {ID: "dedup3"}}
+	assert.Equal(t, stories, cog.filterNewStories("user", stories))
+
+	cog.markStoryArchived("user", stories[1])
+	assert.Equal(t, []*instagram.Story{stories[0], stories[2]}, cog.filterNewStories("user", stories))
+
+	// Stories are archived per user
+	assert.Equal(t, stories, cog.filterNewStories("otheruser", stories))
+
+	ttl, err := c.GetWithTTL(context.Background(), "go-leah/instagramStoryArchive/user/dedup2")
+	require.NoError(t, err)
+	assert.InDelta(t, instagramStoryArchiveExpiry, ttl.TTL, float64(time.Minute))
+}
@@ -0,0 +1,269 @@
+package cog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/docker/go-units"
+	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/utils"
+)
+
+const (
+	DownloadJobTypeStreamlink = "streamlink"
+	DownloadJobTypeWeibo      = "weibo"
+	DownloadJobTypeDash       = "dash"
+)
+
+type DownloadJobStatus string
+
+const (
+	DownloadJobStatusDownloading DownloadJobStatus = "downloading"
+	DownloadJobStatusRemuxing    DownloadJobStatus = "remuxing"
+	DownloadJobStatusUploading   DownloadJobStatus = "uploading"
+)
+
+var (
+	ErrJobNotFound  error = fmt.Errorf("Job not found!")
+	ErrJobCancelled error = fmt.Errorf("job cancelled")
+)
+
+// DownloadJob is a running download that can be inspected and cancelled by users
+type DownloadJob struct {
+	ID          int
+	Type        string
+	Name        string
+	RequesterID string
+	ChannelID   string
+	StartTime   time.Time
+
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	mu     sync.RWMutex
+	status DownloadJobStatus
+	bar    *discord.ProgressBar
+}
+
+func (j *DownloadJob) Status() DownloadJobStatus {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	return j.status
+}
+
+func (j *DownloadJob) SetStatus(status DownloadJobStatus) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.status = status
+}
+
+// SetProgressBar sets the progress bar that the job's bytes done are read from
+func (j *DownloadJob) SetProgressBar(bar *discord.ProgressBar) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.bar = bar
+}
+
+func (j *DownloadJob) BytesDone() int64 {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if j.bar == nil {
+		return 0
+	}
+	return j.bar.Current()
+}
+
+// IsCancelled returns whether the job was cancelled by a user, as opposed to the bot shutting down
+func (j *DownloadJob) IsCancelled() bool {
+	return errors.Is(context.Cause(j.ctx), ErrJobCancelled)
+}
+
+type downloadJobs struct {
+	mu     sync.RWMutex
+	nextID int
+	jobs   map[int]*DownloadJob
+}
+
+func newDownloadJobs() *downloadJobs {
+	return &downloadJobs{
+		nextID: 1,
+		jobs:   make(map[int]*DownloadJob),
+	}
+}
+
+func (js *downloadJobs) add(parent context.Context, jobType string, name string, requesterID string, channelID string) *DownloadJob {
+	ctx, cancel := context.WithCancelCause(parent)
+
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	job := &DownloadJob{
+		ID:          js.nextID,
+		Type:        jobType,
+		Name:        name,
+		RequesterID: requesterID,
+		ChannelID:   channelID,
+		StartTime:   time.Now(),
+
+		ctx:    ctx,
+		cancel: cancel,
+
+		status: DownloadJobStatusDownloading,
+	}
+
+	js.jobs[job.ID] = job
+	js.nextID++
+
+	return job
+}
+
+func (js *downloadJobs) remove(job *DownloadJob) {
+	job.cancel(nil)
+
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	delete(js.jobs, job.ID)
+}
+
+func (js *downloadJobs) get(id int) (*DownloadJob, bool) {
+	js.mu.RLock()
+	defer js.mu.RUnlock()
+
+	job, ok := js.jobs[id]
+	return job, ok
+}
+
+func (js *downloadJobs) list() []*DownloadJob {
+	js.mu.RLock()
+	defer js.mu.RUnlock()
+
+	jobs := make([]*DownloadJob, 0, len(js.jobs))
+	for _, job := range js.jobs {
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ID < jobs[j].ID
+	})
+	return jobs
+}
+
+func (c *DownloadCog) Jobs(ctx context.Context, s *discord.MessageSession, args []string) {
+	jobs := c.jobs.list()
+	if len(jobs) == 0 {
+		s.SendMessage("No jobs running")
+		return
+	}
+
+	lines := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		lines = append(lines, fmt.Sprintf("**#%v** %s `%s`: %s, %s done, requested by <@%s>",
+			job.ID, job.Type, job.Name, job.Status(), units.HumanSize(float64(job.BytesDone())), job.RequesterID))
+	}
+
+	// Embeds are used so that requesters are not pinged
+	s.SendEmbed(&discordgo.MessageEmbed{
+		Title:       "Jobs",
+		Description: strings.Join(lines, "\n"),
+	})
+}
+
+func (c *DownloadCog) Job(ctx context.Context, s *discord.MessageSession, args []string) {
+	job, err := c.getJobFromArgs(args)
+	if err != nil {
+		s.SendError(err)
+		return
+	}
+
+	s.SendEmbed(&discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Job #%v", job.ID),
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Type",
+				Value:  job.Type,
+				Inline: true,
+			},
+			{
+				Name:   "Status",
+				Value:  string(job.Status()),
+				Inline: true,
+			},
+			{
+				Name:   "Bytes Done",
+				Value:  units.HumanSize(float64(job.BytesDone())),
+				Inline: true,
+			},
+			{
+				Name:  "Name",
+				Value: job.Name,
+			},
+			{
+				Name:   "Requested By",
+				Value:  fmt.Sprintf("<@%s>", job.RequesterID),
+				Inline: true,
+			},
+			{
+				Name:   "Channel",
+				Value:  fmt.Sprintf("<#%s>", job.ChannelID),
+				Inline: true,
+			},
+			{
+				Name:   "Started",
+				Value:  utils.FormatDiscordRelativeTime(job.StartTime),
+				Inline: true,
+			},
+		},
+	})
+}
+
+func (c *DownloadCog) Cancel(ctx context.Context, s *discord.MessageSession, args []string) {
+	job, err := c.getJobFromArgs(args)
+	if err != nil {
+		s.SendError(err)
+		return
+	}
+
+	if s.Author.ID != job.RequesterID && s.Author.ID != c.adminID {
+		s.SendErrorf("Only the requester of job #%v can cancel it!", job.ID)
+		return
+	}
+
+	// Uploads cannot be interrupted halfway
+	if job.Status() == DownloadJobStatusUploading {
+		s.SendErrorf("Job #%v is already uploading and can no longer be cancelled!", job.ID)
+		return
+	}
+
+	job.cancel(ErrJobCancelled)
+	s.SendMessage("Cancelling job #%v", job.ID)
+}
+
+func (c *DownloadCog) getJobFromArgs(args []string) (*DownloadJob, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("Usage: <job ID>")
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil {
+		return nil, ErrJobNotFound
+	}
+
+	job, ok := c.jobs.get(id)
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	return job, nil
+}
@@ -0,0 +1,307 @@
+package cog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/hls"
+	httpclient "github.com/xIceArcher/go-leah/http"
+	"github.com/xIceArcher/go-leah/storage"
+	"github.com/xIceArcher/go-leah/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	CacheKeyStreamlinkJobPrefix = "go-leah/download/streamlink/"
+	CacheKeyStreamlinkJobFormat = CacheKeyStreamlinkJobPrefix + "%s"
+
+	// Longer reports are only posted in brief, since Discord messages are limited to 2000 characters
+	maxReportMessageLength = 1900
+
+	streamlinkReportFileName = "report.txt"
+)
+
+// StreamlinkJob is everything needed to resume a streamlink download after the bot restarts
+type StreamlinkJob struct {
+	hls.Recording
+
+	GuildID   string `json:"guildID"`
+	ChannelID string `json:"channelID"`
+	MessageID string `json:"messageID"`
+
+	// Empty for jobs saved before requesters were recorded, which only the admin can cancel
+	RequesterID string `json:"requesterID"`
+
+	HTTPHeader  string `json:"httpHeader"`
+	HTTPCookies string `json:"httpCookies"`
+
+	FileName string `json:"fileName"`
+	Delete   bool   `json:"delete"`
+
+	// Container to remux the recording into, empty to upload the raw transport stream
+	RemuxFormat string `json:"remuxFormat"`
+	MergeRuns   bool   `json:"mergeRuns"`
+
+	// Upload the gap and integrity report next to the recording
+	UploadReport bool `json:"uploadReport"`
+
+	DownloadLimits
+}
+
+func (c *DownloadCog) resumeStreamlinkJobs() {
+	vals, err := c.cache.GetByPrefix(c.ctx, CacheKeyStreamlinkJobPrefix)
+	if err != nil {
+		c.session.Logger.With(zap.Error(err)).Error("Failed to fetch old streamlink jobs")
+		return
+	}
+
+	for key, val := range vals {
+		job := &StreamlinkJob{}
+		if err := json.Unmarshal([]byte(fmt.Sprintf("%v", val)), job); err != nil {
+			c.session.Logger.With(zap.Error(err), zap.String("key", key)).Warn("Failed to parse streamlink job")
+			continue
+		}
+
+		logger := c.session.Logger.With(zap.String("fileName", job.FileName))
+		s := c.session.WithLogger(logger).WithMessage(&discordgo.Message{
+			ID:        job.MessageID,
+			ChannelID: job.ChannelID,
+			GuildID:   job.GuildID,
+		})
+
+		job.RemoveMissingSegments()
+
+		downloadJob := c.jobs.add(c.ctx, DownloadJobTypeStreamlink, job.FileName, job.RequesterID, job.ChannelID)
+		s.SendMessage("Resuming download of %s as job #%v", job.FileName, downloadJob.ID)
+
+		c.wg.Add(1)
+		go c.runStreamlinkJob(s, job, downloadJob)
+	}
+}
+
+func (c *DownloadCog) runStreamlinkJob(s *discord.MessageSession, job *StreamlinkJob, downloadJob *DownloadJob) {
+	defer c.wg.Done()
+	defer c.jobs.remove(downloadJob)
+
+	if !job.IsEnded {
+		client := httpclient.NewClientWithHeadersAndCookiesStr(job.HTTPHeader, job.HTTPCookies)
+
+		bar, err := s.SendBytesProgressBar(1, "Downloading")
+		if err != nil {
+			s.SendError(err)
+			return
+		}
+		downloadJob.SetProgressBar(bar)
+
+		err = hls.Record(downloadJob.ctx, client, &job.Recording, c.downloaderOptions(&job.DownloadLimits), bar, s.Logger, func() {
+			if err := c.saveStreamlinkJob(c.ctx, job); err != nil {
+				s.Logger.With(zap.Error(err)).Warn("Failed to save job")
+			}
+		})
+		bar.Add(1)
+		if downloadJob.ctx.Err() != nil {
+			c.interruptStreamlinkJob(s, job, downloadJob)
+			return
+		} else if err != nil {
+			s.SendError(err)
+			c.clearStreamlinkJob(s, job)
+			return
+		}
+
+		summary := job.Summarize()
+		sendReport(s, job.FileName, summary)
+		if job.UploadReport {
+			if err := writeReport(job, summary); err != nil {
+				s.SendError(err)
+			}
+		}
+
+		// Segments that failed to download are reported as gaps, so they are left out of the upload too
+		job.RemoveMissingSegments()
+
+		// Save before uploading so that a restart does not download the stream again
+		if err := c.saveStreamlinkJob(context.Background(), job); err != nil {
+			s.Logger.With(zap.Error(err)).Warn("Failed to save job")
+		}
+	}
+
+	uploadFileName, uploadRuns := job.FileName, job.SortedRuns()
+	if job.RemuxFormat != "" {
+		downloadJob.SetStatus(DownloadJobStatusRemuxing)
+		s.SendMessage("Remuxing %s...", job.FileName)
+
+		remuxedFilePaths, err := remuxRuns(downloadJob.ctx, job)
+		if downloadJob.ctx.Err() != nil {
+			c.interruptStreamlinkJob(s, job, downloadJob)
+			return
+		} else if err != nil {
+			s.SendError(err)
+			c.clearStreamlinkJob(s, job)
+			return
+		}
+
+		uploadFileName = replaceExtension(job.FileName, job.RemuxFormat)
+		uploadRuns = make([][]string, 0, len(remuxedFilePaths))
+		for _, remuxedFilePath := range remuxedFilePaths {
+			uploadRuns = append(uploadRuns, []string{remuxedFilePath})
+		}
+	}
+
+	defer c.clearStreamlinkJob(s, job)
+
+	if c.storage != nil {
+		downloadJob.SetStatus(DownloadJobStatusUploading)
+		if _, err := handleUpload(c.storage, s, uploadFileName, uploadRuns); err != nil {
+			s.SendError(err)
+			return
+		}
+
+		if job.UploadReport {
+			if err := uploadReport(c.storage, job); err != nil {
+				s.SendError(err)
+			}
+		}
+
+		if job.Delete {
+			s.SendMessage("Clearing disk space...")
+			if err := os.RemoveAll(job.Directory); err != nil {
+				s.SendError(err)
+				return
+			}
+		}
+	}
+
+	c.Disk(c.ctx, s, []string{})
+}
+
+// interruptStreamlinkJob forgets the job if it was cancelled by a user, otherwise it saves the job to be resumed later
+func (c *DownloadCog) interruptStreamlinkJob(s *discord.MessageSession, job *StreamlinkJob, downloadJob *DownloadJob) {
+	if downloadJob.IsCancelled() {
+		// Downloaded segments are left on disk
+		s.SendMessage("Cancelled download of %s", job.FileName)
+		c.clearStreamlinkJob(s, job)
+		return
+	}
+
+	// Cannot use c.ctx here since it has already been cancelled
+	if err := c.saveStreamlinkJob(context.Background(), job); err != nil {
+		s.Logger.With(zap.Error(err)).Error("Failed to save job")
+		return
+	}
+
+	s.SendMessage("Download of %s paused, it will resume when the bot is back", job.FileName)
+}
+
+func sendReport(s *discord.MessageSession, fileName string, summary *hls.Summary) {
+	if summary.IsClean() {
+		s.SendMessage("Report for %s: %s", fileName, summary.String())
+		return
+	}
+
+	report := summary.String()
+	if len(report) > maxReportMessageLength {
+		report = summary.Brief()
+	}
+
+	s.SendMessage("Report for %s:\n```\n%s\n```", fileName, report)
+}
+
+// writeReport writes the report next to the segments of the job, since it cannot be summarized again once missing segments are removed
+func writeReport(job *StreamlinkJob, summary *hls.Summary) error {
+	return os.WriteFile(filepath.Join(job.Directory, streamlinkReportFileName), []byte(summary.String()+"\n"), 0644)
+}
+
+// uploadReport uploads the report of the job as a text file named after the recording
+func uploadReport(storage storage.Storage, job *StreamlinkJob) error {
+	reportFilePath := filepath.Join(job.Directory, streamlinkReportFileName)
+	if _, err := os.Stat(reportFilePath); errors.Is(err, os.ErrNotExist) {
+		// Jobs saved before reports were written when the download ended
+		if err := writeReport(job, job.Summarize()); err != nil {
+			return err
+		}
+	}
+
+	reportFileName := strings.TrimSuffix(job.FileName, path.Ext(job.FileName)) + ".report.txt"
+	_, err := storage.UploadAndConcat(reportFileName, []string{reportFilePath})
+	return err
+}
+
+// remuxRuns remuxes each run of the job into its remux format, or a single file if runs are merged.
+// The remuxed files are returned in the same order as the runs.
+func remuxRuns(ctx context.Context, job *StreamlinkJob) ([]string, error) {
+	runs := job.SortedRuns()
+
+	tsFilePaths := make([]string, 0, len(runs))
+	for runNo, run := range runs {
+		tsFilePath := filepath.Join(job.Directory, fmt.Sprintf("run_%v.ts", runNo+1))
+		if err := utils.ConcatFiles(tsFilePath, run); err != nil {
+			return nil, err
+		}
+		tsFilePaths = append(tsFilePaths, tsFilePath)
+	}
+
+	if job.MergeRuns && len(tsFilePaths) > 1 {
+		chapters := make([]*utils.VideoChapter, 0, len(tsFilePaths))
+		for i, tsFilePath := range tsFilePaths {
+			chapters = append(chapters, &utils.VideoChapter{
+				Title:    fmt.Sprintf("Part %v", i+1),
+				FilePath: tsFilePath,
+			})
+		}
+
+		outFilePath := filepath.Join(job.Directory, "remuxed."+job.RemuxFormat)
+		if err := utils.MergeVideosWithChapters(ctx, chapters, outFilePath); err != nil {
+			return nil, err
+		}
+
+		return []string{outFilePath}, nil
+	}
+
+	outFilePaths := make([]string, 0, len(tsFilePaths))
+	for runNo, tsFilePath := range tsFilePaths {
+		outFilePath := filepath.Join(job.Directory, fmt.Sprintf("remuxed_%v.%s", runNo+1, job.RemuxFormat))
+		if err := utils.RemuxVideo(ctx, tsFilePath, outFilePath); err != nil {
+			return nil, err
+		}
+		outFilePaths = append(outFilePaths, outFilePath)
+	}
+
+	return outFilePaths, nil
+}
+
+func replaceExtension(fileName string, extension string) string {
+	return strings.TrimSuffix(fileName, path.Ext(fileName)) + "." + extension
+}
+
+func (c *DownloadCog) saveStreamlinkJob(ctx context.Context, job *StreamlinkJob) error {
+	jobBytes, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	return c.cache.Set(ctx, fmt.Sprintf(CacheKeyStreamlinkJobFormat, job.MessageID), jobBytes)
+}
+
+func (c *DownloadCog) clearStreamlinkJob(s *discord.MessageSession, job *StreamlinkJob) {
+	// The job is finished even if the bot is shutting down, so c.ctx cannot be used here
+	if err := c.cache.Clear(context.Background(), fmt.Sprintf(CacheKeyStreamlinkJobFormat, job.MessageID)); err != nil {
+		s.Logger.With(zap.Error(err)).Error("Failed to clear job")
+	}
+}
+
+func (c *DownloadCog) Stop() {
+	c.cancel()
+	c.wg.Wait()
+
+	if c.youtubeRecorder != nil {
+		c.youtubeRecorder.Stop()
+	}
+}
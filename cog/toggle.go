@@ -0,0 +1,162 @@
+package cog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xIceArcher/go-leah/cache"
+	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/toggle"
+)
+
+const (
+	// The toggle cog cannot be toggled, otherwise it would be impossible to turn it back on
+	ToggleCogName = "toggle"
+)
+
+var (
+	ErrToggleUnknownFeature error = fmt.Errorf("Unknown feature!")
+	ErrToggleNotInGuild     error = fmt.Errorf("Guild toggles can only be set in a server!")
+)
+
+type ToggleCog struct {
+	GenericCog
+
+	toggles *toggle.Store
+
+	// Maps feature names to whether they are enabled by default
+	features map[string]bool
+}
+
+func NewToggleCog(cfg *config.Config, s *discord.Session) (Cog, error) {
+	c, err := cache.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	features := make(map[string]bool)
+	for name, handlerCfg := range cfg.Discord.Handlers {
+		features[name] = !handlerCfg.IsDisabledByDefault
+	}
+	for name, cogCfg := range cfg.Discord.Cogs {
+		if name != ToggleCogName {
+			features[name] = !cogCfg.IsDisabledByDefault
+		}
+	}
+
+	cog := &ToggleCog{
+		toggles:  toggle.New(c),
+		features: features,
+	}
+
+	cog.allCommands = map[string]CommandFunc{
+		"enable":  cog.Enable,
+		"disable": cog.Disable,
+		"unset":   cog.Unset,
+		"toggles": cog.Toggles,
+	}
+
+	return cog, nil
+}
+
+func (c *ToggleCog) Enable(ctx context.Context, s *discord.MessageSession, args []string) {
+	c.set(ctx, s, args, true)
+}
+
+func (c *ToggleCog) Disable(ctx context.Context, s *discord.MessageSession, args []string) {
+	c.set(ctx, s, args, false)
+}
+
+func (c *ToggleCog) set(ctx context.Context, s *discord.MessageSession, args []string, isEnabled bool) {
+	feature, scope, id, err := c.parseArgs(s, args)
+	if err != nil {
+		s.SendError(err)
+		return
+	}
+
+	if err := c.toggles.Set(ctx, scope, id, feature, isEnabled); err != nil {
+		s.SendInternalError(err)
+		return
+	}
+
+	state := "Disabled"
+	if isEnabled {
+		state = "Enabled"
+	}
+	s.SendMessage("%s %s in this %s", state, feature, scope)
+}
+
+func (c *ToggleCog) Unset(ctx context.Context, s *discord.MessageSession, args []string) {
+	feature, scope, id, err := c.parseArgs(s, args)
+	if err != nil {
+		s.SendError(err)
+		return
+	}
+
+	if err := c.toggles.Unset(ctx, scope, id, feature); err != nil {
+		s.SendInternalError(err)
+		return
+	}
+
+	s.SendMessage("Removed override of %s in this %s", feature, scope)
+}
+
+func (c *ToggleCog) Toggles(ctx context.Context, s *discord.MessageSession, args []string) {
+	features := make([]string, 0, len(c.features))
+	for feature := range c.features {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+
+	lines := make([]string, 0, len(features))
+	for _, feature := range features {
+		isEnabled, source, err := c.toggles.GetState(ctx, s.GuildID, s.ChannelID, feature, c.features[feature])
+		if err != nil {
+			s.SendInternalError(err)
+			return
+		}
+
+		state := ":red_square:"
+		if isEnabled {
+			state = ":green_square:"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s (%s)", state, feature, source))
+	}
+
+	s.SendMessage(strings.Join(lines, "\n"))
+}
+
+func (c *ToggleCog) parseArgs(s *discord.MessageSession, args []string) (feature string, scope toggle.Scope, id string, err error) {
+	if len(args) == 0 || len(args) > 2 {
+		return "", "", "", fmt.Errorf("Usage: <feature> [channel|guild]")
+	}
+
+	feature = args[0]
+	if _, ok := c.features[feature]; !ok {
+		return "", "", "", ErrToggleUnknownFeature
+	}
+
+	scope = toggle.ScopeChannel
+	if len(args) == 2 {
+		scope, err = toggle.ParseScope(args[1])
+		if errors.Is(err, toggle.ErrUnknownScope) {
+			return "", "", "", fmt.Errorf("Scope must be either channel or guild!")
+		}
+	}
+
+	switch scope {
+	case toggle.ScopeGuild:
+		if s.GuildID == "" {
+			return "", "", "", ErrToggleNotInGuild
+		}
+		id = s.GuildID
+	case toggle.ScopeChannel:
+		id = s.ChannelID
+	}
+
+	return feature, scope, id, nil
+}
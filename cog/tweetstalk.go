@@ -0,0 +1,385 @@
+package cog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xIceArcher/go-leah/cache"
+	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/twitter"
+	"github.com/xIceArcher/go-leah/utils"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	CacheKeyTweetStalkPrefix = "go-leah/tweetStalk/"
+
+	// Maps to the ID of the last tweet posted to the channel
+	CacheKeyTweetStalkStalksPrefix = CacheKeyTweetStalkPrefix + "stalks/"
+	CacheKeyTweetStalkStalkFormat  = CacheKeyTweetStalkStalksPrefix + "%s/%s"
+
+	// Maps to the hex color of the account's embeds
+	CacheKeyTweetStalkColorFormat = CacheKeyTweetStalkPrefix + "colors/%s"
+
+	tweetStalkPollInterval = time.Minute
+)
+
+var (
+	ErrTweetStalkUserNotFound error = fmt.Errorf("Twitter user not found!")
+	ErrTweetStalkInvalidColor error = fmt.Errorf("Color must be a hex code like #1DA1F2!")
+
+	tweetStalkColorRegex      = regexp.MustCompile(`^#?([0-9A-Fa-f]{6})$`)
+	tweetStalkScreenNameRegex = regexp.MustCompile(`^[A-Za-z0-9_]{1,15}$`)
+)
+
+type TweetStalkCog struct {
+	GenericCog
+
+	api     twitter.API
+	cache   cache.Cache
+	session *discord.Session
+
+	// Serializes writes to stalks, so that polling cannot bring back a stalk that was just removed
+	stalksMu sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type tweetStalk struct {
+	ChannelID   string
+	ScreenName  string
+	LastTweetID string
+}
+
+func NewTweetStalkCog(cfg *config.Config, s *discord.Session) (Cog, error) {
+	c, err := cache.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cog := &TweetStalkCog{
+		api:     twitter.NewCachedAPI(c, s.Logger),
+		cache:   c,
+		session: s,
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	cog.allCommands = map[string]CommandFunc{
+		"stalk":   cog.Stalk,
+		"unstalk": cog.Unstalk,
+		"stalks":  cog.Stalks,
+		"color":   cog.Color,
+	}
+
+	cog.wg.Add(1)
+	go cog.pollTask()
+
+	return cog, nil
+}
+
+func (c *TweetStalkCog) Stalk(ctx context.Context, s *discord.MessageSession, args []string) {
+	if len(args) == 0 {
+		s.SendErrorf("Usage: stalk <screen name>...")
+		return
+	}
+
+	for _, screenName := range args {
+		screenName = normalizeScreenName(screenName)
+		if !tweetStalkScreenNameRegex.MatchString(screenName) {
+			s.SendErrorf("@%s is not a valid screen name!", screenName)
+			continue
+		}
+
+		tweets, err := c.api.GetUserTweets(screenName)
+		if errors.Is(err, twitter.ErrNotFound) {
+			s.SendError(ErrTweetStalkUserNotFound)
+			continue
+		} else if err != nil {
+			s.SendInternalError(err)
+			continue
+		}
+
+		// Start from the latest tweet so that the channel isn't flooded with old tweets
+		lastTweetID := "0"
+		for _, tweet := range tweets {
+			if tweet.IsNewerThan(lastTweetID) {
+				lastTweetID = tweet.ID
+			}
+		}
+
+		c.stalksMu.Lock()
+		err = c.cache.Set(ctx, fmt.Sprintf(CacheKeyTweetStalkStalkFormat, s.ChannelID, screenName), lastTweetID)
+		c.stalksMu.Unlock()
+		if err != nil {
+			s.SendInternalError(err)
+			continue
+		}
+
+		s.SendMessage("Now stalking @%s", screenName)
+	}
+}
+
+func (c *TweetStalkCog) Unstalk(ctx context.Context, s *discord.MessageSession, args []string) {
+	if len(args) == 0 {
+		s.SendErrorf("Usage: unstalk <screen name>...")
+		return
+	}
+
+	for _, screenName := range args {
+		screenName = normalizeScreenName(screenName)
+
+		if err := c.removeStalk(ctx, s.ChannelID, screenName); errors.Is(err, cache.ErrNotFound) {
+			s.SendErrorf("Not stalking @%s!", screenName)
+			continue
+		} else if err != nil {
+			s.SendInternalError(err)
+			continue
+		}
+
+		s.SendMessage("Stopped stalking @%s", screenName)
+	}
+}
+
+func (c *TweetStalkCog) removeStalk(ctx context.Context, channelID string, screenName string) error {
+	c.stalksMu.Lock()
+	defer c.stalksMu.Unlock()
+
+	cacheKey := fmt.Sprintf(CacheKeyTweetStalkStalkFormat, channelID, screenName)
+	if _, err := c.cache.Get(ctx, cacheKey); err != nil {
+		return err
+	}
+
+	return c.cache.Clear(ctx, cacheKey)
+}
+
+func (c *TweetStalkCog) Stalks(ctx context.Context, s *discord.MessageSession, args []string) {
+	stalks, err := c.getStalks(ctx)
+	if err != nil {
+		s.SendInternalError(err)
+		return
+	}
+
+	lines := make([]string, 0)
+	for _, stalk := range stalks {
+		if stalk.ChannelID != s.ChannelID {
+			continue
+		}
+
+		line := "@" + stalk.ScreenName
+		if color, err := c.getColor(ctx, stalk.ScreenName); err == nil {
+			line += fmt.Sprintf(" (#%s)", color)
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		s.SendMessage("Not stalking anyone in this channel")
+		return
+	}
+
+	sort.Strings(lines)
+	s.SendMessage("Stalking: %s", strings.Join(lines, ", "))
+}
+
+func (c *TweetStalkCog) Color(ctx context.Context, s *discord.MessageSession, args []string) {
+	if len(args) == 0 || len(args) > 2 {
+		s.SendErrorf("Usage: color <screen name> [hex color]")
+		return
+	}
+
+	screenName := normalizeScreenName(args[0])
+	cacheKey := fmt.Sprintf(CacheKeyTweetStalkColorFormat, screenName)
+
+	if len(args) == 1 {
+		if err := c.cache.Clear(ctx, cacheKey); err != nil {
+			s.SendInternalError(err)
+			return
+		}
+
+		s.SendMessage("Reset color of @%s", screenName)
+		return
+	}
+
+	matches := tweetStalkColorRegex.FindStringSubmatch(args[1])
+	if len(matches) <= 1 {
+		s.SendError(ErrTweetStalkInvalidColor)
+		return
+	}
+	color := strings.ToUpper(matches[1])
+
+	if err := c.cache.Set(ctx, cacheKey, color); err != nil {
+		s.SendInternalError(err)
+		return
+	}
+
+	s.SendMessage("Set color of @%s to #%s", screenName, color)
+}
+
+func (c *TweetStalkCog) pollTask() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(tweetStalkPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.poll()
+		}
+	}
+}
+
+func (c *TweetStalkCog) poll() {
+	stalks, err := c.getStalks(c.ctx)
+	if err != nil {
+		c.session.Logger.With(zap.Error(err)).Error("Failed to get stalks")
+		return
+	}
+
+	stalksByScreenName := make(map[string][]*tweetStalk)
+	for _, stalk := range stalks {
+		stalksByScreenName[stalk.ScreenName] = append(stalksByScreenName[stalk.ScreenName], stalk)
+	}
+
+	for screenName, stalks := range stalksByScreenName {
+		if c.ctx.Err() != nil {
+			return
+		}
+
+		logger := c.session.Logger.With(zap.String("screenName", screenName))
+
+		tweets, err := c.api.GetUserTweets(screenName)
+		if err != nil {
+			logger.With(zap.Error(err)).Warn("Failed to get tweets")
+			continue
+		}
+
+		// Post the oldest tweets first
+		slices.SortFunc(tweets, func(a, b *twitter.Tweet) bool {
+			return b.IsNewerThan(a.ID)
+		})
+
+		for _, stalk := range stalks {
+			for _, tweet := range tweets {
+				if !tweet.IsNewerThan(stalk.LastTweetID) {
+					continue
+				}
+
+				stalkLogger := logger.With(zap.String("channelID", stalk.ChannelID), zap.String("tweetID", tweet.ID))
+				if err := c.sendTweet(stalk.ChannelID, tweet); errors.Is(err, discord.ErrMissingPermissions) {
+					// Retrying will not help, so skip the tweet instead of trying again every poll
+					stalkLogger.Warn("Missing permissions to post tweet, skipping")
+				} else if err != nil {
+					// Retry this and any newer tweets at the next poll so that they are posted in order
+					stalkLogger.With(zap.Error(err)).Warn("Failed to post tweet")
+					break
+				}
+				stalk.LastTweetID = tweet.ID
+
+				if isStalked, err := c.updateLastTweetID(stalk); err != nil {
+					logger.With(zap.Error(err)).Error("Failed to write to cache")
+				} else if !isStalked {
+					stalkLogger.Info("Unstalked while polling")
+					break
+				}
+			}
+		}
+	}
+}
+
+// updateLastTweetID saves the last tweet posted for the stalk, and returns false without saving if it was removed in the meantime
+func (c *TweetStalkCog) updateLastTweetID(stalk *tweetStalk) (bool, error) {
+	c.stalksMu.Lock()
+	defer c.stalksMu.Unlock()
+
+	// Cannot use c.ctx here since the tweet has already been handled
+	cacheKey := fmt.Sprintf(CacheKeyTweetStalkStalkFormat, stalk.ChannelID, stalk.ScreenName)
+	if _, err := c.cache.Get(context.Background(), cacheKey); errors.Is(err, cache.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, c.cache.Set(context.Background(), cacheKey, stalk.LastTweetID)
+}
+
+func (c *TweetStalkCog) sendTweet(channelID string, tweet *twitter.Tweet) error {
+	embeds := tweet.GetEmbeds()
+	if color, err := c.getColor(c.ctx, tweet.User.ScreenName); err == nil {
+		for _, embed := range embeds {
+			embed.Color = utils.ParseHexColor(color)
+		}
+	}
+
+	if _, err := c.session.SendEmbeds(channelID, embeds); err != nil {
+		return err
+	}
+
+	for _, video := range tweet.Videos() {
+		if video.Type == twitter.MediaTypeGIF && strings.HasSuffix(video.URL, ".mp4") {
+			c.session.SendMP4URLAsGIF(channelID, video.URL, tweet.ID)
+		} else {
+			c.session.SendVideoURL(channelID, video.URL, tweet.ID)
+		}
+	}
+
+	return nil
+}
+
+func (c *TweetStalkCog) getStalks(ctx context.Context) ([]*tweetStalk, error) {
+	vals, err := c.cache.GetByPrefix(ctx, CacheKeyTweetStalkStalksPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	stalks := make([]*tweetStalk, 0, len(vals))
+	for key, val := range vals {
+		keySplit := strings.Split(strings.TrimPrefix(key, CacheKeyTweetStalkStalksPrefix), "/")
+		if len(keySplit) != 2 {
+			c.session.Logger.With(zap.String("key", key)).Warn("Unknown key")
+			continue
+		}
+
+		stalks = append(stalks, &tweetStalk{
+			ChannelID:   keySplit[0],
+			ScreenName:  keySplit[1],
+			LastTweetID: fmt.Sprintf("%v", val),
+		})
+	}
+
+	return stalks, nil
+}
+
+func (c *TweetStalkCog) getColor(ctx context.Context, screenName string) (string, error) {
+	val, err := c.cache.Get(ctx, fmt.Sprintf(CacheKeyTweetStalkColorFormat, normalizeScreenName(screenName)))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%v", val), nil
+}
+
+func (c *TweetStalkCog) Stop() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+func normalizeScreenName(screenName string) string {
+	return strings.ToLower(strings.TrimPrefix(screenName, "@"))
+}
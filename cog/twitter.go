@@ -33,7 +33,7 @@ type TwitterCog struct {
 func NewTwitterCog(cfg *config.Config, s *discord.Session) (Cog, error) {
 	c := &TwitterCog{}
 
-	cache, err := cache.NewRedisCache(cfg.Redis)
+	cache, err := cache.New(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,232 @@
+package cog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/grafov/m3u8"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	alternativeTypeAudio = "AUDIO"
+)
+
+var (
+	ErrNoMatchingVariant   error = fmt.Errorf("No variant matches the given options!")
+	ErrNoAudioRenditions   error = fmt.Errorf("Playlist has no separate audio renditions!")
+	ErrNoMatchingRendition error = fmt.Errorf("No audio rendition matches the given name!")
+)
+
+// VariantOptions narrows down which variant of a master playlist is downloaded.
+// Zero values mean no restriction.
+type VariantOptions struct {
+	MaxHeight int
+	Codec     string
+	MaxFPS    float64
+	Name      string
+	AudioOnly bool
+}
+
+// selectMediaURI returns the URI of the media playlist in the master playlist that best matches the options
+func selectMediaURI(master *m3u8.MasterPlaylist, opts *VariantOptions) (string, error) {
+	variantOpts := *opts
+	if opts.AudioOnly {
+		// The name refers to the audio rendition instead
+		variantOpts.Name = ""
+	}
+
+	variant, err := selectVariant(master.Variants, &variantOpts)
+	if err != nil {
+		return "", err
+	}
+
+	if !opts.AudioOnly {
+		return variant.URI, nil
+	}
+
+	rendition, err := selectAudioRendition(master.Variants, variant, opts.Name)
+	if err != nil {
+		return "", err
+	}
+
+	return rendition.URI, nil
+}
+
+// selectVariant returns the highest bandwidth variant that matches the options
+func selectVariant(variants []*m3u8.Variant, opts *VariantOptions) (*m3u8.Variant, error) {
+	var bestVariant *m3u8.Variant
+	for _, variant := range variants {
+		if variant.Iframe {
+			continue
+		}
+
+		if opts.Name != "" && !strings.EqualFold(opts.Name, variantName(variant)) && !strings.EqualFold(opts.Name, variant.Name) {
+			continue
+		}
+
+		// Variants that do not declare their resolution, codecs or frame rate are not filtered out
+		if height := variantHeight(variant); opts.MaxHeight > 0 && height > opts.MaxHeight {
+			continue
+		}
+
+		if opts.Codec != "" && variant.Codecs != "" && !hasCodec(variant, opts.Codec) {
+			continue
+		}
+
+		if opts.MaxFPS > 0 && variant.FrameRate > opts.MaxFPS {
+			continue
+		}
+
+		if bestVariant == nil || variant.Bandwidth > bestVariant.Bandwidth {
+			bestVariant = variant
+		}
+	}
+
+	if bestVariant == nil {
+		return nil, ErrNoMatchingVariant
+	}
+
+	return bestVariant, nil
+}
+
+// selectAudioRendition returns the audio rendition with the given name, otherwise the default audio rendition of the variant
+func selectAudioRendition(variants []*m3u8.Variant, variant *m3u8.Variant, name string) (*m3u8.Alternative, error) {
+	renditions := audioRenditions(variants)
+	if len(renditions) == 0 {
+		return nil, ErrNoAudioRenditions
+	}
+
+	if name != "" {
+		for _, rendition := range renditions {
+			if strings.EqualFold(rendition.Name, name) || strings.EqualFold(rendition.Language, name) {
+				return rendition, nil
+			}
+		}
+		return nil, ErrNoMatchingRendition
+	}
+
+	var bestRendition *m3u8.Alternative
+	for _, rendition := range renditions {
+		isInGroup := rendition.GroupId == variant.Audio
+		if bestRendition == nil ||
+			(isInGroup && bestRendition.GroupId != variant.Audio) ||
+			(isInGroup == (bestRendition.GroupId == variant.Audio) && rendition.Default && !bestRendition.Default) {
+			bestRendition = rendition
+		}
+	}
+
+	return bestRendition, nil
+}
+
+// audioRenditions returns the audio renditions with their own playlists, since all variants share the same list of renditions
+func audioRenditions(variants []*m3u8.Variant) []*m3u8.Alternative {
+	renditions := make([]*m3u8.Alternative, 0)
+	seenURIs := make(map[string]struct{})
+
+	for _, variant := range variants {
+		for _, alternative := range variant.Alternatives {
+			if alternative == nil || alternative.Type != alternativeTypeAudio || alternative.URI == "" {
+				continue
+			}
+
+			if _, ok := seenURIs[alternative.URI]; ok {
+				continue
+			}
+			seenURIs[alternative.URI] = struct{}{}
+
+			renditions = append(renditions, alternative)
+		}
+	}
+
+	return renditions
+}
+
+// formatVariants lists the variants and audio renditions of the master playlist for Discord
+func formatVariants(master *m3u8.MasterPlaylist) string {
+	variants := make([]*m3u8.Variant, 0, len(master.Variants))
+	for _, variant := range master.Variants {
+		if !variant.Iframe {
+			variants = append(variants, variant)
+		}
+	}
+
+	slices.SortFunc(variants, func(a, b *m3u8.Variant) bool {
+		return a.Bandwidth > b.Bandwidth
+	})
+
+	lines := []string{"**Variants:**"}
+	for _, variant := range variants {
+		details := make([]string, 0)
+		if variant.Resolution != "" {
+			details = append(details, variant.Resolution)
+		}
+		if variant.FrameRate > 0 {
+			details = append(details, fmt.Sprintf("%v fps", variant.FrameRate))
+		}
+		details = append(details, fmt.Sprintf("%s/s", units.HumanSize(float64(variant.Bandwidth)/8)))
+		if variant.Codecs != "" {
+			details = append(details, variant.Codecs)
+		}
+
+		lines = append(lines, fmt.Sprintf("`%s` %s", variantName(variant), strings.Join(details, ", ")))
+	}
+
+	if renditions := audioRenditions(master.Variants); len(renditions) > 0 {
+		lines = append(lines, "**Audio renditions:**")
+		for _, rendition := range renditions {
+			line := fmt.Sprintf("`%s`", rendition.Name)
+			if rendition.Language != "" {
+				line += fmt.Sprintf(" (%s)", rendition.Language)
+			}
+			if rendition.Default {
+				line += " default"
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// variantName returns the name of the variant, such as 1080p60, which can be passed to --variant
+func variantName(variant *m3u8.Variant) string {
+	if height := variantHeight(variant); height > 0 {
+		name := fmt.Sprintf("%vp", height)
+		if variant.FrameRate > 30 {
+			name += fmt.Sprintf("%.0f", variant.FrameRate)
+		}
+		return name
+	}
+
+	if variant.Name != "" {
+		return variant.Name
+	}
+
+	return fmt.Sprintf("%vk", variant.Bandwidth/1000)
+}
+
+func variantHeight(variant *m3u8.Variant) int {
+	_, heightStr, ok := strings.Cut(variant.Resolution, "x")
+	if !ok {
+		return 0
+	}
+
+	height, err := strconv.Atoi(heightStr)
+	if err != nil {
+		return 0
+	}
+
+	return height
+}
+
+func hasCodec(variant *m3u8.Variant, codec string) bool {
+	for _, variantCodec := range strings.Split(variant.Codecs, ",") {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(variantCodec)), strings.ToLower(codec)) {
+			return true
+		}
+	}
+	return false
+}
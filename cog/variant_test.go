@@ -0,0 +1,50 @@
+package cog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grafov/m3u8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMasterPlaylist = `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUPID="aac",NAME="English",LANGUAGE="en",DEFAULT=YES,URI="audio_en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUPID="aac",NAME="Japanese",LANGUAGE="ja",DEFAULT=NO,URI="audio_ja.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=8000000,RESOLUTION=1920x1080,FRAME-RATE=60.000,CODECS="hvc1.1.6.L123.B0,mp4a.40.2",AUDIO="aac"
+1080p60_hevc.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=6000000,RESOLUTION=1920x1080,FRAME-RATE=60.000,CODECS="avc1.64002A,mp4a.40.2",AUDIO="aac"
+1080p60.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=4000000,RESOLUTION=1920x1080,FRAME-RATE=30.000,CODECS="avc1.64002A,mp4a.40.2",AUDIO="aac"
+1080p30.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2000000,RESOLUTION=1280x720,FRAME-RATE=30.000,CODECS="avc1.4D401F,mp4a.40.2",AUDIO="aac"
+720p.m3u8
+`
+
+func TestSelectMediaURI(t *testing.T) {
+	playlist, listType, err := m3u8.DecodeFrom(strings.NewReader(testMasterPlaylist), true)
+	require.NoError(t, err)
+	require.Equal(t, m3u8.MASTER, listType)
+	master := playlist.(*m3u8.MasterPlaylist)
+
+	for _, tc := range []struct {
+		opts     *VariantOptions
+		expected string
+	}{
+		{&VariantOptions{}, "1080p60_hevc.m3u8"},
+		{&VariantOptions{Codec: "avc1"}, "1080p60.m3u8"},
+		{&VariantOptions{MaxFPS: 30}, "1080p30.m3u8"},
+		{&VariantOptions{MaxHeight: 720}, "720p.m3u8"},
+		{&VariantOptions{Name: "720p"}, "720p.m3u8"},
+		{&VariantOptions{AudioOnly: true}, "audio_en.m3u8"},
+		{&VariantOptions{AudioOnly: true, Name: "ja"}, "audio_ja.m3u8"},
+	} {
+		uri, err := selectMediaURI(master, tc.opts)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, uri)
+	}
+
+	_, err = selectMediaURI(master, &VariantOptions{MaxHeight: 480})
+	assert.ErrorIs(t, err, ErrNoMatchingVariant)
+}
@@ -7,14 +7,19 @@ import (
 )
 
 type Config struct {
-	Google    *GoogleConfig  `yaml:"google"`
-	Instagram *InstaConfig   `yaml:"instagram"`
-	Twitch    *TwitchConfig  `yaml:"twitch"`
-	Redbook   *RedbookConfig `yaml:"redbook"`
-	QNAP      *QNAPConfig    `yaml:"qnap"`
+	Google    *GoogleConfig   `yaml:"google"`
+	Youtube   *YoutubeConfig  `yaml:"youtube"`
+	Instagram *InstaConfig    `yaml:"instagram"`
+	Twitch    *TwitchConfig   `yaml:"twitch"`
+	Redbook   *RedbookConfig  `yaml:"redbook"`
+	Pixiv     *PixivConfig    `yaml:"pixiv"`
+	QNAP      *QNAPConfig     `yaml:"qnap"`
+	Storage   *StorageConfig  `yaml:"storage"`
+	Download  *DownloadConfig `yaml:"download"`
 
 	Discord *DiscordConfig `yaml:"discord"`
 
+	Cache  *CacheConfig `yaml:"cache"`
 	Redis  *RedisConfig `yaml:"redis"`
 	Logger *LogConfig   `yaml:"logger"`
 }
@@ -23,10 +28,26 @@ type GoogleConfig struct {
 	APIKey string `yaml:"apiKey"`
 }
 
+type YoutubeConfig struct {
+	// Live streams tracked in these Discord channels or streamed by these YouTube channels are recorded when they go live
+	RecordDiscordChannelIDs []string `yaml:"recordDiscordChannelIDs"`
+	RecordYoutubeChannelIDs []string `yaml:"recordYoutubeChannelIDs"`
+}
+
 type InstaConfig struct {
 	PostURLFormat  string `yaml:"postUrlFormat"`
 	StoryURLFormat string `yaml:"storyUrlFormat"`
 	UserURLFormat  string `yaml:"userUrlFormat"`
+
+	StoryArchive *InstaStoryArchiveConfig `yaml:"storyArchive"`
+}
+
+type InstaStoryArchiveConfig struct {
+	// New stories of these users are uploaded to the storage
+	Usernames []string `yaml:"usernames"`
+
+	// If set, new stories are also posted to this channel
+	DiscordChannelID string `yaml:"discordChannelID"`
 }
 
 type TwitchConfig struct {
@@ -38,6 +59,11 @@ type RedbookConfig struct {
 	PostURL string `yaml:"postUrl"`
 }
 
+type PixivConfig struct {
+	// PHPSESSID cookie of a logged in account, needed for R-18 artworks
+	SessionID string `yaml:"sessionID"`
+}
+
 type QNAPConfig struct {
 	IsEnabled        bool   `yaml:"isEnabled"`
 	URL              string `yaml:"url"`
@@ -46,6 +72,45 @@ type QNAPConfig struct {
 	DownloadBasePath string `yaml:"downloadBasePath"`
 }
 
+type StorageConfig struct {
+	Type string `yaml:"type"`
+
+	Local  *LocalStorageConfig  `yaml:"local"`
+	S3     *S3StorageConfig     `yaml:"s3"`
+	WebDAV *WebDAVStorageConfig `yaml:"webdav"`
+}
+
+type LocalStorageConfig struct {
+	Path string `yaml:"path"`
+}
+
+type S3StorageConfig struct {
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	Prefix          string `yaml:"prefix"`
+	AccessKeyID     string `yaml:"accessKeyID"`
+	SecretAccessKey string `yaml:"secretAccessKey"`
+	UsePathStyle    bool   `yaml:"usePathStyle"`
+}
+
+type WebDAVStorageConfig struct {
+	URL      string `yaml:"url"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type DownloadConfig struct {
+	// Number of segments each job downloads at once, jobs can override it
+	NumWorkers int `yaml:"numWorkers"`
+
+	// Total bandwidth of all downloads, e.g. 10MB for 10 megabytes per second. Leave empty for no limit.
+	BandwidthLimit string `yaml:"bandwidthLimit"`
+
+	// Estimate the size of each segment from recently downloaded segments instead of requesting it
+	SkipHead bool `yaml:"skipHead"`
+}
+
 type DiscordConfig struct {
 	Token   string `yaml:"token"`
 	Prefix  string `yaml:"prefix"`
@@ -60,13 +125,36 @@ type DiscordConfig struct {
 }
 
 type DiscordCogConfig struct {
-	IsAdminOnly bool     `yaml:"isAdminOnly"`
-	Commands    []string `yaml:"commands"`
-	ChannelIDs  []string `yaml:"channelIDs"`
+	IsAdminOnly         bool     `yaml:"isAdminOnly"`
+	IsDisabledByDefault bool     `yaml:"isDisabledByDefault"`
+	Commands            []string `yaml:"commands"`
+	ChannelIDs          []string `yaml:"channelIDs"`
 }
 
 type DiscordHandlerConfig struct {
-	Regexes []string `yaml:"regexes"`
+	IsDisabledByDefault bool     `yaml:"isDisabledByDefault"`
+	Regexes             []string `yaml:"regexes"`
+
+	// Handlers with this set are handled by yt-dlp instead of a dedicated matcher
+	Ytdlp *YtdlpSiteConfig `yaml:"ytdlp"`
+}
+
+type YtdlpSiteConfig struct {
+	// Shown in the footer of the embed, defaults to the name of the yt-dlp extractor
+	Name    string `yaml:"name"`
+	IconURL string `yaml:"iconUrl"`
+	Color   string `yaml:"color"`
+
+	// Passed to yt-dlp before the URL, e.g. --cookies
+	Args []string `yaml:"args"`
+
+	// Only send the embed, without the video
+	EmbedOnly bool `yaml:"embedOnly"`
+}
+
+type CacheConfig struct {
+	Type         string `yaml:"type"`
+	SnapshotPath string `yaml:"snapshotPath"`
 }
 
 type RedisConfig struct {
@@ -1,5 +1,7 @@
 package consts
 
+import "syscall"
+
 const (
 	ColorNone = "000000"
 
@@ -12,8 +14,17 @@ const (
 	ColorTwitch  = "6441A4"
 	ColorTiktok  = "00F2EA"
 	ColorRedbook = "FF2842"
+	ColorWeibo   = "E6162D"
+	ColorBluesky = "0085FF"
+	ColorPixiv   = "0096FA"
 )
 
 const (
 	TimeFormatYYMMDDHHMMSS = "060102150405"
 )
+
+const (
+	// Sent by the bot to itself to reload the config or restart the process
+	SignalReload  = syscall.SIGHUP
+	SignalRestart = syscall.SIGUSR1
+)
@@ -0,0 +1,423 @@
+package dash
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xIceArcher/go-leah/utils"
+)
+
+// Live manifests without a time shift buffer depth are only downloaded from this far back
+const defaultTimeShiftBufferDepth = 60 * time.Second
+
+var (
+	ErrNoTracks            error = fmt.Errorf("No video or audio representations found!")
+	ErrProtected           error = fmt.Errorf("DRM protected representations cannot be downloaded!")
+	ErrUnsupportedSegments error = fmt.Errorf("Representation uses an unsupported segment addressing scheme!")
+)
+
+var (
+	templateRegex = regexp.MustCompile(`\$(RepresentationID|Number|Time|Bandwidth|)(?:%0(\d+)d)?\$`)
+	durationRegex = regexp.MustCompile(`^P(?:([\d.]+)Y)?(?:([\d.]+)M)?(?:([\d.]+)D)?(?:T(?:([\d.]+)H)?(?:([\d.]+)M)?(?:([\d.]+)S)?)?$`)
+)
+
+// InitSegment is the initialization segment that has to be prepended to the media segments of a track
+type InitSegment struct {
+	URL *url.URL
+
+	// Limit is 0 if the whole file is the initialization segment
+	Offset int64
+	Limit  int64
+}
+
+type Segment struct {
+	// Key orders the segments of a track and is unique across refreshes of a live manifest
+	Key int64
+	URL *url.URL
+}
+
+// SelectTracks returns the highest bandwidth video and audio tracks of the period, either of which may be nil
+func (p *Period) SelectTracks(maxHeight int) (video *Track, audio *Track, err error) {
+	hasProtected := false
+	for _, set := range p.AdaptationSets {
+		for _, rep := range set.Representations {
+			track := &Track{
+				Period:         p,
+				AdaptationSet:  set,
+				Representation: rep,
+			}
+
+			if track.IsProtected() {
+				hasProtected = true
+				continue
+			}
+
+			switch track.ContentType() {
+			case ContentTypeVideo:
+				if maxHeight > 0 && rep.Height > maxHeight {
+					continue
+				}
+
+				if video == nil || rep.Bandwidth > video.Representation.Bandwidth {
+					video = track
+				}
+			case ContentTypeAudio:
+				if audio == nil || rep.Bandwidth > audio.Representation.Bandwidth {
+					audio = track
+				}
+			}
+		}
+	}
+
+	if video == nil && audio == nil {
+		if hasProtected {
+			return nil, nil, ErrProtected
+		}
+		return nil, nil, ErrNoTracks
+	}
+
+	return video, audio, nil
+}
+
+// Track returns the track of the representation with the given ID
+func (p *Period) Track(representationID string) (*Track, bool) {
+	for _, set := range p.AdaptationSets {
+		for _, rep := range set.Representations {
+			if rep.ID == representationID {
+				return &Track{
+					Period:         p,
+					AdaptationSet:  set,
+					Representation: rep,
+				}, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// Segments returns the initialization segment of the track, which is nil if there is none,
+// and the media segments of the track that are available at now
+func (m *MPD) Segments(manifestUrl *url.URL, track *Track, now time.Time) (*InitSegment, []*Segment, error) {
+	baseUrl, err := m.baseURL(manifestUrl, track)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if template := track.segmentTemplate(); template != nil {
+		return m.templateSegments(baseUrl, track, template, now)
+	}
+
+	if list := track.segmentList(); list != nil {
+		return listSegments(baseUrl, list)
+	}
+
+	// Otherwise the whole representation is a single file, which already contains its initialization segment
+	if track.Representation.BaseURL == "" && track.AdaptationSet.BaseURL == "" {
+		return nil, nil, ErrUnsupportedSegments
+	}
+
+	return nil, []*Segment{{URL: baseUrl}}, nil
+}
+
+func (m *MPD) baseURL(manifestUrl *url.URL, track *Track) (*url.URL, error) {
+	baseUrl := manifestUrl
+	for _, ref := range []string{m.BaseURL, track.Period.BaseURL, track.AdaptationSet.BaseURL, track.Representation.BaseURL} {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+
+		var err error
+		baseUrl, err = baseUrl.Parse(ref)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return baseUrl, nil
+}
+
+func (m *MPD) templateSegments(baseUrl *url.URL, track *Track, template *SegmentTemplate, now time.Time) (*InitSegment, []*Segment, error) {
+	rep := track.Representation
+
+	timescale, startNumber := int64(1), int64(1)
+	if template.Timescale != nil && *template.Timescale > 0 {
+		timescale = *template.Timescale
+	}
+	if template.StartNumber != nil {
+		startNumber = *template.StartNumber
+	}
+
+	var initSegment *InitSegment
+	if template.Initialization != "" {
+		initUrl, err := baseUrl.Parse(fillTemplate(template.Initialization, rep, 0, 0))
+		if err != nil {
+			return nil, nil, err
+		}
+		initSegment = &InitSegment{URL: initUrl}
+	}
+
+	periodStart, _ := parseDuration(track.Period.Start)
+
+	var numbers, times []int64
+	if template.SegmentTimeline != nil {
+		// Segments repeated until the end of the period are only known up to the live edge of live streams
+		periodEnd := m.periodDuration(track.Period)
+		if m.IsDynamic() {
+			availabilityStartTime, _ := utils.ParseISOTime(m.AvailabilityStartTime)
+			periodEnd = now.Sub(availabilityStartTime) - periodStart
+		}
+
+		times = expandTimeline(template.SegmentTimeline, toTimescale(periodEnd, timescale)+template.PresentationTimeOffset)
+		for i := range times {
+			numbers = append(numbers, startNumber+int64(i))
+		}
+	} else if template.Duration > 0 {
+		segmentDuration := time.Duration(float64(template.Duration) / float64(timescale) * float64(time.Second))
+
+		first, count := int64(0), int64(0)
+		if m.IsDynamic() {
+			availabilityStartTime, ok := utils.ParseISOTime(m.AvailabilityStartTime)
+			if !ok {
+				return nil, nil, fmt.Errorf("invalid availability start time %s", m.AvailabilityStartTime)
+			}
+
+			// Segments are only available once they have ended
+			count = int64(now.Sub(availabilityStartTime.Add(periodStart)) / segmentDuration)
+
+			timeShiftBufferDepth, ok := parseDuration(m.TimeShiftBufferDepth)
+			if !ok {
+				timeShiftBufferDepth = defaultTimeShiftBufferDepth
+			}
+			first = count - int64(math.Ceil(float64(timeShiftBufferDepth)/float64(segmentDuration)))
+			if first < 0 {
+				first = 0
+			}
+		} else {
+			periodDuration := m.periodDuration(track.Period)
+			if periodDuration <= 0 {
+				return nil, nil, fmt.Errorf("period %s has no duration", track.Period.ID)
+			}
+
+			count = int64(math.Ceil(float64(periodDuration) / float64(segmentDuration)))
+		}
+
+		for i := first; i < count; i++ {
+			numbers = append(numbers, startNumber+i)
+			times = append(times, template.PresentationTimeOffset+i*template.Duration)
+		}
+	} else {
+		return nil, nil, ErrUnsupportedSegments
+	}
+
+	segments := make([]*Segment, 0, len(numbers))
+	for i := range numbers {
+		segmentUrl, err := baseUrl.Parse(fillTemplate(template.Media, rep, numbers[i], times[i]))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Numbers of timelines can shift between refreshes of a live manifest, while times cannot
+		key := numbers[i]
+		if template.SegmentTimeline != nil {
+			key = times[i]
+		}
+
+		segments = append(segments, &Segment{
+			Key: key,
+			URL: segmentUrl,
+		})
+	}
+
+	return initSegment, segments, nil
+}
+
+func listSegments(baseUrl *url.URL, list *SegmentList) (*InitSegment, []*Segment, error) {
+	var initSegment *InitSegment
+	if list.Initialization != nil {
+		initUrl, err := baseUrl.Parse(list.Initialization.SourceURL)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		offset, limit, err := parseByteRange(list.Initialization.Range)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		initSegment = &InitSegment{
+			URL:    initUrl,
+			Offset: offset,
+			Limit:  limit,
+		}
+	}
+
+	segments := make([]*Segment, 0, len(list.SegmentURLs))
+	for i, segmentURL := range list.SegmentURLs {
+		if segmentURL.MediaRange != "" {
+			return nil, nil, ErrUnsupportedSegments
+		}
+
+		segmentUrl, err := baseUrl.Parse(segmentURL.Media)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		segments = append(segments, &Segment{
+			Key: int64(i),
+			URL: segmentUrl,
+		})
+	}
+
+	return initSegment, segments, nil
+}
+
+// periodDuration returns the duration of the period, or 0 if it is unknown
+func (m *MPD) periodDuration(period *Period) time.Duration {
+	if duration, ok := parseDuration(period.Duration); ok {
+		return duration
+	}
+
+	start, _ := parseDuration(period.Start)
+	for i, p := range m.Periods {
+		if p != period {
+			continue
+		}
+
+		if i+1 < len(m.Periods) {
+			if nextStart, ok := parseDuration(m.Periods[i+1].Start); ok {
+				return nextStart - start
+			}
+		}
+	}
+
+	if duration, ok := parseDuration(m.MediaPresentationDuration); ok {
+		return duration - start
+	}
+
+	return 0
+}
+
+// expandTimeline returns the start time of each segment in the timeline.
+// end is used for the last run of segments, which may repeat until the end of the period.
+func expandTimeline(timeline *SegmentTimeline, end int64) []int64 {
+	times := make([]int64, 0, len(timeline.S))
+
+	t := int64(0)
+	for i, s := range timeline.S {
+		if s.T != nil {
+			t = *s.T
+		}
+
+		if s.D <= 0 {
+			continue
+		}
+
+		repeat := s.R
+		if repeat < 0 {
+			runEnd := end
+			if i+1 < len(timeline.S) && timeline.S[i+1].T != nil {
+				runEnd = *timeline.S[i+1].T
+			}
+
+			repeat = 0
+			if runEnd > t {
+				repeat = (runEnd-t+s.D-1)/s.D - 1
+			}
+		}
+
+		for j := int64(0); j <= repeat; j++ {
+			times = append(times, t)
+			t += s.D
+		}
+	}
+
+	return times
+}
+
+func fillTemplate(template string, rep *Representation, number int64, startTime int64) string {
+	return templateRegex.ReplaceAllStringFunc(template, func(match string) string {
+		groups := templateRegex.FindStringSubmatch(match)
+
+		var value int64
+		switch groups[1] {
+		case "":
+			return "$"
+		case "RepresentationID":
+			return rep.ID
+		case "Number":
+			value = number
+		case "Time":
+			value = startTime
+		case "Bandwidth":
+			value = rep.Bandwidth
+		}
+
+		if groups[2] != "" {
+			width, _ := strconv.Atoi(groups[2])
+			return fmt.Sprintf("%0*d", width, value)
+		}
+
+		return strconv.FormatInt(value, 10)
+	})
+}
+
+// parseDuration parses xs:duration values, which can have more precision than utils.ParseISODuration keeps
+func parseDuration(s string) (time.Duration, bool) {
+	matches := durationRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if matches == nil || s == "P" {
+		return 0, false
+	}
+
+	units := []time.Duration{365 * 24 * time.Hour, 30 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+
+	var duration time.Duration
+	for i, unit := range units {
+		if matches[i+1] == "" {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(matches[i+1], 64)
+		if err != nil {
+			return 0, false
+		}
+
+		duration += time.Duration(value * float64(unit))
+	}
+
+	return duration, true
+}
+
+// parseByteRange parses ranges of the form first-last into an offset and a length
+func parseByteRange(s string) (int64, int64, error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+
+	firstStr, lastStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid byte range %s", s)
+	}
+
+	first, err := strconv.ParseInt(firstStr, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	last, err := strconv.ParseInt(lastStr, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return first, last - first + 1, nil
+}
+
+func toTimescale(d time.Duration, timescale int64) int64 {
+	return int64(d.Seconds() * float64(timescale))
+}
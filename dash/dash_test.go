@@ -0,0 +1,92 @@
+package dash
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testStaticMPD = `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" type="static" mediaPresentationDuration="PT9.5S">
+  <Period id="0">
+    <AdaptationSet contentType="video" mimeType="video/mp4">
+      <SegmentTemplate timescale="1000" duration="4000" startNumber="1" initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/seg-$Number%05d$.m4s"/>
+      <Representation id="1080p" bandwidth="5000000" width="1920" height="1080"/>
+      <Representation id="720p" bandwidth="3000000" width="1280" height="720"/>
+    </AdaptationSet>
+    <AdaptationSet mimeType="audio/mp4" lang="en">
+      <Representation id="audio" bandwidth="128000">
+        <BaseURL>audio/</BaseURL>
+        <SegmentTemplate timescale="48000" initialization="init.mp4" media="$Time$.m4s">
+          <SegmentTimeline>
+            <S t="0" d="96000" r="2"/>
+            <S d="48000"/>
+          </SegmentTimeline>
+        </SegmentTemplate>
+      </Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>`
+
+func TestStaticSegments(t *testing.T) {
+	mpd, err := Parse(strings.NewReader(testStaticMPD))
+	require.NoError(t, err)
+	assert.False(t, mpd.IsDynamic())
+
+	manifestUrl, _ := url.Parse("https://example.com/live/manifest.mpd")
+
+	video, audio, err := mpd.Periods[0].SelectTracks(720)
+	require.NoError(t, err)
+	assert.Equal(t, "720p", video.Representation.ID)
+	assert.Equal(t, "audio", audio.Representation.ID)
+
+	initSegment, segments, err := mpd.Segments(manifestUrl, video, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/live/720p/init.mp4", initSegment.URL.String())
+	require.Len(t, segments, 3)
+	assert.Equal(t, "https://example.com/live/720p/seg-00001.m4s", segments[0].URL.String())
+	assert.Equal(t, "https://example.com/live/720p/seg-00003.m4s", segments[2].URL.String())
+
+	initSegment, segments, err = mpd.Segments(manifestUrl, audio, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/live/audio/init.mp4", initSegment.URL.String())
+	require.Len(t, segments, 4)
+	assert.Equal(t, int64(192000), segments[2].Key)
+	assert.Equal(t, "https://example.com/live/audio/288000.m4s", segments[3].URL.String())
+}
+
+func TestDynamicSegments(t *testing.T) {
+	mpd, err := Parse(strings.NewReader(`<MPD type="dynamic" availabilityStartTime="2024-01-01T00:00:00Z" timeShiftBufferDepth="PT10S">
+  <Period start="PT0S">
+    <AdaptationSet mimeType="video/mp4">
+      <SegmentTemplate timescale="1" duration="2" startNumber="0" media="$Number$.m4s"/>
+      <Representation id="v" bandwidth="1000"/>
+    </AdaptationSet>
+  </Period>
+</MPD>`))
+	require.NoError(t, err)
+
+	video, audio, err := mpd.Periods[0].SelectTracks(0)
+	require.NoError(t, err)
+	assert.Nil(t, audio)
+
+	manifestUrl, _ := url.Parse("https://example.com/manifest.mpd")
+	_, segments, err := mpd.Segments(manifestUrl, video, time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, segments, 5)
+	assert.Equal(t, int64(25), segments[0].Key)
+	assert.Equal(t, "https://example.com/29.m4s", segments[4].URL.String())
+}
+
+func TestParseDuration(t *testing.T) {
+	duration, ok := parseDuration("PT1H2M3.456S")
+	require.True(t, ok)
+	assert.Equal(t, time.Hour+2*time.Minute+3456*time.Millisecond, duration)
+
+	_, ok = parseDuration("1H")
+	assert.False(t, ok)
+}
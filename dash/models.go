@@ -0,0 +1,204 @@
+package dash
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+const (
+	TypeStatic  = "static"
+	TypeDynamic = "dynamic"
+
+	ContentTypeVideo = "video"
+	ContentTypeAudio = "audio"
+)
+
+// MPD is the subset of a DASH manifest that is needed to download its segments
+type MPD struct {
+	XMLName xml.Name `xml:"MPD"`
+
+	Type                      string `xml:"type,attr"`
+	AvailabilityStartTime     string `xml:"availabilityStartTime,attr"`
+	MediaPresentationDuration string `xml:"mediaPresentationDuration,attr"`
+	MinimumUpdatePeriod       string `xml:"minimumUpdatePeriod,attr"`
+	TimeShiftBufferDepth      string `xml:"timeShiftBufferDepth,attr"`
+
+	BaseURL string    `xml:"BaseURL"`
+	Periods []*Period `xml:"Period"`
+}
+
+type Period struct {
+	ID       string `xml:"id,attr"`
+	Start    string `xml:"start,attr"`
+	Duration string `xml:"duration,attr"`
+
+	BaseURL         string           `xml:"BaseURL"`
+	SegmentTemplate *SegmentTemplate `xml:"SegmentTemplate"`
+	AdaptationSets  []*AdaptationSet `xml:"AdaptationSet"`
+}
+
+type AdaptationSet struct {
+	ID          string `xml:"id,attr"`
+	ContentType string `xml:"contentType,attr"`
+	MimeType    string `xml:"mimeType,attr"`
+	Codecs      string `xml:"codecs,attr"`
+	Lang        string `xml:"lang,attr"`
+
+	BaseURL            string               `xml:"BaseURL"`
+	ContentProtections []*ContentProtection `xml:"ContentProtection"`
+	SegmentTemplate    *SegmentTemplate     `xml:"SegmentTemplate"`
+	SegmentList        *SegmentList         `xml:"SegmentList"`
+	Representations    []*Representation    `xml:"Representation"`
+}
+
+type Representation struct {
+	ID        string `xml:"id,attr"`
+	Bandwidth int64  `xml:"bandwidth,attr"`
+	Width     int    `xml:"width,attr"`
+	Height    int    `xml:"height,attr"`
+	FrameRate string `xml:"frameRate,attr"`
+	MimeType  string `xml:"mimeType,attr"`
+	Codecs    string `xml:"codecs,attr"`
+
+	BaseURL            string               `xml:"BaseURL"`
+	ContentProtections []*ContentProtection `xml:"ContentProtection"`
+	SegmentTemplate    *SegmentTemplate     `xml:"SegmentTemplate"`
+	SegmentList        *SegmentList         `xml:"SegmentList"`
+}
+
+type ContentProtection struct {
+	SchemeIDURI string `xml:"schemeIdUri,attr"`
+}
+
+type SegmentTemplate struct {
+	Media                  string           `xml:"media,attr"`
+	Initialization         string           `xml:"initialization,attr"`
+	StartNumber            *int64           `xml:"startNumber,attr"`
+	Timescale              *int64           `xml:"timescale,attr"`
+	Duration               int64            `xml:"duration,attr"`
+	PresentationTimeOffset int64            `xml:"presentationTimeOffset,attr"`
+	SegmentTimeline        *SegmentTimeline `xml:"SegmentTimeline"`
+}
+
+type SegmentTimeline struct {
+	S []*S `xml:"S"`
+}
+
+// S is a run of segments with the same duration in a segment timeline
+type S struct {
+	T *int64 `xml:"t,attr"`
+	D int64  `xml:"d,attr"`
+	R int64  `xml:"r,attr"`
+}
+
+type SegmentList struct {
+	Initialization *URLType      `xml:"Initialization"`
+	SegmentURLs    []*SegmentURL `xml:"SegmentURL"`
+}
+
+type URLType struct {
+	SourceURL string `xml:"sourceURL,attr"`
+	Range     string `xml:"range,attr"`
+}
+
+type SegmentURL struct {
+	Media      string `xml:"media,attr"`
+	MediaRange string `xml:"mediaRange,attr"`
+}
+
+func Parse(r io.Reader) (*MPD, error) {
+	mpd := &MPD{}
+	if err := xml.NewDecoder(r).Decode(mpd); err != nil {
+		return nil, err
+	}
+
+	return mpd, nil
+}
+
+func (m *MPD) IsDynamic() bool {
+	return m.Type == TypeDynamic
+}
+
+// Track is a representation together with the elements it inherits its attributes from
+type Track struct {
+	Period         *Period
+	AdaptationSet  *AdaptationSet
+	Representation *Representation
+}
+
+func (t *Track) ContentType() string {
+	if t.AdaptationSet.ContentType != "" {
+		return t.AdaptationSet.ContentType
+	}
+
+	mimeType := t.Representation.MimeType
+	if mimeType == "" {
+		mimeType = t.AdaptationSet.MimeType
+	}
+
+	contentType, _, _ := strings.Cut(mimeType, "/")
+	return contentType
+}
+
+// Extension returns the extension of the file that the segments of the track concatenate into
+func (t *Track) Extension() string {
+	mimeType := t.Representation.MimeType
+	if mimeType == "" {
+		mimeType = t.AdaptationSet.MimeType
+	}
+
+	if strings.HasSuffix(mimeType, "/webm") {
+		return ".webm"
+	}
+	return ".mp4"
+}
+
+func (t *Track) IsProtected() bool {
+	return len(t.AdaptationSet.ContentProtections) > 0 || len(t.Representation.ContentProtections) > 0
+}
+
+// segmentTemplate merges the segment templates of the track, where the more specific elements take precedence
+func (t *Track) segmentTemplate() *SegmentTemplate {
+	var merged *SegmentTemplate
+	for _, template := range []*SegmentTemplate{t.Period.SegmentTemplate, t.AdaptationSet.SegmentTemplate, t.Representation.SegmentTemplate} {
+		if template == nil {
+			continue
+		}
+
+		if merged == nil {
+			merged = &SegmentTemplate{}
+		}
+
+		if template.Media != "" {
+			merged.Media = template.Media
+		}
+		if template.Initialization != "" {
+			merged.Initialization = template.Initialization
+		}
+		if template.StartNumber != nil {
+			merged.StartNumber = template.StartNumber
+		}
+		if template.Timescale != nil {
+			merged.Timescale = template.Timescale
+		}
+		if template.Duration != 0 {
+			merged.Duration = template.Duration
+		}
+		if template.PresentationTimeOffset != 0 {
+			merged.PresentationTimeOffset = template.PresentationTimeOffset
+		}
+		if template.SegmentTimeline != nil {
+			merged.SegmentTimeline = template.SegmentTimeline
+		}
+	}
+
+	return merged
+}
+
+func (t *Track) segmentList() *SegmentList {
+	if t.Representation.SegmentList != nil {
+		return t.Representation.SegmentList
+	}
+	return t.AdaptationSet.SegmentList
+}
@@ -63,6 +63,10 @@ func (p *ProgressBar) SetMax(i int64) {
 	p.raw.ChangeMax64(i)
 }
 
+func (p *ProgressBar) Current() int64 {
+	return int64(p.raw.State().CurrentBytes)
+}
+
 func (p *ProgressBar) updateTask(ctx context.Context) {
 	ticker := time.NewTicker(500 * time.Millisecond)
 
@@ -2,6 +2,7 @@ package discord
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -20,12 +21,33 @@ type Session struct {
 	*discordgo.Session
 
 	Logger *zap.SugaredLogger
+
+	// Cancels long running work such as video compression, e.g. when the bot shuts down
+	ctx context.Context
 }
 
 func NewSession(s *discordgo.Session, l *zap.SugaredLogger) *Session {
 	return &Session{
 		Session: s,
 		Logger:  l,
+		ctx:     context.Background(),
+	}
+}
+
+// WithContext returns a copy of the session whose long running work is cancelled with ctx
+func (s *Session) WithContext(ctx context.Context) *Session {
+	return &Session{
+		Session: s.Session,
+		Logger:  s.Logger,
+		ctx:     ctx,
+	}
+}
+
+func (s *Session) WithLogger(l *zap.SugaredLogger) *Session {
+	return &Session{
+		Session: s.Session,
+		Logger:  l,
+		ctx:     s.ctx,
 	}
 }
 
@@ -180,6 +202,65 @@ func (s *Session) SendEmbeds(channelID string, embeds []*discordgo.MessageEmbed)
 	return NewUpdatableMessageEmbeds(s, m), nil
 }
 
+// EmbedImage is uploaded in place of the image of an embed, for images that Discord cannot fetch by itself
+type EmbedImage struct {
+	FileName    string
+	ContentType string
+	Bytes       []byte
+}
+
+func (s *Session) SendEmbedsWithImages(channelID string, embeds []*discordgo.MessageEmbed, images []*EmbedImage) (UpdatableMessageEmbeds, error) {
+	return s.sendEmbedsWithImages(channelID, embeds, images, s.GetGuildPremiumTier(channelID))
+}
+
+// sendEmbedsWithImages replaces the image of embeds[i] with images[i] if it is not nil.
+// Images that do not fit in the message keep their original URL.
+func (s *Session) sendEmbedsWithImages(channelID string, embeds []*discordgo.MessageEmbed, images []*EmbedImage, tier discordgo.PremiumTier) (UpdatableMessageEmbeds, error) {
+	if len(embeds) == 0 {
+		return UpdatableMessageEmbeds{}, nil
+	} else if len(embeds) > 10 {
+		s.Logger.Warn("More than 10 embeds in message, only first 10 will be sent...")
+		embeds = embeds[:10]
+	}
+
+	hasPermissions, err := s.HasSendMessagePermissions(channelID)
+	if err != nil || !hasPermissions {
+		return nil, ErrMissingPermissions
+	}
+
+	remainingBytes := GetMessageMaxBytes(tier)
+	files := make([]*discordgo.File, 0, len(images))
+	for i, image := range images {
+		if i >= len(embeds) || image == nil || embeds[i].Image == nil {
+			continue
+		}
+
+		if int64(len(image.Bytes)) > remainingBytes {
+			s.Logger.With(zap.String("fileName", image.FileName)).Warn("Image does not fit in message")
+			continue
+		}
+		remainingBytes -= int64(len(image.Bytes))
+
+		embeds[i].Image.URL = fmt.Sprintf("attachment://%s", image.FileName)
+		files = append(files, &discordgo.File{
+			Name:        image.FileName,
+			ContentType: image.ContentType,
+			Reader:      bytes.NewReader(image.Bytes),
+		})
+	}
+
+	m, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds: processEmbeds(embeds),
+		Files:  files,
+	})
+	if err != nil {
+		s.Logger.With(zap.Error(err)).Error("Failed to send complex message")
+		return nil, err
+	}
+
+	return NewUpdatableMessageEmbeds(s, m), nil
+}
+
 func (s *Session) SendVideo(channelID string, video io.ReadCloser, fileName string) {
 	s.sendVideo(channelID, video, fileName, s.GetGuildPremiumTier(channelID))
 }
@@ -196,9 +277,14 @@ func (s *Session) sendVideo(channelID string, video io.ReadCloser, fileName stri
 	}
 	video.Close()
 
-	if buf.Len() > int(GetMessageMaxBytes(tier)) {
-		s.SendMessage(channelID, "Video is too large to embed!")
-		return
+	var reader io.Reader = bytes.NewReader(buf.Bytes())
+	if maxBytes := GetMessageMaxBytes(tier); buf.Len() > int(maxBytes) {
+		reader, err = compressVideo(s.ctx, reader, maxBytes)
+		if err != nil {
+			s.Logger.With(zap.Error(err)).Warn("Failed to compress video")
+			s.SendMessage(channelID, "Video is too large to embed!")
+			return
+		}
 	}
 
 	if _, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
@@ -206,7 +292,7 @@ func (s *Session) sendVideo(channelID string, video io.ReadCloser, fileName stri
 			{
 				Name:        fmt.Sprintf("%s.mp4", fileName),
 				ContentType: "video/mp4",
-				Reader:      bytes.NewReader(buf.Bytes()),
+				Reader:      reader,
 			},
 		},
 	}); err != nil {
@@ -228,7 +314,15 @@ func (s *Session) sendVideoURL(channelID string, videoURL string, fileName strin
 		return
 	}
 
-	file, _, err := utils.Download(videoURL, GetMessageMaxBytes(tier))
+	maxBytes := GetMessageMaxBytes(tier)
+
+	file, _, err := utils.Download(videoURL, maxBytes)
+	if errors.Is(err, utils.ErrResponseTooLong) {
+		file, err = compressVideoURL(s.ctx, videoURL, maxBytes)
+		if err != nil {
+			s.Logger.With(zap.Error(err)).Warn("Failed to compress video")
+		}
+	}
 	if err != nil {
 		// Video is too big, just send the URL
 		s.SendMessage(channelID, videoURL)
@@ -268,30 +362,64 @@ func (s *Session) sendVideoURLs(channelID string, videoURLs []string, fileNamePr
 	files := make([]*discordgo.File, 0, len(videoURLs))
 
 	remainingBytes := maxBytes
+
+	// Videos that are sent in messages of their own are sent after the ones before them
+	flushFiles := func() {
+		if len(files) > 0 {
+			messages = append(messages, &discordgo.MessageSend{
+				Files: files,
+			})
+		}
+		files = make([]*discordgo.File, 0)
+		remainingBytes = maxBytes
+	}
+
 	for i, url := range videoURLs {
 		file, bytes, err := utils.Download(url, remainingBytes)
 		if errors.Is(err, utils.ErrResponseTooLong) && bytes < maxBytes {
 			// This video can't fit in the current message, but does not exceed the maximum bytes in a single message
 			// Flush the current files to a message and start a new message
 			// Then redownload the video
-			messages = append(messages, &discordgo.MessageSend{
-				Files: files,
-			})
-			files = make([]*discordgo.File, 0)
-			remainingBytes = maxBytes
+			flushFiles()
 
 			file, bytes, err = utils.Download(url, remainingBytes)
 		}
 
-		if (err != nil && !errors.Is(err, utils.ErrResponseTooLong)) || bytes > maxBytes {
-			// Either we can't fetch the link or this video exceeds the maximum bytes in a single message
-			// So we just send the video URL
+		if err != nil && !errors.Is(err, utils.ErrResponseTooLong) {
+			// We can't fetch the link, so we just send the video URL
+			flushFiles()
 			messages = append(messages, &discordgo.MessageSend{
 				Content: url,
 			})
 			continue
 		}
 
+		if bytes > maxBytes {
+			// This video exceeds the maximum bytes in a single message
+			// Compress it into a message of its own, or send the video URL if that fails
+			flushFiles()
+
+			compressed, err := compressVideoURL(s.ctx, url, maxBytes)
+			if err != nil {
+				s.Logger.With(zap.Error(err)).Warn("Failed to compress video")
+				messages = append(messages, &discordgo.MessageSend{
+					Content: url,
+				})
+				continue
+			}
+
+			messages = append(messages, &discordgo.MessageSend{
+				Files: []*discordgo.File{
+					{
+						Name:        fmt.Sprintf("%v_%v.mp4", fileNamePrefix, i),
+						ContentType: "video/mp4",
+						Reader:      compressed,
+					},
+				},
+			})
+			continue
+		}
+
 		remainingBytes -= bytes
 		files = append(files, &discordgo.File{
 			Name:        fmt.Sprintf("%v_%v.mp4", fileNamePrefix, i),
@@ -301,11 +429,7 @@ func (s *Session) sendVideoURLs(channelID string, videoURLs []string, fileNamePr
 	}
 
 	// Flush the last message if there are videos in it
-	if len(files) > 0 {
-		messages = append(messages, &discordgo.MessageSend{
-			Files: files,
-		})
-	}
+	flushFiles()
 
 	for _, message := range messages {
 		if _, err := s.ChannelMessageSendComplex(channelID, message); err != nil {
@@ -448,6 +572,10 @@ func (s *MessageSession) SendEmbeds(embeds []*discordgo.MessageEmbed) (Updatable
 	return s.Session.SendEmbeds(s.ChannelID, embeds)
 }
 
+func (s *MessageSession) SendEmbedsWithImages(embeds []*discordgo.MessageEmbed, images []*EmbedImage) (UpdatableMessageEmbeds, error) {
+	return s.Session.sendEmbedsWithImages(s.ChannelID, embeds, images, s.GetGuildPremiumTier())
+}
+
 func (s *MessageSession) SendBytesProgressBar(totalBytes int64, description ...string) (*ProgressBar, error) {
 	return s.Session.SendBytesProgressBar(s.ChannelID, totalBytes, description...)
 }
@@ -0,0 +1,71 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/xIceArcher/go-leah/utils"
+)
+
+const (
+	// Videos larger than this are sent as URLs instead of being downloaded to be compressed
+	maxCompressInputBytes = 500 * 1024 * 1024
+	compressTimeout       = 10 * time.Minute
+)
+
+// compressVideo re-encodes the video so that it fits in a message of maxBytes
+func compressVideo(ctx context.Context, video io.Reader, maxBytes int64) (io.Reader, error) {
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	inPath := filepath.Join(dir, "in")
+	if err := func() error {
+		in, err := os.Create(inPath)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		if _, err := io.Copy(in, video); err != nil {
+			return err
+		}
+		return in.Close()
+	}(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, compressTimeout)
+	defer cancel()
+
+	outPath := filepath.Join(dir, "out.mp4")
+	if err := utils.CompressVideo(ctx, inPath, outPath, maxBytes); err != nil {
+		return nil, err
+	}
+
+	out, err := os.ReadFile(outPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(out), nil
+}
+
+// compressVideoURL downloads the video and re-encodes it so that it fits in a message of maxBytes
+func compressVideoURL(ctx context.Context, videoURL string, maxBytes int64) (io.Reader, error) {
+	video, _, err := utils.Download(videoURL, maxCompressInputBytes)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := video.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	return compressVideo(ctx, video, maxBytes)
+}
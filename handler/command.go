@@ -6,9 +6,11 @@ import (
 	"strings"
 
 	"github.com/google/shlex"
+	"github.com/xIceArcher/go-leah/cache"
 	"github.com/xIceArcher/go-leah/cog"
 	"github.com/xIceArcher/go-leah/config"
 	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/toggle"
 	"go.uber.org/zap"
 	"golang.org/x/exp/slices"
 )
@@ -22,18 +24,30 @@ type CommandHandler struct {
 	Cogs []*CogWithConfig
 
 	activeCommands map[string]struct{}
+
+	toggles *toggle.Store
 }
 
 type CogWithConfig struct {
+	Name string
+
 	cog.Cog
 	*config.DiscordCogConfig
 }
 
 func NewCommandHandler(cfg *config.Config, s *discord.Session) (MessageHandler, error) {
 	implementedCogs := map[string]cog.Constructor{
-		"admin":    cog.NewAdminCog,
-		"twitter":  cog.NewTwitterCog,
-		"download": cog.NewDownloadCog,
+		"admin":      cog.NewAdminCog,
+		"twitter":    cog.NewTwitterCog,
+		"tweetstalk": cog.NewTweetStalkCog,
+		"download":   cog.NewDownloadCog,
+		"toggle":     cog.NewToggleCog,
+		"instastory": cog.NewInstagramStoryArchiveCog,
+	}
+
+	toggleCache, err := cache.New(cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	activeCommands := make(map[string]struct{})
@@ -71,6 +85,7 @@ func NewCommandHandler(cfg *config.Config, s *discord.Session) (MessageHandler,
 		}
 
 		cogsWithConfig = append(cogsWithConfig, &CogWithConfig{
+			Name:             cogName,
 			Cog:              c,
 			DiscordCogConfig: cogCfg,
 		})
@@ -82,6 +97,8 @@ func NewCommandHandler(cfg *config.Config, s *discord.Session) (MessageHandler,
 
 		Cogs:           cogsWithConfig,
 		activeCommands: activeCommands,
+
+		toggles: toggle.New(toggleCache),
 	}, nil
 }
 
@@ -127,6 +144,21 @@ func (h *CommandHandler) Handle(ctx context.Context, s *discord.MessageSession)
 			return true
 		}
 
+		// The toggle cog can never be disabled, otherwise there would be no way to enable it again
+		if cogWithConfig.Name != cog.ToggleCogName {
+			isEnabled, err := h.toggles.IsEnabled(ctx, s.GuildID, s.ChannelID, cogWithConfig.Name, !cogWithConfig.IsDisabledByDefault)
+			if err != nil {
+				s.Logger.With(zap.Error(err)).Warn("Failed to get toggle, using default")
+			}
+
+			if !isEnabled {
+				// Unlike matchers, commands are sent on purpose, so tell the user why nothing happened
+				s.Logger.Info("Disabled")
+				s.SendErrorf("`%s` is disabled in this channel", cogWithConfig.Name)
+				return true
+			}
+		}
+
 		cogWithConfig.Handle(ctx, s, msgCommand, msgArgs)
 		s.Logger.Info("Success")
 		return true
@@ -31,3 +31,9 @@ func (hs MessageHandlers) HandleAll(ctx context.Context, s *discord.MessageSessi
 		handler.Handle(ctx, s)
 	}
 }
+
+func (hs MessageHandlers) Stop() {
+	for _, handler := range hs {
+		handler.Stop()
+	}
+}
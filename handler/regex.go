@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"regexp"
 
+	"github.com/xIceArcher/go-leah/cache"
 	"github.com/xIceArcher/go-leah/config"
 	"github.com/xIceArcher/go-leah/discord"
 	"github.com/xIceArcher/go-leah/matcher"
+	"github.com/xIceArcher/go-leah/toggle"
 	"github.com/xIceArcher/go-leah/utils"
 	"go.uber.org/zap"
 )
@@ -17,6 +19,8 @@ type RegexHandler struct {
 	FilterRegexes []*regexp.Regexp
 
 	Matchers []*MatcherWithRegexes
+
+	toggles *toggle.Store
 }
 
 type MatcherWithRegexes struct {
@@ -24,6 +28,8 @@ type MatcherWithRegexes struct {
 
 	matcher.Matcher
 	Regexes []*regexp.Regexp
+
+	IsDisabledByDefault bool
 }
 
 func NewRegexHandler(cfg *config.Config, s *discord.Session) (MessageHandler, error) {
@@ -37,20 +43,33 @@ func NewRegexHandler(cfg *config.Config, s *discord.Session) (MessageHandler, er
 		filterRegexes = append(filterRegexes, regex)
 	}
 
+	c, err := cache.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	implementedMatchers := map[string]matcher.Constructor{
 		"youtubeLiveStream":  matcher.NewYoutubeLiveStreamMatcher,
 		"instagramPost":      matcher.NewInstagramPostMatcher,
 		"instagramStory":     matcher.NewInstagramStoryMatcher,
 		"instagramShareLink": matcher.NewInstagramShareLinkMatcher,
+		"instagramProfile":   matcher.NewInstagramProfileMatcher,
 		"twitchLiveStream":   matcher.NewTwitchLiveStreamMatcher,
 		"twitterPost":        matcher.NewTwitterPostMatcher,
+		"twitterSpace":       matcher.NewTwitterSpaceMatcher,
 		"tiktokVideo":        matcher.NewTiktokVideoMatcher,
 		"redbookPost":        matcher.NewRedbookPostMatcher,
+		"weiboPost":          matcher.NewWeiboPostMatcher,
+		"blueskyPost":        matcher.NewBlueskyPostMatcher,
+		"pixivArtwork":       matcher.NewPixivArtworkMatcher,
 	}
 
 	matchersWithRegexes := make([]*MatcherWithRegexes, 0, len(implementedMatchers))
 	for matcherName, matcherConfig := range cfg.Discord.Handlers {
 		matcherConstructor, ok := implementedMatchers[matcherName]
+		if !ok && matcherConfig.Ytdlp != nil {
+			matcherConstructor, ok = matcher.NewYtdlpMatcher(matcherConfig.Ytdlp), true
+		}
 		if !ok {
 			return nil, fmt.Errorf("matcher %s not found", matcherName)
 		}
@@ -82,12 +101,16 @@ func NewRegexHandler(cfg *config.Config, s *discord.Session) (MessageHandler, er
 			Name:    matcherName,
 			Matcher: m,
 			Regexes: regexes,
+
+			IsDisabledByDefault: matcherConfig.IsDisabledByDefault,
 		})
 	}
 
 	return &RegexHandler{
 		FilterRegexes: filterRegexes,
 		Matchers:      matchersWithRegexes,
+
+		toggles: toggle.New(c),
 	}, nil
 }
 
@@ -115,7 +138,6 @@ func (h *RegexHandler) Handle(ctx context.Context, s *discord.MessageSession) bo
 		}
 
 		if len(matches) > 0 {
-			matched = true
 			matches = utils.Unique(matches)
 
 			s.Logger = s.Logger.With(
@@ -123,6 +145,18 @@ func (h *RegexHandler) Handle(ctx context.Context, s *discord.MessageSession) bo
 				zap.Strings("matches", matches),
 			)
 
+			isEnabled, err := h.toggles.IsEnabled(ctx, s.GuildID, s.ChannelID, matcher.Name, !matcher.IsDisabledByDefault)
+			if err != nil {
+				s.Logger.With(zap.Error(err)).Warn("Failed to get toggle, using default")
+			}
+
+			if !isEnabled {
+				s.Logger.Info("Disabled")
+				continue
+			}
+
+			matched = true
+
 			matcher.Handle(ctx, s, matches)
 			s.Logger.Info("Success")
 		}
@@ -0,0 +1,376 @@
+package hls
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/progress"
+	"github.com/xIceArcher/go-leah/throttle"
+	"go.uber.org/zap"
+)
+
+const (
+	segmentQueueSize  = 10000
+	segmentMaxRetries = 5
+
+	DefaultNumWorkers = 2
+
+	// Number of recently downloaded segments whose average size is used as the estimate when HEAD requests are skipped
+	numSizeSamples = 5
+)
+
+type Segment struct {
+	FileName string
+	URL      *url.URL
+
+	// Limit bytes starting from Offset of the URL, or the whole URL if Limit is 0
+	Offset int64
+	Limit  int64
+
+	// Nil if the segment is not encrypted
+	Block cipher.Block
+	IV    []byte
+
+	// Nil if problems with the segment are not reported
+	Report *Report
+	RunNo  int
+	SeqNo  int
+
+	// Size added to the progress bar before the segment is downloaded, corrected once it is
+	expectedSize int64
+	headSize     int64
+}
+
+type DownloaderOptions struct {
+	// Defaults to DefaultNumWorkers if not positive
+	NumWorkers int
+
+	// Estimate the size of each segment from recently downloaded segments instead of sending a HEAD request
+	SkipHead bool
+
+	// Downloads wait on every limiter, nil limiters are ignored
+	Limiters []*throttle.Limiter
+}
+
+var (
+	globalLimiters   = make(map[int64]*throttle.Limiter)
+	globalLimitersMu sync.Mutex
+)
+
+// NewDownloaderOptions returns the configured download options.
+// Everything downloaded with any of the returned options shares the configured bandwidth limit.
+func NewDownloaderOptions(cfg *config.Config) (*DownloaderOptions, error) {
+	opts := &DownloaderOptions{}
+	if cfg.Download == nil {
+		return opts, nil
+	}
+
+	bytesPerSecond, err := throttle.ParseBytesPerSecond(cfg.Download.BandwidthLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.NumWorkers = cfg.Download.NumWorkers
+	opts.SkipHead = cfg.Download.SkipHead
+	if limiter := getGlobalLimiter(bytesPerSecond); limiter != nil {
+		opts.Limiters = append(opts.Limiters, limiter)
+	}
+
+	return opts, nil
+}
+
+// getGlobalLimiter returns the limiter shared by every caller with the same limit, so that a changed limit takes effect on reload
+func getGlobalLimiter(bytesPerSecond int64) *throttle.Limiter {
+	globalLimitersMu.Lock()
+	defer globalLimitersMu.Unlock()
+
+	limiter, ok := globalLimiters[bytesPerSecond]
+	if !ok {
+		limiter = throttle.NewLimiter(bytesPerSecond)
+		globalLimiters[bytesPerSecond] = limiter
+	}
+
+	return limiter
+}
+
+// Downloader downloads segments in the background. The sizes of the segments are added to the progress bar,
+// which may be nil, before they are downloaded.
+type Downloader struct {
+	toHeadChan chan *Segment
+	wg         sync.WaitGroup
+
+	client   *retryablehttp.Client
+	opts     *DownloaderOptions
+	bar      progress.Bar
+	logger   *zap.SugaredLogger
+	estimate *sizeEstimate
+}
+
+func NewDownloader(ctx context.Context, client *retryablehttp.Client, opts *DownloaderOptions, bar progress.Bar, logger *zap.SugaredLogger) *Downloader {
+	if opts == nil {
+		opts = &DownloaderOptions{}
+	}
+
+	d := &Downloader{
+		toHeadChan: make(chan *Segment, segmentQueueSize),
+
+		client:   client,
+		opts:     opts,
+		bar:      bar,
+		logger:   logger,
+		estimate: &sizeEstimate{},
+	}
+	toGetChan := make(chan *Segment, segmentQueueSize)
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.getSegmentSize(ctx, toGetChan)
+	}()
+
+	numWorkers := opts.NumWorkers
+	if numWorkers <= 0 {
+		numWorkers = DefaultNumWorkers
+	}
+
+	for i := 0; i < numWorkers; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.downloadSegment(ctx, toGetChan)
+		}()
+	}
+
+	return d
+}
+
+func (d *Downloader) Add(segment *Segment) {
+	d.toHeadChan <- segment
+}
+
+// Close waits for all added segments to be downloaded, or for the context of the downloader to be cancelled
+func (d *Downloader) Close() {
+	close(d.toHeadChan)
+	d.wg.Wait()
+}
+
+func (d *Downloader) getSegmentSize(ctx context.Context, out chan<- *Segment) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case segment, ok := <-d.toHeadChan:
+			if !ok {
+				close(out)
+				return
+			}
+
+			if segment.Limit > 0 {
+				// The size of byte range segments is already known
+				segment.expectedSize = segment.Limit
+				segment.headSize = segment.Limit
+			} else if d.opts.SkipHead {
+				segment.expectedSize = d.estimate.Get()
+			} else {
+				resp, err := d.client.Head(segment.URL.String())
+				if err != nil || resp.StatusCode != http.StatusOK {
+					d.logger.With(zap.Error(err)).Warn("Failed to HEAD segment URL")
+				} else if resp.ContentLength > 0 {
+					segment.expectedSize = resp.ContentLength
+					segment.headSize = resp.ContentLength
+				}
+			}
+
+			if d.bar != nil {
+				d.bar.AddMax(segment.expectedSize)
+			}
+
+			out <- segment
+		}
+	}
+}
+
+func (d *Downloader) downloadSegment(ctx context.Context, segmentChan <-chan *Segment) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case segment, ok := <-segmentChan:
+			if !ok {
+				return
+			}
+
+			segmentLogger := d.logger.With(zap.String("url", segment.URL.String()))
+
+			// Segments are only given their final name once complete, so that resumed jobs can tell which ones are missing
+			partFileName := segment.FileName + ".part"
+
+			var lastErr error
+			numRetries := 0
+			for numRetries < segmentMaxRetries {
+				if lastErr = func() error {
+					out, err := os.Create(partFileName)
+					if err != nil {
+						return err
+					}
+					defer out.Close()
+
+					resp, err := getRange(ctx, d.client, segment.URL, segment.Offset, segment.Limit)
+					if err != nil {
+						return err
+					}
+					defer resp.Body.Close()
+
+					bytes, err := io.ReadAll(throttle.NewReader(ctx, resp.Body, d.opts.Limiters...))
+					if err != nil {
+						return err
+					}
+
+					if segment.Report != nil {
+						if resp.ContentLength > 0 && resp.ContentLength != int64(len(bytes)) {
+							segment.Report.addSizeMismatch(segment.RunNo, segment.SeqNo, resp.ContentLength, int64(len(bytes)))
+						} else if segment.headSize > 0 && segment.headSize != int64(len(bytes)) {
+							segment.Report.addSizeMismatch(segment.RunNo, segment.SeqNo, segment.headSize, int64(len(bytes)))
+						}
+					}
+
+					d.estimate.Add(int64(len(bytes)))
+					if d.bar != nil {
+						// Correct the size added before the segment was downloaded
+						d.bar.AddMax(int64(len(bytes)) - segment.expectedSize)
+						segment.expectedSize = int64(len(bytes))
+						d.bar.Add(int64(len(bytes)))
+					}
+
+					if segment.Block != nil {
+						if len(bytes)%aes.BlockSize != 0 {
+							return fmt.Errorf("encrypted segment of size %v is not a multiple of the block size", len(bytes))
+						}
+
+						mode := cipher.NewCBCDecrypter(segment.Block, segment.IV)
+						mode.CryptBlocks(bytes, bytes)
+					}
+
+					if _, err = out.Write(bytes); err != nil {
+						return err
+					}
+
+					if err := out.Close(); err != nil {
+						return err
+					}
+
+					if err := os.Rename(partFileName, segment.FileName); err != nil {
+						return err
+					}
+
+					segmentLogger.Info("Downloaded")
+					return nil
+				}(); lastErr != nil {
+					segmentLogger.With(zap.Int("numRetries", numRetries)).Error(lastErr)
+					numRetries++
+					continue
+				}
+
+				break
+			}
+
+			if lastErr != nil && ctx.Err() == nil {
+				segmentLogger.Error("Too many retries, segment skipped")
+				if segment.Report != nil {
+					segment.Report.addFailedSegment(segment.RunNo, segment.SeqNo, lastErr)
+				}
+			}
+		}
+	}
+}
+
+// sizeEstimate is the average size of the most recently downloaded segments
+type sizeEstimate struct {
+	mu    sync.Mutex
+	sizes []int64
+}
+
+func (e *sizeEstimate) Add(size int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.sizes = append(e.sizes, size)
+	if len(e.sizes) > numSizeSamples {
+		e.sizes = e.sizes[len(e.sizes)-numSizeSamples:]
+	}
+}
+
+// Get returns 0 if no segments have been downloaded yet
+func (e *sizeEstimate) Get() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.sizes) == 0 {
+		return 0
+	}
+
+	var total int64
+	for _, size := range e.sizes {
+		total += size
+	}
+
+	return total / int64(len(e.sizes))
+}
+
+// DownloadRange downloads limit bytes starting from offset of the URL into fileName, or the whole file if limit is 0
+func DownloadRange(ctx context.Context, client *retryablehttp.Client, u *url.URL, offset int64, limit int64, fileName string) error {
+	resp, err := getRange(ctx, client, u, offset, limit)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	out, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+// getRange requests limit bytes starting from offset of the URL, or the whole URL if limit is 0.
+// A server that ignores the range would return the whole file, so only partial content is accepted for ranges.
+func getRange(ctx context.Context, client *retryablehttp.Client, u *url.URL, offset int64, limit int64) (*http.Response, error) {
+	req, err := retryablehttp.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	expectedStatus := http.StatusOK
+	if limit > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%v-%v", offset, offset+limit-1))
+		expectedStatus = http.StatusPartialContent
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != expectedStatus {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP request to %s returned status %v", resp.Request.URL.String(), resp.StatusCode)
+	}
+
+	return resp, nil
+}
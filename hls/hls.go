@@ -0,0 +1,331 @@
+package hls
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/grafov/m3u8"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/xIceArcher/go-leah/progress"
+	"go.uber.org/zap"
+)
+
+const (
+	keyMethodNone   = "NONE"
+	keyMethodAES128 = "AES-128"
+
+	maxPlaylistErrors = 10
+	progressInterval  = 30 * time.Second
+)
+
+var ErrUnsupportedEncryption error = fmt.Errorf("Unsupported encryption method")
+
+func GetPlaylist(client *retryablehttp.Client, m3u8Url *url.URL) (m3u8.Playlist, error) {
+	resp, err := client.Get(m3u8Url.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP request to %s returned status %v", resp.Request.URL.String(), resp.StatusCode)
+	}
+
+	playlist, _, err := m3u8.DecodeFrom(resp.Body, true)
+	return playlist, err
+}
+
+// Record downloads segments of the recording's media playlist until the stream ends or ctx is cancelled.
+// onProgress is called every 30 seconds so that the recording can be persisted while it runs.
+func Record(ctx context.Context, client *retryablehttp.Client, rec *Recording, opts *DownloaderOptions, bar progress.Bar, logger *zap.SugaredLogger, onProgress func()) error {
+	m3u8Url, err := url.Parse(rec.M3U8URL)
+	if err != nil {
+		return err
+	}
+
+	downloader := NewDownloader(ctx, client, opts, bar, logger)
+	defer downloader.Close()
+
+	if len(rec.Runs) == 0 {
+		rec.Runs = append(rec.Runs, make(map[int]string))
+	}
+	currRunNo := len(rec.Runs) - 1
+
+	if rec.Keys == nil {
+		rec.Keys = make(map[string][]byte)
+	}
+	if rec.InitSegments == nil {
+		rec.InitSegments = make(map[int]*InitSegment)
+	}
+	if rec.Report == nil {
+		rec.Report = &Report{}
+	}
+
+	startNewRun := func() {
+		currRunNo++
+		rec.Runs = append(rec.Runs, make(map[int]string))
+	}
+
+	doneCh := make(chan int, 1)
+
+	var sleepTime time.Duration
+	errCount := 0
+	lastProgressTime := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Context cancelled, download aborted")
+			return ctx.Err()
+		case <-doneCh:
+			logger.Info("Stream closed")
+			rec.IsEnded = true
+			return nil
+		case <-time.After(sleepTime):
+			if err := func() error {
+				playlist, err := GetPlaylist(client, m3u8Url)
+				if err != nil {
+					return err
+				}
+
+				mediaList, ok := playlist.(*m3u8.MediaPlaylist)
+				if !ok {
+					return fmt.Errorf("not a media playlist")
+				}
+				sleepTime = time.Duration(mediaList.TargetDuration) * time.Second
+
+				// Keys and maps are only set on the first segment they apply to
+				var currKey *m3u8.Key
+				var currMap *m3u8.Map
+
+				// Byte ranges without an offset continue from the end of the previous segment's range of the same URL
+				var prevRangeUrl string
+				var prevRangeEnd int64
+
+				for i, segment := range mediaList.Segments {
+					if segment == nil {
+						continue
+					}
+
+					if segment.Key != nil {
+						currKey = segment.Key
+					}
+					if segment.Map != nil {
+						currMap = segment.Map
+					}
+
+					segmentUrl, err := m3u8Url.Parse(segment.URI)
+					if err != nil {
+						return err
+					}
+
+					var offset, limit int64
+					if segment.Limit > 0 {
+						offset, limit = segment.Offset, segment.Limit
+						if offset == 0 && prevRangeUrl == segmentUrl.String() {
+							offset = prevRangeEnd
+						}
+						prevRangeUrl, prevRangeEnd = segmentUrl.String(), offset+limit
+					} else {
+						prevRangeUrl = ""
+					}
+
+					fileName := filepath.Join(rec.Directory, segmentFileName(segmentUrl, offset, limit))
+
+					seqNo := i + int(mediaList.SeqNo)
+					currRunSegments := rec.Runs[currRunNo]
+					if existingFileName, ok := currRunSegments[seqNo]; ok {
+						if fileName == existingFileName {
+							continue
+						}
+
+						startNewRun()
+					}
+
+					var initUrl *url.URL
+					if currMap != nil {
+						initUrl, err = m3u8Url.Parse(currMap.URI)
+						if err != nil {
+							return err
+						}
+					}
+
+					if rec.isInitSegmentChanged(currRunNo, initUrl, currMap) {
+						// Segments cannot be concatenated with segments from a different initialization segment
+						if len(rec.Runs[currRunNo]) > 0 {
+							startNewRun()
+						}
+
+						delete(rec.InitSegments, currRunNo)
+						if currMap != nil {
+							initSegment, err := downloadInitSegment(ctx, client, initUrl, currMap, rec.Directory, currRunNo)
+							if err != nil {
+								return err
+							}
+							rec.InitSegments[currRunNo] = initSegment
+						}
+					}
+
+					block, err := getSegmentBlock(ctx, client, m3u8Url, currKey, rec.Keys)
+					if err != nil {
+						return err
+					}
+
+					var iv []byte
+					if block != nil {
+						iv, err = segmentIV(currKey, uint64(seqNo))
+						if err != nil {
+							return err
+						}
+					}
+
+					if segment.Discontinuity {
+						rec.Report.addDiscontinuity(currRunNo, seqNo)
+					}
+					rec.Report.addSegment(segment.Duration)
+
+					downloader.Add(&Segment{
+						FileName: fileName,
+						URL:      segmentUrl,
+						Offset:   offset,
+						Limit:    limit,
+						Block:    block,
+						IV:       iv,
+
+						Report: rec.Report,
+						RunNo:  currRunNo,
+						SeqNo:  seqNo,
+					})
+
+					rec.Runs[currRunNo][seqNo] = fileName
+				}
+
+				if mediaList.Closed {
+					doneCh <- 1
+				}
+
+				return nil
+			}(); err != nil {
+				logger.With(zap.Int("errCount", errCount)).Error(err)
+				errCount++
+
+				if errCount >= maxPlaylistErrors {
+					logger.Error("Too many errors when getting M3U8, aborting...")
+					doneCh <- 1
+				}
+
+				break
+			}
+
+			errCount = 0
+
+			if time.Since(lastProgressTime) >= progressInterval {
+				onProgress()
+				lastProgressTime = time.Now()
+			}
+		}
+	}
+}
+
+// segmentFileName names segments after their URL. Byte range segments, which usually all share one URL, are also named after their range.
+func segmentFileName(segmentUrl *url.URL, offset int64, limit int64) string {
+	fileName := path.Base(segmentUrl.Path)
+	if limit <= 0 {
+		return fileName
+	}
+
+	extension := path.Ext(fileName)
+	return fmt.Sprintf("%s_%v-%v%s", strings.TrimSuffix(fileName, extension), offset, offset+limit-1, extension)
+}
+
+// getSegmentBlock returns the cipher to decrypt segments encrypted with the key, or nil if they are not encrypted.
+// Keys are downloaded the first time their URI is seen.
+func getSegmentBlock(ctx context.Context, client *retryablehttp.Client, m3u8Url *url.URL, key *m3u8.Key, keys map[string][]byte) (cipher.Block, error) {
+	if key == nil || key.Method == "" || key.Method == keyMethodNone {
+		return nil, nil
+	}
+
+	if key.Method != keyMethodAES128 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncryption, key.Method)
+	}
+
+	keyUrl, err := m3u8Url.Parse(key.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	keyBytes, ok := keys[keyUrl.String()]
+	if !ok {
+		keyBytes, err = downloadKey(ctx, client, keyUrl)
+		if err != nil {
+			return nil, err
+		}
+		keys[keyUrl.String()] = keyBytes
+	}
+
+	return aes.NewCipher(keyBytes)
+}
+
+// segmentIV returns the IV of a segment encrypted with the key.
+// Keys without an IV use the media sequence number of the segment as a 128-bit big-endian integer.
+func segmentIV(key *m3u8.Key, seqNo uint64) ([]byte, error) {
+	iv := make([]byte, aes.BlockSize)
+
+	if key.IV == "" {
+		binary.BigEndian.PutUint64(iv[aes.BlockSize-8:], seqNo)
+		return iv, nil
+	}
+
+	ivHex := strings.TrimPrefix(strings.TrimPrefix(key.IV, "0x"), "0X")
+	ivParsed, ok := new(big.Int).SetString(ivHex, 16)
+	if !ok || ivParsed.BitLen() > aes.BlockSize*8 {
+		return nil, fmt.Errorf("invalid IV %s", key.IV)
+	}
+
+	return ivParsed.FillBytes(iv), nil
+}
+
+func downloadKey(ctx context.Context, client *retryablehttp.Client, keyUrl *url.URL) ([]byte, error) {
+	resp, err := client.Get(keyUrl.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP request to %s returned status %v", resp.Request.URL.String(), resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// downloadInitSegment downloads the EXT-X-MAP initialization segment of a run into dir
+func downloadInitSegment(ctx context.Context, client *retryablehttp.Client, initUrl *url.URL, initMap *m3u8.Map, dir string, runNo int) (*InitSegment, error) {
+	extension := path.Ext(initUrl.Path)
+	if extension == "" {
+		extension = ".mp4"
+	}
+
+	fileName := filepath.Join(dir, fmt.Sprintf("init_%v%s", runNo, extension))
+	if err := DownloadRange(ctx, client, initUrl, initMap.Offset, initMap.Limit, fileName); err != nil {
+		return nil, err
+	}
+
+	return &InitSegment{
+		URL:      initUrl.String(),
+		Limit:    initMap.Limit,
+		Offset:   initMap.Offset,
+		FileName: fileName,
+	}, nil
+}
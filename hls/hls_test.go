@@ -0,0 +1,175 @@
+package hls
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafov/m3u8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xIceArcher/go-leah/config"
+	httpclient "github.com/xIceArcher/go-leah/http"
+	"go.uber.org/zap"
+)
+
+func TestSegmentIV(t *testing.T) {
+	iv, err := segmentIV(&m3u8.Key{Method: keyMethodAES128}, 0x0102030405060708)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3, 4, 5, 6, 7, 8}, iv)
+
+	iv, err = segmentIV(&m3u8.Key{Method: keyMethodAES128, IV: "0x000102030405060708090A0B0C0D0E0F"}, 100)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, iv)
+
+	_, err = segmentIV(&m3u8.Key{Method: keyMethodAES128, IV: "0xZZ"}, 100)
+	assert.Error(t, err)
+}
+
+func TestSortedRunsPrependsInitSegments(t *testing.T) {
+	dir := t.TempDir()
+	for _, fileName := range []string{"a.m4s", "b.m4s", "c.ts"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fileName), []byte{0}, 0644))
+	}
+
+	rec := &Recording{
+		Runs: []map[int]string{
+			{2: filepath.Join(dir, "b.m4s"), 1: filepath.Join(dir, "a.m4s")},
+			{1: filepath.Join(dir, "c.ts")},
+		},
+		InitSegments: map[int]*InitSegment{
+			0: {FileName: "init_0.mp4"},
+		},
+	}
+
+	assert.Equal(t, [][]string{
+		{"init_0.mp4", filepath.Join(dir, "a.m4s"), filepath.Join(dir, "b.m4s")},
+		{filepath.Join(dir, "c.ts")},
+	}, rec.SortedRuns())
+}
+
+func TestRecordSkipsFailedSegments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.m3u8":
+			w.Write([]byte("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:2\n#EXT-X-MEDIA-SEQUENCE:0\n" +
+				"#EXTINF:2.0,\na.ts\n#EXTINF:2.0,\nb.ts\n#EXTINF:2.0,\nc.ts\n#EXT-X-ENDLIST\n"))
+		case "/b.ts":
+			// Fails on every retry
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Write([]byte(r.URL.Path))
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	rec := &Recording{M3U8URL: server.URL + "/index.m3u8", Directory: dir}
+	err := Record(context.Background(), httpclient.NewClientWithHeaders(nil), rec, nil, nil, zap.NewNop().Sugar(), func() {})
+	require.NoError(t, err)
+	assert.True(t, rec.IsEnded)
+
+	// The failed segment is still part of the run, but is not returned to be uploaded
+	assert.Len(t, rec.Runs[0], 3)
+	assert.Equal(t, [][]string{{filepath.Join(dir, "a.ts"), filepath.Join(dir, "c.ts")}}, rec.SortedRuns())
+
+	summary := rec.Summarize()
+	assert.Equal(t, []*Gap{{RunNo: 0, FirstSeqNo: 1, LastSeqNo: 1}}, summary.Gaps)
+	require.Len(t, summary.FailedSegments, 1)
+	assert.Equal(t, 1, summary.FailedSegments[0].SeqNo)
+}
+
+func TestSizeEstimate(t *testing.T) {
+	e := &sizeEstimate{}
+	assert.Equal(t, int64(0), e.Get())
+
+	e.Add(100)
+	e.Add(200)
+	assert.Equal(t, int64(150), e.Get())
+
+	// Only the most recent segments are averaged
+	for i := 0; i < numSizeSamples; i++ {
+		e.Add(1000)
+	}
+	assert.Equal(t, int64(1000), e.Get())
+}
+
+func TestNewDownloaderOptionsSharesLimiter(t *testing.T) {
+	cfg := &config.Config{Download: &config.DownloadConfig{BandwidthLimit: "10MB"}}
+
+	opts1, err := NewDownloaderOptions(cfg)
+	require.NoError(t, err)
+	opts2, err := NewDownloaderOptions(cfg)
+	require.NoError(t, err)
+
+	require.Len(t, opts1.Limiters, 1)
+	require.Len(t, opts2.Limiters, 1)
+	assert.Same(t, opts1.Limiters[0], opts2.Limiters[0])
+
+	opts3, err := NewDownloaderOptions(&config.Config{Download: &config.DownloadConfig{BandwidthLimit: "20MB"}})
+	require.NoError(t, err)
+	require.Len(t, opts3.Limiters, 1)
+	assert.NotSame(t, opts1.Limiters[0], opts3.Limiters[0])
+
+	opts4, err := NewDownloaderOptions(&config.Config{Download: &config.DownloadConfig{}})
+	require.NoError(t, err)
+	assert.Empty(t, opts4.Limiters)
+}
+
+func TestRecordByteRanges(t *testing.T) {
+	resource := []byte("initAAAABBBBCCCC")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.m3u8":
+			w.Write([]byte("#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:2\n#EXT-X-MEDIA-SEQUENCE:0\n" +
+				"#EXT-X-MAP:URI=\"main.mp4\",BYTERANGE=\"4@0\"\n" +
+				"#EXTINF:2.0,\n#EXT-X-BYTERANGE:4@4\nmain.mp4\n" +
+				"#EXTINF:2.0,\n#EXT-X-BYTERANGE:4\nmain.mp4\n" +
+				"#EXTINF:2.0,\n#EXT-X-BYTERANGE:4\nmain.mp4\n#EXT-X-ENDLIST\n"))
+		case "/main.mp4":
+			http.ServeContent(w, r, "main.mp4", time.Time{}, bytes.NewReader(resource))
+		}
+	}))
+	defer server.Close()
+
+	rec := &Recording{M3U8URL: server.URL + "/index.m3u8", Directory: t.TempDir()}
+	err := Record(context.Background(), httpclient.NewClientWithHeaders(nil), rec, nil, nil, zap.NewNop().Sugar(), func() {})
+	require.NoError(t, err)
+
+	runs := rec.SortedRuns()
+	require.Len(t, runs, 1)
+
+	contents := make([]string, 0, len(runs[0]))
+	for _, filePath := range runs[0] {
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		contents = append(contents, string(content))
+	}
+	assert.Equal(t, []string{"init", "AAAA", "BBBB", "CCCC"}, contents)
+}
+
+func TestDownloadRangeRequiresPartialContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Ignores the range and returns the whole file
+		w.Write([]byte("initAAAA"))
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL + "/main.mp4")
+	require.NoError(t, err)
+
+	fileName := filepath.Join(t.TempDir(), "init.mp4")
+	assert.Error(t, DownloadRange(context.Background(), httpclient.NewClientWithHeaders(nil), u, 0, 4, fileName))
+
+	// The whole file is expected without a range
+	require.NoError(t, DownloadRange(context.Background(), httpclient.NewClientWithHeaders(nil), u, 0, 0, fileName))
+	content, err := os.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, "initAAAA", string(content))
+}
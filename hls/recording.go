@@ -0,0 +1,105 @@
+package hls
+
+import (
+	"errors"
+	"net/url"
+	"os"
+
+	"github.com/grafov/m3u8"
+	"golang.org/x/exp/slices"
+)
+
+// Recording is the state of a media playlist download, which can be persisted to resume the download later
+type Recording struct {
+	M3U8URL string `json:"m3u8URL"`
+
+	// Decryption keys by key URI, since keys can be rotated throughout the stream
+	Keys map[string][]byte `json:"keys"`
+
+	Directory string `json:"directory"`
+
+	// Each run maps sequence numbers to segment file paths.
+	// A new run is started whenever the playlist reuses a sequence number for a different segment.
+	Runs    []map[int]string `json:"runs"`
+	IsEnded bool             `json:"isEnded"`
+
+	// Initialization segment of each fMP4 run by run number.
+	// A new run is also started whenever the initialization segment changes.
+	InitSegments map[int]*InitSegment `json:"initSegments"`
+
+	// Nil for recordings saved before reports were kept
+	Report *Report `json:"report"`
+}
+
+// InitSegment is the EXT-X-MAP initialization segment that is prepended to a run
+type InitSegment struct {
+	URL      string `json:"url"`
+	Limit    int64  `json:"limit"`
+	Offset   int64  `json:"offset"`
+	FileName string `json:"fileName"`
+}
+
+type downloadedFile struct {
+	Name  string
+	SeqNo int
+}
+
+// SortedRuns returns the file paths of each run ordered by sequence number.
+// Segments that never finished downloading are left out, and so are runs without any downloaded segments.
+func (r *Recording) SortedRuns() [][]string {
+	sortedRuns := make([][]string, 0, len(r.Runs))
+	for runNo, runSegments := range r.Runs {
+		files := make([]*downloadedFile, 0, len(runSegments))
+		for seqNo, filePath := range runSegments {
+			if _, err := os.Stat(filePath); errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			files = append(files, &downloadedFile{
+				Name:  filePath,
+				SeqNo: seqNo,
+			})
+		}
+
+		slices.SortFunc(files, func(i, j *downloadedFile) bool {
+			return i.SeqNo < j.SeqNo
+		})
+
+		if len(files) == 0 {
+			continue
+		}
+
+		run := make([]string, 0, len(files)+1)
+		if initSegment, ok := r.InitSegments[runNo]; ok {
+			run = append(run, initSegment.FileName)
+		}
+		for _, file := range files {
+			run = append(run, file.Name)
+		}
+		sortedRuns = append(sortedRuns, run)
+	}
+
+	return sortedRuns
+}
+
+// RemoveMissingSegments forgets segments that were queued but never finished downloading,
+// so that they are downloaded again if they are still in the playlist
+func (r *Recording) RemoveMissingSegments() {
+	for _, runSegments := range r.Runs {
+		for seqNo, filePath := range runSegments {
+			if _, err := os.Stat(filePath); errors.Is(err, os.ErrNotExist) {
+				delete(runSegments, seqNo)
+			}
+		}
+	}
+}
+
+// isInitSegmentChanged returns whether the run has a different initialization segment from initMap, which is nil for runs without one
+func (r *Recording) isInitSegmentChanged(runNo int, initUrl *url.URL, initMap *m3u8.Map) bool {
+	initSegment, ok := r.InitSegments[runNo]
+	if initMap == nil {
+		return ok
+	}
+
+	return !ok || initSegment.URL != initUrl.String() || initSegment.Limit != initMap.Limit || initSegment.Offset != initMap.Offset
+}
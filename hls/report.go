@@ -0,0 +1,287 @@
+package hls
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xIceArcher/go-leah/utils"
+	"golang.org/x/exp/slices"
+)
+
+// Report tracks problems found while recording that are not visible in the runs themselves. It is safe for concurrent use.
+type Report struct {
+	mu sync.Mutex
+
+	FailedSegments  []*SegmentProblem
+	Discontinuities []*SegmentProblem
+	SizeMismatches  []*SegmentProblem
+
+	// Used to estimate the duration of missing segments
+	NumSegments   int
+	TotalDuration float64
+}
+
+// SegmentProblem identifies a segment by its run and sequence number
+type SegmentProblem struct {
+	RunNo  int    `json:"runNo"`
+	SeqNo  int    `json:"seqNo"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type reportJSON struct {
+	FailedSegments  []*SegmentProblem `json:"failedSegments"`
+	Discontinuities []*SegmentProblem `json:"discontinuities"`
+	SizeMismatches  []*SegmentProblem `json:"sizeMismatches"`
+	NumSegments     int               `json:"numSegments"`
+	TotalDuration   float64           `json:"totalDuration"`
+}
+
+func (r *Report) MarshalJSON() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return json.Marshal(&reportJSON{
+		FailedSegments:  r.FailedSegments,
+		Discontinuities: r.Discontinuities,
+		SizeMismatches:  r.SizeMismatches,
+		NumSegments:     r.NumSegments,
+		TotalDuration:   r.TotalDuration,
+	})
+}
+
+func (r *Report) UnmarshalJSON(data []byte) error {
+	val := &reportJSON{}
+	if err := json.Unmarshal(data, val); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.FailedSegments = val.FailedSegments
+	r.Discontinuities = val.Discontinuities
+	r.SizeMismatches = val.SizeMismatches
+	r.NumSegments = val.NumSegments
+	r.TotalDuration = val.TotalDuration
+	return nil
+}
+
+func (r *Report) addSegment(duration float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.NumSegments++
+	r.TotalDuration += duration
+}
+
+func (r *Report) addFailedSegment(runNo int, seqNo int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.FailedSegments = append(r.FailedSegments, &SegmentProblem{RunNo: runNo, SeqNo: seqNo, Detail: err.Error()})
+}
+
+func (r *Report) addDiscontinuity(runNo int, seqNo int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Discontinuities = append(r.Discontinuities, &SegmentProblem{RunNo: runNo, SeqNo: seqNo})
+}
+
+func (r *Report) addSizeMismatch(runNo int, seqNo int, expected int64, actual int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.SizeMismatches = append(r.SizeMismatches, &SegmentProblem{
+		RunNo:  runNo,
+		SeqNo:  seqNo,
+		Detail: fmt.Sprintf("expected %v bytes, got %v", expected, actual),
+	})
+}
+
+// Gap is a range of sequence numbers missing from a run
+type Gap struct {
+	RunNo      int
+	FirstSeqNo int
+	LastSeqNo  int
+}
+
+func (g *Gap) Len() int {
+	return g.LastSeqNo - g.FirstSeqNo + 1
+}
+
+// Summary is the final report of a recording
+type Summary struct {
+	Gaps            []*Gap
+	MissingDuration time.Duration
+
+	// Failed segments that are still missing, since resumed recordings download them again if they can
+	FailedSegments  []*SegmentProblem
+	Discontinuities []*SegmentProblem
+	SizeMismatches  []*SegmentProblem
+}
+
+// Summarize finds the segments missing between the first and last downloaded segment of each run
+// and estimates their duration from the average duration of every segment in the playlist
+func (r *Recording) Summarize() *Summary {
+	summary := &Summary{}
+	missing := make(map[int]map[int]bool)
+
+	numMissing := 0
+	addGap := func(gap *Gap) {
+		summary.Gaps = append(summary.Gaps, gap)
+		numMissing += gap.Len()
+
+		for seqNo := gap.FirstSeqNo; seqNo <= gap.LastSeqNo; seqNo++ {
+			missing[gap.RunNo][seqNo] = true
+		}
+	}
+
+	for runNo, runSegments := range r.Runs {
+		missing[runNo] = make(map[int]bool)
+
+		seqNos := make([]int, 0, len(runSegments))
+		notDownloadedSeqNos := make([]int, 0)
+		for seqNo, filePath := range runSegments {
+			if _, err := os.Stat(filePath); errors.Is(err, os.ErrNotExist) {
+				notDownloadedSeqNos = append(notDownloadedSeqNos, seqNo)
+				continue
+			}
+			seqNos = append(seqNos, seqNo)
+		}
+		slices.Sort(seqNos)
+		slices.Sort(notDownloadedSeqNos)
+
+		for i := 1; i < len(seqNos); i++ {
+			if seqNos[i] != seqNos[i-1]+1 {
+				addGap(&Gap{RunNo: runNo, FirstSeqNo: seqNos[i-1] + 1, LastSeqNo: seqNos[i] - 1})
+			}
+		}
+
+		// Segments after the last downloaded one that never finished downloading are also missing
+		var trailingGap *Gap
+		for _, seqNo := range notDownloadedSeqNos {
+			if len(seqNos) > 0 && seqNo < seqNos[len(seqNos)-1] {
+				continue
+			}
+
+			if trailingGap != nil && seqNo == trailingGap.LastSeqNo+1 {
+				trailingGap.LastSeqNo = seqNo
+				continue
+			}
+
+			if trailingGap != nil {
+				addGap(trailingGap)
+			}
+			trailingGap = &Gap{RunNo: runNo, FirstSeqNo: seqNo, LastSeqNo: seqNo}
+		}
+		if trailingGap != nil {
+			addGap(trailingGap)
+		}
+	}
+
+	slices.SortFunc(summary.Gaps, func(i, j *Gap) bool {
+		if i.RunNo != j.RunNo {
+			return i.RunNo < j.RunNo
+		}
+		return i.FirstSeqNo < j.FirstSeqNo
+	})
+
+	if r.Report == nil {
+		return summary
+	}
+
+	r.Report.mu.Lock()
+	defer r.Report.mu.Unlock()
+
+	if r.Report.NumSegments > 0 {
+		avgDuration := r.Report.TotalDuration / float64(r.Report.NumSegments)
+		summary.MissingDuration = time.Duration(avgDuration * float64(numMissing) * float64(time.Second))
+	}
+
+	isFailedReported := make(map[int]map[int]bool)
+	for _, failed := range r.Report.FailedSegments {
+		if !missing[failed.RunNo][failed.SeqNo] || isFailedReported[failed.RunNo][failed.SeqNo] {
+			continue
+		}
+
+		if _, ok := isFailedReported[failed.RunNo]; !ok {
+			isFailedReported[failed.RunNo] = make(map[int]bool)
+		}
+		isFailedReported[failed.RunNo][failed.SeqNo] = true
+		summary.FailedSegments = append(summary.FailedSegments, failed)
+	}
+
+	summary.Discontinuities = slices.Clone(r.Report.Discontinuities)
+	summary.SizeMismatches = slices.Clone(r.Report.SizeMismatches)
+	return summary
+}
+
+func (s *Summary) IsClean() bool {
+	return len(s.Gaps) == 0 && len(s.Discontinuities) == 0 && len(s.SizeMismatches) == 0
+}
+
+// Brief only counts the problems of each kind
+func (s *Summary) Brief() string {
+	if s.IsClean() {
+		return s.String()
+	}
+
+	numMissing := 0
+	for _, gap := range s.Gaps {
+		numMissing += gap.Len()
+	}
+
+	return fmt.Sprintf("Missing %v segments (~%s) in %v gaps, %v failed to download, %v discontinuities, %v size mismatches",
+		numMissing, utils.FormatDurationSimple(s.MissingDuration), len(s.Gaps), len(s.FailedSegments), len(s.Discontinuities), len(s.SizeMismatches))
+}
+
+func (s *Summary) String() string {
+	if s.IsClean() {
+		return "No missing segments, discontinuities or size mismatches"
+	}
+
+	var sb strings.Builder
+
+	numMissing := 0
+	for _, gap := range s.Gaps {
+		numMissing += gap.Len()
+	}
+
+	if numMissing > 0 {
+		sb.WriteString(fmt.Sprintf("Missing %v segments (~%s) in %v gaps\n", numMissing, utils.FormatDurationSimple(s.MissingDuration), len(s.Gaps)))
+		for _, gap := range s.Gaps {
+			if gap.Len() == 1 {
+				sb.WriteString(fmt.Sprintf("- Run %v: segment %v\n", gap.RunNo+1, gap.FirstSeqNo))
+			} else {
+				sb.WriteString(fmt.Sprintf("- Run %v: segments %v-%v\n", gap.RunNo+1, gap.FirstSeqNo, gap.LastSeqNo))
+			}
+		}
+	}
+
+	writeProblems := func(title string, problems []*SegmentProblem) {
+		if len(problems) == 0 {
+			return
+		}
+
+		sb.WriteString(fmt.Sprintf("%s: %v\n", title, len(problems)))
+		for _, problem := range problems {
+			sb.WriteString(fmt.Sprintf("- Run %v: segment %v", problem.RunNo+1, problem.SeqNo))
+			if problem.Detail != "" {
+				sb.WriteString(fmt.Sprintf(" (%s)", problem.Detail))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	writeProblems("Failed to download", s.FailedSegments)
+	writeProblems("Discontinuities", s.Discontinuities)
+	writeProblems("Size mismatches", s.SizeMismatches)
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
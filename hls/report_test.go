@@ -0,0 +1,120 @@
+package hls
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRun(t *testing.T, dir string, downloaded []int, notDownloaded []int) map[int]string {
+	run := make(map[int]string)
+	for _, seqNo := range downloaded {
+		run[seqNo] = filepath.Join(dir, fmt.Sprintf("%v.ts", seqNo))
+		require.NoError(t, os.WriteFile(run[seqNo], []byte{0}, 0644))
+	}
+	for _, seqNo := range notDownloaded {
+		run[seqNo] = filepath.Join(dir, fmt.Sprintf("%v.ts", seqNo))
+	}
+	return run
+}
+
+func TestSummarize(t *testing.T) {
+	dir := t.TempDir()
+
+	rec := &Recording{
+		Directory: dir,
+		Runs: []map[int]string{
+			newTestRun(t, dir, []int{1, 2, 5, 6, 10}, []int{3, 11, 12}),
+		},
+		Report: &Report{
+			FailedSegments: []*SegmentProblem{
+				{RunNo: 0, SeqNo: 3, Detail: "status 404"},
+				{RunNo: 0, SeqNo: 3, Detail: "status 404"},
+				// Downloaded again after a restart
+				{RunNo: 0, SeqNo: 5, Detail: "status 404"},
+			},
+			Discontinuities: []*SegmentProblem{{RunNo: 0, SeqNo: 10}},
+			NumSegments:     4,
+			TotalDuration:   8,
+		},
+	}
+
+	summary := rec.Summarize()
+	assert.Equal(t, []*Gap{
+		{RunNo: 0, FirstSeqNo: 3, LastSeqNo: 4},
+		{RunNo: 0, FirstSeqNo: 7, LastSeqNo: 9},
+		{RunNo: 0, FirstSeqNo: 11, LastSeqNo: 12},
+	}, summary.Gaps)
+	assert.Equal(t, 14*time.Second, summary.MissingDuration)
+	assert.Equal(t, []*SegmentProblem{{RunNo: 0, SeqNo: 3, Detail: "status 404"}}, summary.FailedSegments)
+	assert.False(t, summary.IsClean())
+	assert.Contains(t, summary.String(), "Missing 7 segments (~0:00:14) in 3 gaps")
+	assert.Contains(t, summary.String(), "- Run 1: segments 7-9")
+	assert.Contains(t, summary.Brief(), "1 failed to download, 1 discontinuities, 0 size mismatches")
+}
+
+func TestSummarizeAgreesWithSortedRuns(t *testing.T) {
+	dir := t.TempDir()
+
+	rec := &Recording{
+		Directory: dir,
+		Runs: []map[int]string{
+			newTestRun(t, dir, []int{1, 2, 4}, []int{3, 5}),
+		},
+	}
+
+	summary := rec.Summarize()
+
+	// Every segment reported as missing is left out of the upload, and every other segment is uploaded
+	uploaded := make(map[string]bool)
+	for _, filePath := range rec.SortedRuns()[0] {
+		uploaded[filePath] = true
+	}
+	for _, gap := range summary.Gaps {
+		for seqNo := gap.FirstSeqNo; seqNo <= gap.LastSeqNo; seqNo++ {
+			assert.False(t, uploaded[rec.Runs[gap.RunNo][seqNo]], seqNo)
+		}
+	}
+	assert.Len(t, uploaded, 3)
+
+	// Removing the missing segments does not change what is uploaded
+	sortedRuns := rec.SortedRuns()
+	rec.RemoveMissingSegments()
+	assert.Equal(t, sortedRuns, rec.SortedRuns())
+}
+
+func TestSummarizeClean(t *testing.T) {
+	dir := t.TempDir()
+
+	rec := &Recording{
+		Directory: dir,
+		Runs: []map[int]string{
+			newTestRun(t, dir, []int{1, 2, 3}, nil),
+		},
+	}
+
+	summary := rec.Summarize()
+	assert.True(t, summary.IsClean())
+	assert.Empty(t, summary.Gaps)
+}
+
+func TestReportJSON(t *testing.T) {
+	report := &Report{}
+	report.addSegment(2)
+	report.addSizeMismatch(0, 1, 100, 90)
+
+	reportBytes, err := json.Marshal(report)
+	require.NoError(t, err)
+
+	actual := &Report{}
+	require.NoError(t, json.Unmarshal(reportBytes, actual))
+	assert.Equal(t, 1, actual.NumSegments)
+	assert.Equal(t, float64(2), actual.TotalDuration)
+	assert.Equal(t, []*SegmentProblem{{RunNo: 0, SeqNo: 1, Detail: "expected 100 bytes, got 90"}}, actual.SizeMismatches)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +16,8 @@ import (
 
 type API struct{}
 
+var ErrNoStories error = fmt.Errorf("no stories")
+
 var (
 	instaPostURLFormat  string
 	instaStoryURLFormat string
@@ -94,6 +97,41 @@ func (a *API) GetStory(username string, storyID string) (*Story, error) {
 }
 
 func (API) GetLatestStory(username string) (*Story, error) {
+	rawReel, err := getRawReel(username)
+	if err != nil {
+		return nil, err
+	}
+
+	latestReelIdx := 0
+	for i, reel := range rawReel.ReelMedia {
+		if reel.TakenAtTimestamp > rawReel.ReelMedia[latestReelIdx].TakenAtTimestamp {
+			latestReelIdx = i
+		}
+	}
+
+	return parseStory(rawReel.ReelMedia[latestReelIdx], &rawReel.User), nil
+}
+
+// GetStories returns all current stories of the user, oldest first
+func (API) GetStories(username string) ([]*Story, error) {
+	rawReel, err := getRawReel(username)
+	if err != nil {
+		return nil, err
+	}
+
+	stories := make([]*Story, 0, len(rawReel.ReelMedia))
+	for _, reelMedia := range rawReel.ReelMedia {
+		stories = append(stories, parseStory(reelMedia, &rawReel.User))
+	}
+
+	sort.SliceStable(stories, func(i, j int) bool {
+		return stories[i].Timestamp.Before(stories[j].Timestamp)
+	})
+
+	return stories, nil
+}
+
+func getRawReel(username string) (*RawReel, error) {
 	resp, err := client.Get(fmt.Sprintf(instaStoryURLFormat, username))
 	if err != nil {
 		return nil, err
@@ -115,17 +153,10 @@ func (API) GetLatestStory(username string) (*Story, error) {
 	}
 
 	if len(rawReel.ReelMedia) == 0 {
-		return nil, errors.New("failed to get stories")
-	}
-
-	latestReelIdx := 0
-	for i, reel := range rawReel.ReelMedia {
-		if reel.TakenAtTimestamp > rawReel.ReelMedia[latestReelIdx].TakenAtTimestamp {
-			latestReelIdx = i
-		}
+		return nil, ErrNoStories
 	}
 
-	return parseStory(rawReel.ReelMedia[latestReelIdx], &rawReel.User), nil
+	return rawReel, nil
 }
 
 func (API) GetUser(username string) (*User, error) {
@@ -158,6 +189,10 @@ func getRawUser(username string) (*RawUser, error) {
 		return nil, err
 	}
 
+	if rawUser.User == nil {
+		return nil, errors.New("failed to get user")
+	}
+
 	return rawUser.User, nil
 }
 
@@ -191,11 +226,46 @@ func parseUser(rawUser *RawUser) *User {
 		fullName = rawUser.Username
 	}
 
-	return &User{
+	profilePicURL := rawUser.ProfilePicURL
+	if rawUser.ProfilePicURLHD != "" {
+		profilePicURL = rawUser.ProfilePicURLHD
+	}
+
+	user := &User{
 		Username:      rawUser.Username,
 		Fullname:      fullName,
-		ProfilePicURL: rawUser.ProfilePicURL,
+		ProfilePicURL: profilePicURL,
+
+		Biography:      rawUser.Biography,
+		IsPrivate:      rawUser.IsPrivate,
+		IsVerified:     rawUser.IsVerified,
+		FollowerCount:  rawUser.FollowedBy.Count,
+		FollowingCount: rawUser.Follow.Count,
 	}
+
+	if rawUser.TimelineMedia != nil {
+		user.PostCount = rawUser.TimelineMedia.Count
+
+		for _, edge := range rawUser.TimelineMedia.Edges {
+			if edge.Node == nil {
+				continue
+			}
+
+			thumbnailURL := edge.Node.ThumbnailURL
+			if thumbnailURL == "" {
+				thumbnailURL = edge.Node.DisplayURL
+			}
+
+			user.LatestPosts = append(user.LatestPosts, &PostPreview{
+				Shortcode:    edge.Node.Shortcode,
+				ThumbnailURL: thumbnailURL,
+				IsVideo:      edge.Node.IsVideo,
+				Timestamp:    time.Unix(edge.Node.TakenAtTimestamp, 0),
+			})
+		}
+	}
+
+	return user
 }
 
 func (API) GetHashtagURL(s string) string {
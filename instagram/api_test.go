@@ -0,0 +1,69 @@
+package instagram
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rawUserRespJSON = `{
+	"user": {
+		"username": "leah",
+		"full_name": "Leah",
+		"profile_pic_url": "https://cdn/small.jpg",
+		"profile_pic_url_hd": "https://cdn/hd.jpg",
+		"biography": "hello @friend #tag",
+		"is_private": false,
+		"edge_followed_by": {"count": 1200},
+		"edge_follow": {"count": 34},
+		"edge_owner_to_timeline_media": {
+			"count": 56,
+			"edges": [
+				{"node": {"shortcode": "A", "thumbnail_src": "https://cdn/a.jpg", "taken_at_timestamp": 1700000000}},
+				{"node": {"shortcode": "B", "display_url": "https://cdn/b.jpg", "is_video": true}},
+				{"node": {"shortcode": "C", "thumbnail_src": "https://cdn/c.jpg"}},
+				{"node": {"shortcode": "D", "thumbnail_src": "https://cdn/d.jpg"}},
+				{"node": {"shortcode": "E", "thumbnail_src": "https://cdn/e.jpg"}}
+			]
+		}
+	}
+}`
+
+func TestParseUser(t *testing.T) {
+	rawResp := &RawUserResp{}
+	require.NoError(t, json.Unmarshal([]byte(rawUserRespJSON), rawResp))
+
+	user := parseUser(rawResp.User)
+	assert.Equal(t, "https://cdn/hd.jpg", user.ProfilePicURL)
+	assert.Equal(t, 1200, user.FollowerCount)
+	assert.Equal(t, 34, user.FollowingCount)
+	assert.Equal(t, 56, user.PostCount)
+	require.Len(t, user.LatestPosts, 5)
+	assert.Equal(t, "https://cdn/b.jpg", user.LatestPosts[1].ThumbnailURL)
+	assert.True(t, user.LatestPosts[1].IsVideo)
+	assert.Equal(t, "https://instagram.com/p/A", user.LatestPosts[0].URL())
+}
+
+func TestParseUserWithoutProfile(t *testing.T) {
+	user := parseUser(&RawUser{Username: "leah", ProfilePicURL: "https://cdn/small.jpg"})
+	assert.Equal(t, "leah", user.Fullname)
+	assert.Equal(t, "https://cdn/small.jpg", user.ProfilePicURL)
+	assert.Empty(t, user.LatestPosts)
+	assert.Len(t, user.GetEmbeds(), 1)
+}
+
+func TestUserGetEmbeds(t *testing.T) {
+	rawResp := &RawUserResp{}
+	require.NoError(t, json.Unmarshal([]byte(rawUserRespJSON), rawResp))
+
+	embeds := parseUser(rawResp.User).GetEmbeds()
+	require.Len(t, embeds, MAX_EMBEDS_PER_POST)
+	assert.Contains(t, embeds[0].Description, "[@friend](")
+	assert.Equal(t, "https://cdn/a.jpg", embeds[0].Image.URL)
+	assert.Equal(t, "https://cdn/d.jpg", embeds[3].Image.URL)
+	for _, embed := range embeds {
+		assert.Equal(t, "https://instagram.com/leah", embed.URL)
+	}
+}
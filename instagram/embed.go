@@ -2,6 +2,8 @@ package instagram
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -22,15 +24,7 @@ var (
 )
 
 func (p *Post) GetEmbeds() (embeds []*discordgo.MessageEmbed) {
-	textWithEntities := &utils.TextWithEntities{Text: p.Text}
-	textWithEntities.AddByRegex(MentionRegex, func(s string) string {
-		return discord.GetNamedLink(s, (&API{}).GetMentionURL(s))
-	})
-	textWithEntities.AddByRegex(HashtagRegex, func(s string) string {
-		return discord.GetNamedLink(s, (&API{}).GetHashtagURL(s))
-	})
-
-	segmentedText := textWithEntities.GetReplacedText(4096, -1)
+	segmentedText := getTextWithEntities(p.Text).GetReplacedText(4096, -1)
 
 	embeds = append(embeds, &discordgo.MessageEmbed{
 		URL:   p.URL(),
@@ -106,3 +100,87 @@ func (s *Story) GetEmbed() *discordgo.MessageEmbed {
 
 	return embed
 }
+
+func (u *User) GetEmbeds() (embeds []*discordgo.MessageEmbed) {
+	embed := &discordgo.MessageEmbed{
+		URL:   u.URL(),
+		Title: fmt.Sprintf("Instagram profile of %s", u.Fullname),
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    fmt.Sprintf("%s (%s)", u.Fullname, u.Username),
+			URL:     u.URL(),
+			IconURL: u.ProfilePicURL,
+		},
+		Description: getTextWithEntities(u.Biography).GetReplacedText(4096, 1)[0],
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: u.ProfilePicURL,
+		},
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Followers",
+				Value:  strconv.Itoa(u.FollowerCount),
+				Inline: true,
+			},
+			{
+				Name:   "Following",
+				Value:  strconv.Itoa(u.FollowingCount),
+				Inline: true,
+			},
+			{
+				Name:   "Posts",
+				Value:  strconv.Itoa(u.PostCount),
+				Inline: true,
+			},
+		},
+		Color:  utils.ParseHexColor(consts.ColorInsta),
+		Footer: instagramFooter,
+	}
+
+	if u.IsPrivate {
+		embed.Title += " (private)"
+	}
+
+	// Discord tiles the images of embeds with the same URL, so the latest posts are shown as a single gallery
+	latestPosts := u.LatestPosts[:min(len(u.LatestPosts), MAX_EMBEDS_PER_POST)]
+	if len(latestPosts) == 0 {
+		return []*discordgo.MessageEmbed{embed}
+	}
+
+	postLinks := make([]string, 0, len(latestPosts))
+	for i, post := range latestPosts {
+		postLinks = append(postLinks, discord.GetNamedLink(strconv.Itoa(i+1), post.URL()))
+	}
+
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:  "Latest posts",
+		Value: strings.Join(postLinks, " "),
+	})
+	embed.Image = &discordgo.MessageEmbedImage{
+		URL: latestPosts[0].ThumbnailURL,
+	}
+	embed.Timestamp = latestPosts[0].Timestamp.Format(time.RFC3339)
+
+	embeds = append(embeds, embed)
+	for _, post := range latestPosts[1:] {
+		embeds = append(embeds, &discordgo.MessageEmbed{
+			URL: u.URL(),
+			Image: &discordgo.MessageEmbedImage{
+				URL: post.ThumbnailURL,
+			},
+			Color: utils.ParseHexColor(consts.ColorInsta),
+		})
+	}
+
+	return embeds
+}
+
+func getTextWithEntities(text string) *utils.TextWithEntities {
+	textWithEntities := &utils.TextWithEntities{Text: text}
+	textWithEntities.AddByRegex(MentionRegex, func(s string) string {
+		return discord.GetNamedLink(s, (&API{}).GetMentionURL(s))
+	})
+	textWithEntities.AddByRegex(HashtagRegex, func(s string) string {
+		return discord.GetNamedLink(s, (&API{}).GetHashtagURL(s))
+	})
+
+	return textWithEntities
+}
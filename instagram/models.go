@@ -45,9 +45,37 @@ type RawUserResp struct {
 }
 
 type RawUser struct {
-	Username      string `json:"username"`
-	FullName      string `json:"full_name"`
-	ProfilePicURL string `json:"profile_pic_url"`
+	Username        string `json:"username"`
+	FullName        string `json:"full_name"`
+	ProfilePicURL   string `json:"profile_pic_url"`
+	ProfilePicURLHD string `json:"profile_pic_url_hd"`
+
+	// Only returned when fetching the user itself
+	Biography     string            `json:"biography"`
+	IsPrivate     bool              `json:"is_private"`
+	IsVerified    bool              `json:"is_verified"`
+	FollowedBy    RawCount          `json:"edge_followed_by"`
+	Follow        RawCount          `json:"edge_follow"`
+	TimelineMedia *RawTimelineMedia `json:"edge_owner_to_timeline_media"`
+}
+
+type RawCount struct {
+	Count int `json:"count"`
+}
+
+type RawTimelineMedia struct {
+	Count int `json:"count"`
+	Edges []struct {
+		Node *RawTimelineNode `json:"node"`
+	} `json:"edges"`
+}
+
+type RawTimelineNode struct {
+	Shortcode        string `json:"shortcode"`
+	DisplayURL       string `json:"display_url"`
+	ThumbnailURL     string `json:"thumbnail_src"`
+	IsVideo          bool   `json:"is_video"`
+	TakenAtTimestamp int64  `json:"taken_at_timestamp"`
 }
 
 type RawCarouselMedia struct {
@@ -175,12 +203,32 @@ type User struct {
 	Username      string
 	Fullname      string
 	ProfilePicURL string
+
+	Biography      string
+	IsPrivate      bool
+	IsVerified     bool
+	FollowerCount  int
+	FollowingCount int
+	PostCount      int
+	LatestPosts    []*PostPreview
 }
 
 func (u *User) URL() string {
 	return fmt.Sprintf("https://instagram.com/%s", u.Username)
 }
 
+// PostPreview is a post as shown on its owner's profile
+type PostPreview struct {
+	Shortcode    string
+	ThumbnailURL string
+	IsVideo      bool
+	Timestamp    time.Time
+}
+
+func (p *PostPreview) URL() string {
+	return fmt.Sprintf("https://instagram.com/p/%s", p.Shortcode)
+}
+
 type Post struct {
 	Shortcode string
 	Owner     *User
@@ -9,7 +9,10 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/xIceArcher/go-leah/bot"
+	"github.com/xIceArcher/go-leah/cache"
 	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/consts"
+	"github.com/xIceArcher/go-leah/discord"
 	"github.com/xIceArcher/go-leah/handler"
 	"github.com/xIceArcher/go-leah/logger"
 	"go.uber.org/zap"
@@ -20,6 +23,23 @@ func main() {
 	flag.StringVar(&configPath, "config", "./config.yaml", "Path of configuration file")
 	flag.Parse()
 
+	if !run(configPath) {
+		return
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Replace the current process so that the new one keeps the same PID
+	if err := syscall.Exec(executable, os.Args, os.Environ()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run starts the bot and blocks until it is shut down, returning whether the bot should restart
+func run(configPath string) (restart bool) {
 	cfg := &config.Config{}
 	if err := cfg.LoadConfig(configPath); err != nil {
 		log.Fatal(err)
@@ -44,30 +64,98 @@ func main() {
 	defer bot.Stop()
 	logger.Info("Bot started")
 
-	logger.Info("Initializing command handler...")
-	commandHandler, err := handler.NewCommandHandler(cfg, bot.Session)
+	handlers, err := newHandlers(cfg, bot.Session)
 	if err != nil {
-		logger.With(zap.Error(err)).Fatal("Failed to initialize command handler")
+		logger.With(zap.Error(err)).Fatal("Failed to initialize handlers")
+	}
+
+	for _, h := range handlers {
+		bot.AddHandler(h)
+	}
+
+	logger.Info("Bot running")
+
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, consts.SignalReload, consts.SignalRestart)
+
+	for sig := range sc {
+		if sig == consts.SignalReload {
+			cfg = reload(configPath, cfg, bot)
+			continue
+		}
+
+		restart = sig == consts.SignalRestart
+		break
+	}
+
+	if restart {
+		logger.Info("Restarting bot...")
+	} else {
+		logger.Info("Shutting down bot...")
 	}
-	defer commandHandler.Stop()
-	logger.Info("Initialized command handler")
 
-	bot.AddHandler(commandHandler)
+	// Cancel work such as video compression first so that stopping the handlers does not wait for it
+	bot.Stop()
 
-	logger.Info("Initializing regex handler...")
-	regexHandler, err := handler.NewRegexHandler(cfg, bot.Session)
+	// Stopping the handlers lets running tasks persist themselves so that they can be resumed
+	bot.RemoveHandlers().Stop()
+
+	if err := cache.FlushMemoryCache(); err != nil {
+		logger.With(zap.Error(err)).Error("Failed to save cache snapshot")
+	}
+
+	return restart
+}
+
+func newHandlers(cfg *config.Config, s *discord.Session) (handler.MessageHandlers, error) {
+	s.Logger.Info("Initializing command handler...")
+	commandHandler, err := handler.NewCommandHandler(cfg, s)
 	if err != nil {
-		logger.With(zap.Error(err)).Fatal("Failed to initialize regex handler")
+		return nil, err
 	}
-	defer regexHandler.Stop()
-	logger.Info("Initialized regex handler")
+	s.Logger.Info("Initialized command handler")
 
-	bot.AddHandler(regexHandler)
+	s.Logger.Info("Initializing regex handler...")
+	regexHandler, err := handler.NewRegexHandler(cfg, s)
+	if err != nil {
+		commandHandler.Stop()
+		return nil, err
+	}
+	s.Logger.Info("Initialized regex handler")
 
-	logger.Info("Bot running")
+	return handler.MessageHandlers{commandHandler, regexHandler}, nil
+}
 
-	sc := make(chan os.Signal, 1)
-	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM)
-	<-sc
-	logger.Info("Shutting down bot...")
+// reload rebuilds all handlers from the config file and returns the config that is in use afterwards
+func reload(configPath string, oldCfg *config.Config, bot *bot.Bot) *config.Config {
+	logger := bot.Session.Logger
+	logger.Info("Reloading config...")
+
+	newCfg := &config.Config{}
+	if err := newCfg.LoadConfig(configPath); err != nil {
+		logger.With(zap.Error(err)).Error("Failed to load config, keeping old config")
+		return oldCfg
+	}
+
+	// The old handlers must be stopped first so that their tasks are persisted before the new handlers resume them
+	bot.RemoveHandlers().Stop()
+
+	cfg := newCfg
+	handlers, err := newHandlers(cfg, bot.Session)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("Failed to initialize handlers from new config, reverting to old config")
+
+		cfg = oldCfg
+		handlers, err = newHandlers(cfg, bot.Session)
+		if err != nil {
+			logger.With(zap.Error(err)).Fatal("Failed to initialize handlers")
+		}
+	}
+
+	for _, h := range handlers {
+		bot.AddHandler(h)
+	}
+
+	logger.Info("Reloaded config")
+	return cfg
 }
@@ -0,0 +1,66 @@
+package matcher
+
+import (
+	"context"
+	"errors"
+
+	"github.com/xIceArcher/go-leah/bluesky"
+	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/discord"
+	"go.uber.org/zap"
+)
+
+type BlueskyPostMatcher struct {
+	GenericMatcher
+
+	api bluesky.API
+}
+
+func NewBlueskyPostMatcher(cfg *config.Config, s *discord.Session) (Matcher, error) {
+	return &BlueskyPostMatcher{
+		api: bluesky.NewBaseAPI(),
+	}, nil
+}
+
+func (m *BlueskyPostMatcher) Handle(ctx context.Context, s *discord.MessageSession, matches []string) {
+	for _, match := range matches {
+		logger := s.Logger.With(
+			zap.String("match", match),
+		)
+
+		actor, rkey, ok := bluesky.ParsePostPath(match)
+		if !ok {
+			logger.Error("Unknown match")
+			continue
+		}
+
+		post, err := m.api.GetPost(actor, rkey)
+		if errors.Is(err, bluesky.ErrNotFound) {
+			logger.Info("Post not found")
+			continue
+		} else if err != nil {
+			logger.With(zap.Error(err)).Error("Get post")
+			continue
+		}
+
+		if _, err := s.SendEmbeds(post.GetEmbeds()); err != nil {
+			continue
+		}
+
+		// Like photos, the videos of the quoted post are shown if the post has no media of its own
+		videoPost := post
+		if post.IsQuoted && len(post.Medias) == 0 {
+			videoPost = post.QuotedPost
+		}
+
+		for _, video := range videoPost.Videos() {
+			videoURL, err := m.api.GetVideoURL(videoPost.Author.DID, video.CID)
+			if err != nil {
+				logger.With(zap.Error(err)).Error("Get video URL")
+				continue
+			}
+
+			s.SendVideoURL(videoURL, rkey)
+		}
+	}
+}
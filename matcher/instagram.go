@@ -11,6 +11,7 @@ import (
 	"github.com/xIceArcher/go-leah/discord"
 	"github.com/xIceArcher/go-leah/instagram"
 	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
 )
 
 type InstagramPostMatcher struct {
@@ -42,16 +43,8 @@ func (m *InstagramPostMatcher) Handle(ctx context.Context, s *discord.MessageSes
 			continue
 		}
 
-		embeds := post.GetEmbeds()
-		if len(embeds) > 10 {
-			// We send 8 embeds per message because Discord tiles 4 embeds into a single frame
-			// And each message can only have a maximum of 10 embeds
-			for start := 0; start < len(embeds); start += 8 {
-				end := min(start+8, len(embeds))
-				s.SendEmbeds(post.GetEmbeds()[start:end])
-			}
-		} else {
-			s.SendEmbeds(post.GetEmbeds())
+		for _, embeds := range splitEmbeds(post.GetEmbeds()) {
+			s.SendEmbeds(embeds)
 		}
 
 		s.SendVideoURLs(post.VideoURLs, shortcode)
@@ -114,6 +107,46 @@ func (m *InstagramStoryMatcher) Handle(ctx context.Context, s *discord.MessageSe
 	}
 }
 
+// Paths that look like profile links but are not users
+var instagramReservedPaths = []string{"p", "reel", "reels", "tv", "stories", "explore", "accounts", "direct", "share"}
+
+type InstagramProfileMatcher struct {
+	GenericMatcher
+
+	api *instagram.API
+}
+
+func NewInstagramProfileMatcher(cfg *config.Config, s *discord.Session) (Matcher, error) {
+	api, err := instagram.NewAPI(cfg.Instagram)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InstagramProfileMatcher{
+		api: api,
+	}, nil
+}
+
+func (m *InstagramProfileMatcher) Handle(ctx context.Context, s *discord.MessageSession, matches []string) {
+	for _, username := range matches {
+		logger := s.Logger.With(
+			zap.String("username", username),
+		)
+
+		if slices.Contains(instagramReservedPaths, strings.ToLower(username)) {
+			continue
+		}
+
+		user, err := m.api.GetUser(username)
+		if err != nil {
+			logger.With(zap.Error(err)).Error("Get user")
+			continue
+		}
+
+		s.SendEmbeds(user.GetEmbeds())
+	}
+}
+
 type InstagramShareLinkMatcher struct {
 	GenericMatcher
 
@@ -2,6 +2,9 @@ package matcher
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/xIceArcher/go-leah/config"
 	"github.com/xIceArcher/go-leah/discord"
@@ -19,3 +22,45 @@ type GenericMatcher struct{}
 func (m *GenericMatcher) Handle(context.Context, *discord.MessageSession, []string) {}
 
 func (m *GenericMatcher) Stop() {}
+
+// splitEmbeds splits the embeds of a gallery, or anything sent in step with them, into the embeds of each message.
+// Up to 10 embeds are sent in a single message, otherwise each message has 8 embeds
+// because Discord tiles 4 embeds into a single frame and each message can only have a maximum of 10 embeds.
+func splitEmbeds[T any](embeds []T) (messages [][]T) {
+	if len(embeds) <= 10 {
+		return [][]T{embeds}
+	}
+
+	for start := 0; start < len(embeds); start += 8 {
+		end := min(start+8, len(embeds))
+		messages = append(messages, embeds[start:end])
+	}
+	return messages
+}
+
+// getTaskEmbed fetches the embed that a persisted watch task was updating.
+// Task keys are of the form <prefix><channelID>/<messageID>/<embed index>.
+func getTaskEmbed(s *discord.Session, prefix string, taskKey string) (*discord.UpdatableMessageEmbed, error) {
+	key := strings.TrimPrefix(taskKey, prefix)
+	keySplit := strings.Split(key, "/")
+	if len(keySplit) != 3 {
+		return nil, fmt.Errorf("unknown key %s", key)
+	}
+
+	channelID, messageID, idxStr := keySplit[0], keySplit[1], keySplit[2]
+	idx, err := strconv.Atoi(idxStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %s: %w", key, err)
+	}
+
+	embeds, err := s.GetMessageEmbeds(channelID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if idx >= len(embeds) {
+		return nil, fmt.Errorf("expected embed %v but message only has %v embeds", idx, len(embeds))
+	}
+
+	return embeds[idx], nil
+}
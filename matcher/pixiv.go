@@ -0,0 +1,140 @@
+package matcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/pixiv"
+	"go.uber.org/zap"
+)
+
+type PixivArtworkMatcher struct {
+	GenericMatcher
+
+	api *pixiv.API
+}
+
+func NewPixivArtworkMatcher(cfg *config.Config, s *discord.Session) (Matcher, error) {
+	return &PixivArtworkMatcher{
+		api: pixiv.NewAPI(cfg.Pixiv),
+	}, nil
+}
+
+func (m *PixivArtworkMatcher) Handle(ctx context.Context, s *discord.MessageSession, matches []string) {
+	for _, id := range matches {
+		logger := s.Logger.With(
+			zap.String("id", id),
+		)
+
+		artwork, err := m.api.GetArtwork(id)
+		if errors.Is(err, pixiv.ErrNotFound) {
+			logger.With(zap.Error(err)).Info("Artwork not found")
+			continue
+		} else if err != nil {
+			logger.With(zap.Error(err)).Error("Get artwork")
+			continue
+		}
+
+		if artwork.IsR18() && !m.isNSFWChannel(s) {
+			embed := artwork.GetEmbed()
+			embed.Description = fmt.Sprintf("This artwork is rated %s and can only be previewed in age-restricted channels.", artwork.Rating())
+			s.SendEmbed(embed)
+			continue
+		}
+
+		embeds := artwork.GetEmbeds()
+		images := m.downloadImages(artwork, len(embeds), s.GetGuildPremiumTier(), logger)
+
+		imageMessages := splitEmbeds(images)
+		for i, embeds := range splitEmbeds(embeds) {
+			s.SendEmbedsWithImages(embeds, imageMessages[i])
+		}
+
+		if artwork.IsUgoira() {
+			m.sendUgoira(ctx, s, artwork, logger)
+		}
+	}
+}
+
+// downloadImages downloads the image of each embed, since pximg.net rejects requests from Discord.
+// Images share the size limit of the message they are sent in with the other images in it.
+func (m *PixivArtworkMatcher) downloadImages(artwork *pixiv.Artwork, count int, tier discordgo.PremiumTier, logger *zap.SugaredLogger) []*discord.EmbedImage {
+	maxBytes := getImageMaxBytes(count, tier)
+
+	images := make([]*discord.EmbedImage, count)
+	for i, page := range artwork.Pages[:min(len(artwork.Pages), count)] {
+		imageBytes, err := m.api.DownloadImage(page.URL, maxBytes[i])
+		if err != nil {
+			logger.With(zap.Error(err), zap.String("url", page.URL)).Warn("Download image")
+			continue
+		}
+
+		ext := path.Ext(page.URL)
+		images[i] = &discord.EmbedImage{
+			FileName:    fmt.Sprintf("%s_p%v%s", artwork.ID, i, ext),
+			ContentType: mime.TypeByExtension(ext),
+			Bytes:       imageBytes,
+		}
+	}
+
+	return images
+}
+
+// getImageMaxBytes returns the size limit of each of count images, which is the size limit of its message split evenly between the images in it
+func getImageMaxBytes(count int, tier discordgo.PremiumTier) []int64 {
+	maxBytes := make([]int64, 0, count)
+	for _, message := range splitEmbeds(make([]struct{}, count)) {
+		for range message {
+			maxBytes = append(maxBytes, discord.GetMessageMaxBytes(tier)/int64(len(message)))
+		}
+	}
+
+	return maxBytes
+}
+
+func (m *PixivArtworkMatcher) sendUgoira(ctx context.Context, s *discord.MessageSession, artwork *pixiv.Artwork, logger *zap.SugaredLogger) {
+	ugoira, err := m.api.GetUgoira(artwork.ID)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("Get ugoira")
+		return
+	}
+
+	tempDir, err := os.MkdirTemp("", "")
+	if err != nil {
+		logger.With(zap.Error(err)).Error("Create temp dir")
+		return
+	}
+	defer os.RemoveAll(tempDir)
+
+	outPath := filepath.Join(tempDir, "out.mp4")
+	if err := m.api.DownloadUgoiraVideo(ctx, ugoira, outPath); err != nil {
+		logger.With(zap.Error(err)).Error("Convert ugoira")
+		return
+	}
+
+	f, err := os.Open(outPath)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("Open ugoira video")
+		return
+	}
+
+	s.SendVideo(f, artwork.ID)
+}
+
+func (m *PixivArtworkMatcher) isNSFWChannel(s *discord.MessageSession) bool {
+	channel, err := s.Channel(s.ChannelID)
+	if err != nil {
+		s.Logger.With(zap.Error(err)).Warn("Get channel")
+		return false
+	}
+
+	return channel.NSFW
+}
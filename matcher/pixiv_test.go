@@ -0,0 +1,25 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xIceArcher/go-leah/discord"
+)
+
+func TestGetImageMaxBytes(t *testing.T) {
+	messageMaxBytes := discord.GetMessageMaxBytes(discordgo.PremiumTierNone)
+
+	maxBytes := getImageMaxBytes(2, discordgo.PremiumTierNone)
+	assert.Equal(t, []int64{messageMaxBytes / 2, messageMaxBytes / 2}, maxBytes)
+
+	// 8 images in the first message and 4 in the second
+	maxBytes = getImageMaxBytes(12, discordgo.PremiumTierNone)
+	require.Len(t, maxBytes, 12)
+	assert.Equal(t, messageMaxBytes/8, maxBytes[0])
+	assert.Equal(t, messageMaxBytes/8, maxBytes[7])
+	assert.Equal(t, messageMaxBytes/4, maxBytes[8])
+	assert.Equal(t, messageMaxBytes/4, maxBytes[11])
+}
@@ -2,10 +2,12 @@ package matcher
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"path"
 	"strconv"
+	"strings"
 
 	"github.com/xIceArcher/go-leah/config"
 	"github.com/xIceArcher/go-leah/discord"
@@ -14,6 +16,11 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	tiktokPostTypeVideo = "video"
+	tiktokPostTypePhoto = "photo"
+)
+
 type TiktokVideoMatcher struct {
 	GenericMatcher
 
@@ -32,11 +39,17 @@ func NewTiktokVideoMatcher(cfg *config.Config, s *discord.Session) (Matcher, err
 }
 
 func (h *TiktokVideoMatcher) Handle(ctx context.Context, s *discord.MessageSession, matches []string) {
-	for _, id := range matches {
+	for _, match := range matches {
 		logger := s.Logger.With(
-			zap.String("id", id),
+			zap.String("match", match),
 		)
 
+		// Matches are either video/<id> or photo/<id>, a bare video ID, or a short link
+		postType, id, ok := strings.Cut(match, "/")
+		if !ok {
+			postType, id = tiktokPostTypeVideo, match
+		}
+
 		if _, err := strconv.Atoi(id); err != nil {
 			// This is a short link, expand it
 			expandedURL, err := utils.ExpandURL(fmt.Sprintf("https://vt.tiktok.com/%s", id))
@@ -51,7 +64,24 @@ func (h *TiktokVideoMatcher) Handle(ctx context.Context, s *discord.MessageSessi
 				continue
 			}
 
-			_, id = path.Split(u.Path)
+			var dir string
+			dir, id = path.Split(u.Path)
+			postType = path.Base(dir)
+		}
+
+		logger = logger.With(zap.String("id", id))
+
+		if postType == tiktokPostTypePhoto {
+			photoPost, err := h.api.GetPhotoPost(id)
+			if err == nil {
+				for _, embeds := range splitEmbeds(photoPost.GetEmbeds()) {
+					s.SendEmbeds(embeds)
+				}
+				continue
+			} else if !errors.Is(err, tiktok.ErrNotPhotoPost) {
+				logger.With(zap.Error(err)).Error("Get photo post")
+				continue
+			}
 		}
 
 		video, err := h.api.GetVideo(id)
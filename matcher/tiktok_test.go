@@ -0,0 +1,48 @@
+package matcher
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xIceArcher/go-leah/tiktok"
+)
+
+func TestSplitPhotoPostEmbeds(t *testing.T) {
+	post := &tiktok.PhotoPost{
+		ID:     "7300000000000000000",
+		Music:  &tiktok.Music{},
+		Author: &tiktok.User{UniqueID: "user", Nickname: "User"},
+	}
+	for i := 0; i < 13; i++ {
+		post.PhotoURLs = append(post.PhotoURLs, fmt.Sprintf("https://p16.tiktokcdn.com/%v.jpeg", i))
+	}
+
+	messages := splitEmbeds(post.GetEmbeds())
+	require.Len(t, messages, 2)
+	assert.Len(t, messages[0], 8)
+	assert.Len(t, messages[1], 5)
+
+	// Every photo is sent exactly once and in order
+	var photoURLs []string
+	for _, embeds := range messages {
+		for _, embed := range embeds {
+			photoURLs = append(photoURLs, embed.Image.URL)
+		}
+	}
+	assert.Equal(t, post.PhotoURLs, photoURLs)
+}
+
+func TestSplitPhotoPostEmbedsSingleMessage(t *testing.T) {
+	post := &tiktok.PhotoPost{
+		ID:        "7300000000000000000",
+		PhotoURLs: []string{"https://p16.tiktokcdn.com/0.jpeg", "https://p16.tiktokcdn.com/1.jpeg"},
+		Music:     &tiktok.Music{},
+		Author:    &tiktok.User{UniqueID: "user", Nickname: "User"},
+	}
+
+	messages := splitEmbeds(post.GetEmbeds())
+	require.Len(t, messages, 1)
+	assert.Len(t, messages[0], 2)
+}
@@ -2,19 +2,48 @@ package matcher
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/xIceArcher/go-leah/cache"
 	"github.com/xIceArcher/go-leah/config"
 	"github.com/xIceArcher/go-leah/discord"
 	"github.com/xIceArcher/go-leah/twitch"
 	"go.uber.org/zap"
 )
 
+const (
+	CacheKeyTwitchLiveStreamPrefix = "go-leah/twitchLiveStream/"
+	CacheKeyTwitchLiveStreamFormat = CacheKeyTwitchLiveStreamPrefix + "%s/%s/%v"
+
+	twitchLiveStreamRefreshInterval = 5 * time.Minute
+
+	// A stream is only considered ended once it is not found this many times in a row, a minute apart,
+	// so that a short drop or an API hiccup does not end it
+	twitchLiveStreamMaxNotFound           = 3
+	twitchLiveStreamNotFoundRetryInterval = time.Minute
+)
+
+// twitchLiveStreamTask is saved so that the end time of the stream is known after a restart
+type twitchLiveStreamTask struct {
+	LoginName    string    `json:"loginName"`
+	LastLiveTime time.Time `json:"lastLiveTime"`
+	NumNotFound  int       `json:"numNotFound"`
+}
+
 type TwitchLiveStreamMatcher struct {
 	GenericMatcher
 
-	api *twitch.API
+	api   *twitch.API
+	cache cache.Cache
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 func NewTwitchLiveStreamMatcher(cfg *config.Config, s *discord.Session) (Matcher, error) {
@@ -23,12 +52,56 @@ func NewTwitchLiveStreamMatcher(cfg *config.Config, s *discord.Session) (Matcher
 		return nil, err
 	}
 
-	return &TwitchLiveStreamMatcher{
-		api: api,
-	}, nil
+	c, err := cache.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	matcher := &TwitchLiveStreamMatcher{
+		api:   api,
+		cache: c,
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	matcher.resumeOldTasks(s)
+	return matcher, nil
+}
+
+func (m *TwitchLiveStreamMatcher) resumeOldTasks(s *discord.Session) {
+	oldTasks, err := m.cache.GetByPrefix(m.ctx, CacheKeyTwitchLiveStreamPrefix)
+	if err != nil {
+		s.Logger.With(zap.Error(err)).Error("Failed to fetch old tasks")
+	}
+
+	for taskKey, taskValue := range oldTasks {
+		task := &twitchLiveStreamTask{}
+		if err := json.Unmarshal([]byte(fmt.Sprintf("%v", taskValue)), task); err != nil {
+			s.Logger.With(zap.Error(err), zap.String("key", taskKey)).Warn("Failed to parse task")
+			continue
+		}
+
+		embed, err := getTaskEmbed(s, CacheKeyTwitchLiveStreamPrefix, taskKey)
+		if err != nil {
+			s.Logger.With(zap.Error(err), zap.String("key", taskKey)).Warn("Failed to get embed")
+			continue
+		}
+
+		// The stream may have ended while we were down, so refresh immediately
+		m.wg.Add(1)
+		go m.watchStreamTask(taskKey, task, embed, 0, s.Logger)
+
+		if err := m.cache.Clear(m.ctx, taskKey); err != nil {
+			s.Logger.With(zap.Error(err)).Error("Failed to clear cache key")
+		}
+	}
 }
 
 func (m *TwitchLiveStreamMatcher) Handle(ctx context.Context, s *discord.MessageSession, matches []string) {
+	loginNames := make([]string, 0, len(matches))
 	embeds := make([]*discordgo.MessageEmbed, 0, len(matches))
 
 	for _, loginName := range matches {
@@ -45,8 +118,86 @@ func (m *TwitchLiveStreamMatcher) Handle(ctx context.Context, s *discord.Message
 			continue
 		}
 
+		loginNames = append(loginNames, loginName)
 		embeds = append(embeds, streamInfo.GetEmbed())
 	}
 
-	s.SendEmbeds(embeds)
+	updatableEmbeds, err := s.SendEmbeds(embeds)
+	if err != nil {
+		return
+	}
+
+	for i, embed := range updatableEmbeds {
+		cacheKey := fmt.Sprintf(CacheKeyTwitchLiveStreamFormat, embed.ChannelID, embed.Message.ID, i)
+
+		task := &twitchLiveStreamTask{
+			LoginName:    loginNames[i],
+			LastLiveTime: time.Now(),
+		}
+
+		m.wg.Add(1)
+		go m.watchStreamTask(cacheKey, task, embed, twitchLiveStreamRefreshInterval, s.Logger)
+	}
+}
+
+func (m *TwitchLiveStreamMatcher) watchStreamTask(cacheKey string, task *twitchLiveStreamTask, embed *discord.UpdatableMessageEmbed, firstTickDelay time.Duration, logger *zap.SugaredLogger) {
+	defer m.wg.Done()
+
+	logger = logger.With(zap.String("loginName", task.LoginName))
+
+	nextTickTime := time.Now().Add(firstTickDelay)
+	for {
+		select {
+		case <-m.ctx.Done():
+			taskBytes, err := json.Marshal(task)
+			if err != nil {
+				logger.With(zap.Error(err)).Error("Failed to marshal task")
+				return
+			}
+
+			// Cannot use ctx here since it has already been cancelled
+			err = m.cache.Set(context.Background(), cacheKey, taskBytes)
+			if err != nil {
+				logger.With(zap.Error(err)).Error("Failed to write to cache")
+			}
+			return
+		case <-time.After(time.Until(nextTickTime)):
+			nextTickTime = time.Now().Add(twitchLiveStreamRefreshInterval)
+
+			stream, err := m.api.GetStream(task.LoginName)
+			if errors.Is(err, twitch.ErrNotFound) {
+				task.NumNotFound++
+				if task.NumNotFound < twitchLiveStreamMaxNotFound {
+					logger.With(zap.Int("numNotFound", task.NumNotFound)).Info("Stream not found, checking again")
+					nextTickTime = time.Now().Add(twitchLiveStreamNotFoundRetryInterval)
+					continue
+				}
+
+				// The stream ended some time after it was last seen live, which may have been before a restart
+				twitch.SetEmbedEnded(embed.MessageEmbed, task.LastLiveTime)
+			} else if err != nil {
+				logger.With(zap.Error(err)).Warn("Failed to get stream")
+				continue
+			} else {
+				task.LastLiveTime = time.Now()
+				task.NumNotFound = 0
+				embed.MessageEmbed = stream.GetEmbed()
+			}
+
+			if err := embed.Update(); err != nil {
+				logger.With(zap.Error(err)).Error("Failed to update embed")
+				return
+			}
+
+			if stream == nil {
+				logger.Info("Stream ended")
+				return
+			}
+		}
+	}
+}
+
+func (m *TwitchLiveStreamMatcher) Stop() {
+	m.cancel()
+	m.wg.Wait()
 }
@@ -2,10 +2,15 @@ package matcher
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/bwmarrin/discordgo"
+	"github.com/xIceArcher/go-leah/cache"
 	"github.com/xIceArcher/go-leah/config"
 	"github.com/xIceArcher/go-leah/discord"
 	"github.com/xIceArcher/go-leah/twitter"
@@ -164,3 +169,187 @@ func isDiscordMainEmbedPossiblyCorrect(tweet *twitter.Tweet) bool {
 
 	return true
 }
+
+const (
+	CacheKeyTwitterSpacePrefix = "go-leah/twitterSpace/"
+	CacheKeyTwitterSpaceFormat = CacheKeyTwitterSpacePrefix + "%s/%s/%v"
+
+	twitterSpaceRefreshInterval = time.Minute
+
+	// Spaces that fail to load this many times in a row are assumed to have ended
+	twitterSpaceMaxConsecutiveFailures = 10
+)
+
+type TwitterSpaceMatcher struct {
+	GenericMatcher
+
+	api   twitter.API
+	cache cache.Cache
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewTwitterSpaceMatcher(cfg *config.Config, s *discord.Session) (Matcher, error) {
+	c, err := cache.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	matcher := &TwitterSpaceMatcher{
+		api:   twitter.NewBaseAPI(),
+		cache: c,
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	matcher.resumeOldTasks(s)
+	return matcher, nil
+}
+
+func (m *TwitterSpaceMatcher) resumeOldTasks(s *discord.Session) {
+	oldTasks, err := m.cache.GetByPrefix(m.ctx, CacheKeyTwitterSpacePrefix)
+	if err != nil {
+		s.Logger.With(zap.Error(err)).Error("Failed to fetch old tasks")
+	}
+
+	for taskKey, taskValue := range oldTasks {
+		spaceID := fmt.Sprintf("%v", taskValue)
+
+		space, err := m.api.GetSpace(spaceID)
+		if errors.Is(err, twitter.ErrNotFound) {
+			// The space was deleted, so there is nothing left to watch
+			s.Logger.With(zap.String("spaceID", spaceID)).Info("Space not found")
+			if err := m.cache.Clear(m.ctx, taskKey); err != nil {
+				s.Logger.With(zap.Error(err)).Error("Failed to clear cache key")
+			}
+			continue
+		} else if err != nil {
+			s.Logger.With(zap.Error(err), zap.String("spaceID", spaceID)).Warn("Failed to get space")
+			continue
+		}
+
+		embed, err := getTaskEmbed(s, CacheKeyTwitterSpacePrefix, taskKey)
+		if err != nil {
+			s.Logger.With(zap.Error(err), zap.String("key", taskKey)).Warn("Failed to get embed")
+			continue
+		}
+
+		m.wg.Add(1)
+		go m.watchSpaceTask(taskKey, space, embed, s.Logger)
+
+		if err := m.cache.Clear(m.ctx, taskKey); err != nil {
+			s.Logger.With(zap.Error(err)).Error("Failed to clear cache key")
+		}
+	}
+}
+
+func (m *TwitterSpaceMatcher) Handle(ctx context.Context, s *discord.MessageSession, matches []string) {
+	spaces := make([]*twitter.Space, 0, len(matches))
+	embeds := make([]*discordgo.MessageEmbed, 0, len(matches))
+
+	for _, spaceID := range matches {
+		logger := s.Logger.With(
+			zap.String("spaceID", spaceID),
+		)
+
+		space, err := m.api.GetSpace(spaceID)
+		if errors.Is(err, twitter.ErrNotFound) {
+			logger.Info("Space not found")
+			continue
+		} else if err != nil {
+			logger.With(zap.Error(err)).Error("Get space")
+			continue
+		}
+
+		spaces = append(spaces, space)
+		embeds = append(embeds, space.GetEmbed())
+	}
+
+	updatableEmbeds, err := s.SendEmbeds(embeds)
+	if err != nil {
+		return
+	}
+
+	for i, embed := range updatableEmbeds {
+		if spaces[i].IsEnded() {
+			continue
+		}
+
+		cacheKey := fmt.Sprintf(CacheKeyTwitterSpaceFormat, embed.ChannelID, embed.Message.ID, i)
+
+		m.wg.Add(1)
+		go m.watchSpaceTask(cacheKey, spaces[i], embed, s.Logger)
+	}
+}
+
+func (m *TwitterSpaceMatcher) watchSpaceTask(cacheKey string, space *twitter.Space, embed *discord.UpdatableMessageEmbed, logger *zap.SugaredLogger) {
+	defer m.wg.Done()
+
+	logger = logger.With(zap.String("spaceID", space.ID))
+
+	numConsecutiveFailures := 0
+	for {
+		nextTickTime := time.Now().Add(twitterSpaceRefreshInterval)
+		if space.State == twitter.SpaceStateScheduled && time.Until(space.ScheduledStartTime) > twitterSpaceRefreshInterval {
+			// Nothing will change until the space starts
+			nextTickTime = space.ScheduledStartTime
+		}
+
+		select {
+		case <-m.ctx.Done():
+			// Cannot use ctx here since it has already been cancelled
+			err := m.cache.Set(context.Background(), cacheKey, space.ID)
+			if err != nil {
+				logger.With(zap.Error(err)).Error("Failed to write to cache")
+			}
+			return
+		case <-time.After(time.Until(nextTickTime)):
+			newSpace, err := m.api.GetSpace(space.ID)
+			if errors.Is(err, twitter.ErrNotFound) {
+				logger.Info("Space not found, assuming it has ended")
+				space.State = twitter.SpaceStateEnded
+			} else if err != nil {
+				numConsecutiveFailures++
+				if numConsecutiveFailures < twitterSpaceMaxConsecutiveFailures {
+					logger.With(zap.Error(err)).Warn("Failed to get space")
+					continue
+				}
+
+				logger.With(zap.Error(err)).Warn("Failed to get space too many times, assuming it has ended")
+				space.State = twitter.SpaceStateEnded
+			} else {
+				numConsecutiveFailures = 0
+				space = newSpace
+			}
+
+			if space.IsEnded() && space.EndTime.IsZero() {
+				space.EndTime = time.Now()
+			}
+			if space.IsEnded() && space.StartTime.IsZero() {
+				// Spaces that are deleted before they start never had a start time
+				space.StartTime = space.EndTime
+			}
+
+			embed.MessageEmbed = space.GetEmbed()
+			if err := embed.Update(); err != nil {
+				logger.With(zap.Error(err)).Error("Failed to update embed")
+				return
+			}
+
+			if space.IsEnded() {
+				logger.Info("Space ended")
+				return
+			}
+		}
+	}
+}
+
+func (m *TwitterSpaceMatcher) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
@@ -0,0 +1,46 @@
+package matcher
+
+import (
+	"context"
+	"errors"
+
+	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/weibo"
+	"go.uber.org/zap"
+)
+
+type WeiboPostMatcher struct {
+	GenericMatcher
+
+	api *weibo.API
+}
+
+func NewWeiboPostMatcher(cfg *config.Config, s *discord.Session) (Matcher, error) {
+	return &WeiboPostMatcher{
+		api: weibo.NewAPI(),
+	}, nil
+}
+
+func (m *WeiboPostMatcher) Handle(ctx context.Context, s *discord.MessageSession, matches []string) {
+	for _, postID := range matches {
+		logger := s.Logger.With(
+			zap.String("postID", postID),
+		)
+
+		post, err := m.api.GetPost(postID)
+		if errors.Is(err, weibo.ErrNotFound) {
+			logger.Info("Post not found")
+			continue
+		} else if err != nil {
+			logger.With(zap.Error(err)).Error("Get post")
+			continue
+		}
+
+		s.SendEmbeds(post.GetEmbeds())
+
+		for _, video := range post.MediaPost().Videos {
+			s.SendVideoURL(video.URL, post.ID)
+		}
+	}
+}
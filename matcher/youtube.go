@@ -4,16 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/hashicorp/go-retryablehttp"
 	"github.com/xIceArcher/go-leah/cache"
 	"github.com/xIceArcher/go-leah/config"
 	"github.com/xIceArcher/go-leah/consts"
 	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/hls"
+	httpclient "github.com/xIceArcher/go-leah/http"
+	"github.com/xIceArcher/go-leah/storage"
 	"github.com/xIceArcher/go-leah/utils"
 	"github.com/xIceArcher/go-leah/youtube"
 	"go.uber.org/zap"
@@ -21,7 +23,16 @@ import (
 
 const (
 	CacheKeyYoutubeLiveStreamPrefix = "go-leah/youtubeLiveStream/"
-	CacheKeyYoutubeLiveStreamFormat = CacheKeyYoutubeLiveStreamPrefix + "%s/%s/%v"
+
+	// Streams watched because of a recording command are resumed separately from those watched by the matcher
+	CacheKeyYoutubeRecordTaskPrefix = "go-leah/youtubeRecord/task/"
+
+	// How often streams that should be recorded are checked once they are about to start
+	youtubeRecordingWaitInterval = 30 * time.Second
+)
+
+var (
+	ErrNotActiveLivestream error = fmt.Errorf("video is not an upcoming or live stream")
 )
 
 type YoutubeLiveStreamMatcher struct {
@@ -30,13 +41,35 @@ type YoutubeLiveStreamMatcher struct {
 	api   youtube.API
 	cache cache.Cache
 
+	// Watch tasks are saved as <cacheKeyPrefix><channelID>/<messageID>/<embed index>
+	cacheKeyPrefix string
+
+	// Used while waiting for a recording to start, since the cached API may be minutes out of date
+	uncachedAPI youtube.API
+
+	// Nil if recordings are not uploaded
+	storage                 storage.Storage
+	client                  *retryablehttp.Client
+	downloaderOpts          *hls.DownloaderOptions
+	recordDiscordChannelIDs []string
+	recordYoutubeChannelIDs []string
+
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 }
 
 func NewYoutubeLiveStreamMatcher(cfg *config.Config, s *discord.Session) (Matcher, error) {
-	c, err := cache.NewRedisCache(cfg.Redis)
+	return newYoutubeLiveStreamMatcher(cfg, s, CacheKeyYoutubeLiveStreamPrefix)
+}
+
+// NewYoutubeRecorder returns a live stream matcher for streams that are watched on request with Record
+func NewYoutubeRecorder(cfg *config.Config, s *discord.Session) (*YoutubeLiveStreamMatcher, error) {
+	return newYoutubeLiveStreamMatcher(cfg, s, CacheKeyYoutubeRecordTaskPrefix)
+}
+
+func newYoutubeLiveStreamMatcher(cfg *config.Config, s *discord.Session, cacheKeyPrefix string) (*YoutubeLiveStreamMatcher, error) {
+	c, err := cache.New(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -46,22 +79,54 @@ func NewYoutubeLiveStreamMatcher(cfg *config.Config, s *discord.Session) (Matche
 		return nil, err
 	}
 
+	uncachedAPI, err := youtube.NewAPI(cfg.Google)
+	if err != nil {
+		return nil, err
+	}
+
+	recordingStorage, err := storage.New(cfg, s.Logger)
+	if errors.Is(err, storage.ErrNotConfigured) {
+		s.Logger.Info("No storage configured, recordings will not be uploaded")
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Recordings share the configured bandwidth limit with each other and with the download cog
+	downloaderOpts, err := hls.NewDownloaderOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	matcher := &YoutubeLiveStreamMatcher{
 		api:   a,
 		cache: c,
 
+		cacheKeyPrefix: cacheKeyPrefix,
+
+		uncachedAPI: uncachedAPI,
+
+		storage: recordingStorage,
+		client:  httpclient.NewClientWithHeaders(nil),
+
+		downloaderOpts: downloaderOpts,
+
 		ctx:    ctx,
 		cancel: cancel,
 	}
 
+	if cfg.Youtube != nil {
+		matcher.recordDiscordChannelIDs = cfg.Youtube.RecordDiscordChannelIDs
+		matcher.recordYoutubeChannelIDs = cfg.Youtube.RecordYoutubeChannelIDs
+	}
+
 	matcher.resumeOldTasks(s)
 	return matcher, nil
 }
 
 func (m *YoutubeLiveStreamMatcher) resumeOldTasks(s *discord.Session) {
-	oldTasks, err := m.cache.GetByPrefix(m.ctx, CacheKeyYoutubeLiveStreamPrefix)
+	oldTasks, err := m.cache.GetByPrefix(m.ctx, m.cacheKeyPrefix)
 	if err != nil {
 		s.Logger.With(zap.Error(err)).Error("Failed to fetch old tasks")
 	}
@@ -72,35 +137,17 @@ func (m *YoutubeLiveStreamMatcher) resumeOldTasks(s *discord.Session) {
 		video, err := m.api.GetVideo(m.ctx, videoID, []string{youtube.PartLiveStreamingDetails, youtube.PartContentDetails, youtube.PartSnippet})
 		if err != nil {
 			s.Logger.With(zap.Error(err), zap.String("videoID", videoID)).Warn("Failed to get video")
+			m.resumeRecording(s, taskKey, videoID)
 			continue
 		}
 
-		key := strings.TrimPrefix(taskKey, CacheKeyYoutubeLiveStreamPrefix)
-		keySplit := strings.Split(key, "/")
-		if len(keySplit) != 3 {
-			s.Logger.With(zap.String("key", key)).Warn("Unknown key")
-			continue
-		}
-
-		channelID, messageID, idxStr := keySplit[0], keySplit[1], keySplit[2]
-		idx, err := strconv.Atoi(idxStr)
+		embed, err := getTaskEmbed(s, m.cacheKeyPrefix, taskKey)
 		if err != nil {
-			s.Logger.With(zap.Error(err), zap.String("key", key)).Warn("Failed to parse key")
+			s.Logger.With(zap.Error(err), zap.String("key", taskKey)).Warn("Failed to get embed")
 			continue
 		}
 
-		embeds, err := s.GetMessageEmbeds(channelID, messageID)
-		if err != nil {
-			s.Logger.With(zap.Error(err), zap.String("channelID", channelID), zap.String("messageID", messageID)).Warn("Failed to get message")
-			continue
-		}
-
-		if idx >= len(embeds) {
-			s.Logger.With(zap.Int("expectedIdx", idx), zap.Int("numEmbeds", len(embeds))).Warn("Failed to get embed")
-			continue
-		}
-
-		go m.watchVideoTask(taskKey, video, embeds[idx], s.Logger)
+		go m.watchVideoTask(taskKey, video, embed, s.Logger)
 
 		if err := m.cache.Clear(m.ctx, taskKey); err != nil {
 			s.Logger.With(zap.Error(err)).Error("Failed to clear cache key")
@@ -143,19 +190,59 @@ func (m *YoutubeLiveStreamMatcher) Handle(ctx context.Context, s *discord.Messag
 	updatableEmbeds, err := s.SendEmbeds(embeds)
 	if err == nil {
 		for i, embed := range updatableEmbeds {
-			cacheKey := fmt.Sprintf(CacheKeyYoutubeLiveStreamFormat, embed.ChannelID, embed.Message.ID, i)
-			go m.watchVideoTask(cacheKey, videos[i], updatableEmbeds[i], s.Logger)
+			go m.watchVideoTask(m.taskCacheKey(embed, i), videos[i], updatableEmbeds[i], s.Logger)
 		}
 	}
 }
 
+// Record watches the video like a posted link, and records it as soon as it goes live
+func (m *YoutubeLiveStreamMatcher) Record(ctx context.Context, s *discord.MessageSession, videoID string) error {
+	video, err := m.api.GetVideo(ctx, videoID, []string{
+		youtube.PartLiveStreamingDetails,
+		youtube.PartContentDetails,
+		youtube.PartSnippet,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !video.IsActiveLivestream() {
+		return ErrNotActiveLivestream
+	}
+
+	if err := youtube.RequestRecording(ctx, m.cache, videoID); err != nil {
+		return err
+	}
+
+	updatableEmbeds, err := s.SendEmbeds([]*discordgo.MessageEmbed{video.GetEmbed()})
+	if err != nil {
+		return err
+	}
+
+	go m.watchVideoTask(m.taskCacheKey(updatableEmbeds[0], 0), video, updatableEmbeds[0], s.Logger)
+	return nil
+}
+
+func (m *YoutubeLiveStreamMatcher) taskCacheKey(embed *discord.UpdatableMessageEmbed, idx int) string {
+	return fmt.Sprintf("%s%s/%s/%v", m.cacheKeyPrefix, embed.ChannelID, embed.Message.ID, idx)
+}
+
 func (m *YoutubeLiveStreamMatcher) watchVideoTask(cacheKey string, video *youtube.Video, embed *discord.UpdatableMessageEmbed, logger *zap.SugaredLogger) {
 	m.wg.Add(1)
 	defer m.wg.Done()
 
-	logger = logger.With(zap.String("videoID", video.ID))
+	videoID := video.ID
+	logger = logger.With(zap.String("videoID", videoID))
+
+	liveEmbed := &liveStreamEmbed{embed: embed}
+	var recordingDone <-chan struct{}
 
 	for {
+		if recordingDone == nil {
+			recordingDone = m.maybeStartRecording(video, liveEmbed, logger)
+		}
+		isWaitingToRecord := recordingDone == nil && liveEmbed.RecordingStatus() == youtubeRecordingStatusWaiting
+
 		var nextTickTime time.Time
 		if video.IsDone {
 			// If the video is already done, immediately update
@@ -174,10 +261,17 @@ func (m *YoutubeLiveStreamMatcher) watchVideoTask(cacheKey string, video *youtub
 			nextTickTime = time.Now().Add(5 * time.Minute)
 		}
 
+		if isWaitingToRecord && time.Until(video.LiveStreamingDetails.ScheduledStartTime) <= time.Hour {
+			// Start recording as soon as the stream goes live instead of at the next refresh
+			if waitTickTime := time.Now().Add(youtubeRecordingWaitInterval); waitTickTime.Before(nextTickTime) {
+				nextTickTime = waitTickTime
+			}
+		}
+
 		select {
 		case <-m.ctx.Done():
 			// Cannot use ctx here since it has already been cancelled
-			err := m.cache.Set(context.Background(), cacheKey, video.ID)
+			err := m.cache.Set(context.Background(), cacheKey, videoID)
 			if err != nil {
 				logger.With(zap.Error(err)).Error("Failed to write to cache")
 			}
@@ -185,9 +279,20 @@ func (m *YoutubeLiveStreamMatcher) watchVideoTask(cacheKey string, video *youtub
 		case <-time.After(time.Until(nextTickTime)):
 			startTime := video.LiveStreamingDetails.ActualStartTime
 
+			api := m.api
+			if isWaitingToRecord {
+				api = m.uncachedAPI
+			}
+
 			var err error
-			video, err = m.api.GetVideo(m.ctx, video.ID, []string{youtube.PartLiveStreamingDetails, youtube.PartContentDetails, youtube.PartSnippet})
-			if err != nil {
+			video, err = api.GetVideo(m.ctx, videoID, []string{youtube.PartLiveStreamingDetails, youtube.PartContentDetails, youtube.PartSnippet})
+			isVideoGone := err != nil
+
+			if err := liveEmbed.Update(func(embed *discordgo.MessageEmbed) *discordgo.MessageEmbed {
+				if !isVideoGone {
+					return video.GetEmbed()
+				}
+
 				// Assume the video ended and became unlisted
 				embed.Fields = []*discordgo.MessageEmbedField{
 					{
@@ -203,17 +308,18 @@ func (m *YoutubeLiveStreamMatcher) watchVideoTask(cacheKey string, video *youtub
 				}
 
 				embed.Color = utils.ParseHexColor(consts.ColorNone)
-			} else {
-				embed.MessageEmbed = video.GetEmbed()
-			}
-
-			if err := embed.Update(); err != nil {
+				return embed
+			}); err != nil {
 				logger.With(zap.Error(err)).Error("Failed to update embed")
+				m.waitForRecording(cacheKey, videoID, recordingDone, logger)
 				return
 			}
 
 			if video == nil || video.IsDone {
 				logger.Info("Video done")
+
+				// The recording may still be uploading, and has to be resumed if the bot stops before it is done
+				m.waitForRecording(cacheKey, videoID, recordingDone, logger)
 				return
 			}
 		}
@@ -0,0 +1,384 @@
+package matcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/xIceArcher/go-leah/cache"
+	"github.com/xIceArcher/go-leah/consts"
+	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/hls"
+	"github.com/xIceArcher/go-leah/youtube"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	youtubeRecordingFieldName = "Recording"
+
+	youtubeRecordingStatusWaiting   = "Waiting for stream"
+	youtubeRecordingStatusRecording = "Recording"
+	youtubeRecordingStatusPaused    = "Paused until the bot is back"
+	youtubeRecordingStatusUploading = "Uploading"
+	youtubeRecordingStatusSaved     = "Saved"
+	youtubeRecordingStatusFailed    = "Failed"
+)
+
+// Videos that are being recorded, so that a video watched by more than one task is only recorded once.
+// Shared by every live stream matcher, since the download cog watches requested streams with its own.
+var (
+	recordingVideoIDs   = make(map[string]struct{})
+	recordingVideoIDsMu sync.Mutex
+)
+
+// liveStreamEmbed serializes updates to a tracked embed from its watch task and its recording
+type liveStreamEmbed struct {
+	mu              sync.Mutex
+	embed           *discord.UpdatableMessageEmbed
+	recordingStatus string
+}
+
+// Update replaces the embed with the result of updateFunc, which is given the current embed
+func (e *liveStreamEmbed) Update(updateFunc func(embed *discordgo.MessageEmbed) *discordgo.MessageEmbed) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.embed.MessageEmbed = updateFunc(e.embed.MessageEmbed)
+	return e.update()
+}
+
+func (e *liveStreamEmbed) RecordingStatus() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.recordingStatus
+}
+
+func (e *liveStreamEmbed) SetRecordingStatus(status string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.recordingStatus == status {
+		return nil
+	}
+
+	e.recordingStatus = status
+	return e.update()
+}
+
+func (e *liveStreamEmbed) update() error {
+	if e.recordingStatus != "" {
+		fields := make([]*discordgo.MessageEmbedField, 0, len(e.embed.Fields)+1)
+		for _, field := range e.embed.Fields {
+			if field.Name != youtubeRecordingFieldName {
+				fields = append(fields, field)
+			}
+		}
+
+		e.embed.Fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   youtubeRecordingFieldName,
+			Value:  e.recordingStatus,
+			Inline: true,
+		})
+	}
+
+	return e.embed.Update()
+}
+
+// shouldRecord returns whether the video was opted into recording, either by config or by a request
+func (m *YoutubeLiveStreamMatcher) shouldRecord(video *youtube.Video, channelID string, logger *zap.SugaredLogger) bool {
+	if slices.Contains(m.recordDiscordChannelIDs, channelID) || slices.Contains(m.recordYoutubeChannelIDs, video.ChannelID) {
+		return true
+	}
+
+	if _, err := m.cache.Get(m.ctx, fmt.Sprintf(youtube.CacheKeyRecordingFormat, video.ID)); err == nil {
+		// A recording was interrupted by a restart
+		return true
+	}
+
+	isRequested, err := youtube.IsRecordingRequested(m.ctx, m.cache, video.ID)
+	if err != nil {
+		logger.With(zap.Error(err)).Warn("Failed to check if recording was requested")
+		return false
+	}
+
+	return isRequested
+}
+
+// maybeStartRecording starts recording the video if it should be recorded and it is live.
+// It returns a channel that is closed once the recording is done, or nil if the recording was not started.
+func (m *YoutubeLiveStreamMatcher) maybeStartRecording(video *youtube.Video, liveEmbed *liveStreamEmbed, logger *zap.SugaredLogger) <-chan struct{} {
+	if !m.shouldRecord(video, liveEmbed.embed.ChannelID, logger) {
+		return nil
+	}
+
+	_, err := m.cache.Get(m.ctx, fmt.Sprintf(youtube.CacheKeyRecordingFormat, video.ID))
+	hasSavedRecording := err == nil
+
+	isLive := video.IsActiveLivestream() && !video.LiveStreamingDetails.ActualStartTime.IsZero()
+	if !isLive && !hasSavedRecording {
+		if !video.IsDone {
+			if err := liveEmbed.SetRecordingStatus(youtubeRecordingStatusWaiting); err != nil {
+				logger.With(zap.Error(err)).Warn("Failed to update embed")
+			}
+		}
+		return nil
+	}
+
+	if !startRecording(video.ID) {
+		logger.Info("Video is already being recorded")
+		return nil
+	}
+
+	done := make(chan struct{})
+
+	m.wg.Add(1)
+	go m.recordLiveStream(video, liveEmbed, logger, done)
+	return done
+}
+
+// startRecording marks the video as being recorded, and returns false if it already is
+func startRecording(videoID string) bool {
+	recordingVideoIDsMu.Lock()
+	defer recordingVideoIDsMu.Unlock()
+
+	if _, ok := recordingVideoIDs[videoID]; ok {
+		return false
+	}
+
+	recordingVideoIDs[videoID] = struct{}{}
+	return true
+}
+
+func stopRecording(videoID string) {
+	recordingVideoIDsMu.Lock()
+	defer recordingVideoIDsMu.Unlock()
+
+	delete(recordingVideoIDs, videoID)
+}
+
+// recordLiveStream records the video until the stream ends, then uploads the recording to storage.
+// done is closed once it returns.
+func (m *YoutubeLiveStreamMatcher) recordLiveStream(video *youtube.Video, liveEmbed *liveStreamEmbed, logger *zap.SugaredLogger, done chan<- struct{}) {
+	defer m.wg.Done()
+	defer close(done)
+	defer stopRecording(video.ID)
+
+	logger = logger.With(zap.String("recording", video.ID))
+	setStatus := func(status string) {
+		if err := liveEmbed.SetRecordingStatus(status); err != nil {
+			logger.With(zap.Error(err)).Warn("Failed to update embed")
+		}
+	}
+
+	cacheKey := fmt.Sprintf(youtube.CacheKeyRecordingFormat, video.ID)
+	rec, err := m.loadRecording(cacheKey)
+	if errors.Is(err, cache.ErrNotFound) {
+		dir := fmt.Sprintf("%s-%s", time.Now().Format(consts.TimeFormatYYMMDDHHMMSS), video.ID)
+		if err := os.Mkdir(dir, os.ModePerm); err != nil {
+			logger.With(zap.Error(err)).Error("Failed to create recording directory")
+			setStatus(youtubeRecordingStatusFailed)
+			return
+		}
+
+		rec = &hls.Recording{Directory: dir}
+	} else if err != nil {
+		logger.With(zap.Error(err)).Error("Failed to load recording")
+		setStatus(youtubeRecordingStatusFailed)
+		return
+	} else {
+		rec.RemoveMissingSegments()
+	}
+
+	if err := youtube.ClearRecordingRequest(m.ctx, m.cache, video.ID); err != nil {
+		logger.With(zap.Error(err)).Warn("Failed to clear recording request")
+	}
+
+	if !rec.IsEnded {
+		m3u8URL, err := youtube.GetHLSURL(m.ctx, video.ID)
+		if err != nil && len(rec.Runs) == 0 {
+			logger.With(zap.Error(err)).Error("Failed to get HLS URL")
+			setStatus(youtubeRecordingStatusFailed)
+			return
+		} else if err != nil {
+			// The stream ended while the bot was down, so upload what was recorded before that
+			logger.With(zap.Error(err)).Warn("Failed to get HLS URL, assuming stream ended")
+		} else {
+			rec.M3U8URL = m3u8URL
+			setStatus(youtubeRecordingStatusRecording)
+
+			err = hls.Record(m.ctx, m.client, rec, m.downloaderOpts, nil, logger, func() {
+				if err := m.saveRecording(m.ctx, cacheKey, rec); err != nil {
+					logger.With(zap.Error(err)).Warn("Failed to save recording")
+				}
+			})
+			if m.ctx.Err() != nil {
+				// Cannot use m.ctx here since it has already been cancelled
+				if err := m.saveRecording(context.Background(), cacheKey, rec); err != nil {
+					logger.With(zap.Error(err)).Error("Failed to save recording")
+					setStatus(youtubeRecordingStatusFailed)
+					return
+				}
+
+				setStatus(youtubeRecordingStatusPaused)
+				return
+			} else if err != nil {
+				logger.With(zap.Error(err)).Error("Failed to record stream")
+				setStatus(youtubeRecordingStatusFailed)
+				m.clearRecording(cacheKey, logger)
+				return
+			}
+		}
+
+		// Save before uploading so that a restart does not record the stream again
+		rec.IsEnded = true
+		if err := m.saveRecording(context.Background(), cacheKey, rec); err != nil {
+			logger.With(zap.Error(err)).Warn("Failed to save recording")
+		}
+	}
+
+	defer m.clearRecording(cacheKey, logger)
+
+	if m.storage == nil {
+		logger.Info("No storage configured, recording left in working directory")
+		setStatus(youtubeRecordingStatusSaved)
+		return
+	}
+
+	setStatus(youtubeRecordingStatusUploading)
+
+	// Segments that failed to download cannot be uploaded
+	rec.RemoveMissingSegments()
+
+	runs := rec.SortedRuns()
+	for runNo, run := range runs {
+		if len(run) == 0 {
+			continue
+		}
+
+		fileName := youtubeRecordingFileName(video, runNo, len(runs))
+		if _, err := m.storage.UploadAndConcat(fileName, run); err != nil {
+			logger.With(zap.Error(err), zap.String("fileName", fileName)).Error("Failed to upload recording")
+			setStatus(youtubeRecordingStatusFailed)
+			return
+		}
+	}
+
+	if err := os.RemoveAll(rec.Directory); err != nil {
+		logger.With(zap.Error(err)).Warn("Failed to delete recording directory")
+	}
+
+	setStatus(youtubeRecordingStatusSaved)
+}
+
+// resumeRecording finishes the saved recording of a video that can no longer be fetched, which is named after the title in its embed
+func (m *YoutubeLiveStreamMatcher) resumeRecording(s *discord.Session, taskKey string, videoID string) {
+	if _, err := m.cache.Get(m.ctx, fmt.Sprintf(youtube.CacheKeyRecordingFormat, videoID)); err != nil {
+		return
+	}
+
+	logger := s.Logger.With(zap.String("videoID", videoID))
+
+	embed, err := getTaskEmbed(s, m.cacheKeyPrefix, taskKey)
+	if err != nil {
+		logger.With(zap.Error(err), zap.String("key", taskKey)).Warn("Failed to get embed")
+		return
+	}
+
+	video := &youtube.Video{
+		ID:     videoID,
+		Title:  embed.Title,
+		IsDone: true,
+	}
+
+	if !startRecording(videoID) {
+		// The task that is recording the video resumes it if the bot stops again
+		logger.Info("Video is already being recorded")
+		if err := m.cache.Clear(m.ctx, taskKey); err != nil {
+			logger.With(zap.Error(err)).Error("Failed to clear cache key")
+		}
+		return
+	}
+
+	done := make(chan struct{})
+
+	m.wg.Add(2)
+	go m.recordLiveStream(video, &liveStreamEmbed{embed: embed}, logger, done)
+	go func() {
+		defer m.wg.Done()
+		m.waitForRecording(taskKey, videoID, done, logger)
+	}()
+
+	if err := m.cache.Clear(m.ctx, taskKey); err != nil {
+		logger.With(zap.Error(err)).Error("Failed to clear cache key")
+	}
+}
+
+// waitForRecording blocks until the recording is done. If the bot stops before then, the task is saved so that
+// the recording is resumed after a restart. recordingDone may be nil if nothing is being recorded.
+func (m *YoutubeLiveStreamMatcher) waitForRecording(cacheKey string, videoID string, recordingDone <-chan struct{}, logger *zap.SugaredLogger) {
+	if recordingDone == nil {
+		return
+	}
+
+	select {
+	case <-recordingDone:
+	case <-m.ctx.Done():
+		// Cannot use ctx here since it has already been cancelled
+		if err := m.cache.Set(context.Background(), cacheKey, videoID); err != nil {
+			logger.With(zap.Error(err)).Error("Failed to write to cache")
+		}
+	}
+}
+
+func (m *YoutubeLiveStreamMatcher) loadRecording(cacheKey string) (*hls.Recording, error) {
+	val, err := m.cache.Get(m.ctx, cacheKey)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &hls.Recording{}
+	if err := json.Unmarshal([]byte(fmt.Sprintf("%v", val)), rec); err != nil {
+		return nil, err
+	}
+
+	return rec, nil
+}
+
+func (m *YoutubeLiveStreamMatcher) saveRecording(ctx context.Context, cacheKey string, rec *hls.Recording) error {
+	recBytes, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	return m.cache.Set(ctx, cacheKey, recBytes)
+}
+
+func (m *YoutubeLiveStreamMatcher) clearRecording(cacheKey string, logger *zap.SugaredLogger) {
+	// Cannot use m.ctx here since it may have already been cancelled
+	if err := m.cache.Clear(context.Background(), cacheKey); err != nil {
+		logger.With(zap.Error(err)).Error("Failed to clear recording")
+	}
+}
+
+func youtubeRecordingFileName(video *youtube.Video, runNo int, numRuns int) string {
+	startTime := time.Now()
+	if video.LiveStreamingDetails != nil && !video.LiveStreamingDetails.ActualStartTime.IsZero() {
+		startTime = video.LiveStreamingDetails.ActualStartTime
+	}
+
+	title := strings.NewReplacer("/", "_", `\`, "_").Replace(video.Title)
+	fileName := fmt.Sprintf("%s %s [%s]", startTime.Format("2006-01-02"), title, video.ID)
+	if numRuns > 1 {
+		fileName = fmt.Sprintf("%s_%v", fileName, runNo+1)
+	}
+
+	return fileName + ".ts"
+}
@@ -0,0 +1,64 @@
+package matcher
+
+import (
+	"context"
+
+	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/ytdlp"
+	"go.uber.org/zap"
+)
+
+// YtdlpMatcher embeds links of any site yt-dlp supports. Its regexes should match whole URLs.
+type YtdlpMatcher struct {
+	GenericMatcher
+
+	siteCfg *config.YtdlpSiteConfig
+}
+
+// NewYtdlpMatcher returns a constructor for the site, since sites are only known from the config
+func NewYtdlpMatcher(siteCfg *config.YtdlpSiteConfig) Constructor {
+	return func(cfg *config.Config, s *discord.Session) (Matcher, error) {
+		return &YtdlpMatcher{
+			siteCfg: siteCfg,
+		}, nil
+	}
+}
+
+func (m *YtdlpMatcher) Handle(ctx context.Context, s *discord.MessageSession, matches []string) {
+	for _, url := range matches {
+		logger := s.Logger.With(
+			zap.String("url", url),
+		)
+
+		info, err := ytdlp.GetInfo(ctx, url, m.siteCfg.Args...)
+		if err != nil {
+			logger.With(zap.Error(err)).Error("Get info")
+			continue
+		}
+
+		s.SendEmbed(info.GetEmbed(&ytdlp.EmbedOptions{
+			SiteName: m.siteCfg.Name,
+			IconURL:  m.siteCfg.IconURL,
+			Color:    m.siteCfg.Color,
+		}))
+
+		if m.siteCfg.EmbedOnly {
+			continue
+		}
+
+		format := info.BestFormat(discord.GetMessageMaxBytes(s.GetGuildPremiumTier()))
+		if format == nil {
+			logger.Info("No format is small enough to send")
+			continue
+		}
+
+		video, err := ytdlp.Download(ctx, url, format, m.siteCfg.Args...)
+		if err != nil {
+			logger.With(zap.Error(err), zap.String("format", format.ID())).Error("Download video")
+			continue
+		}
+
+		s.SendVideo(video, info.ID)
+	}
+}
@@ -0,0 +1,254 @@
+package pixiv
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/xIceArcher/go-leah/config"
+	httpclient "github.com/xIceArcher/go-leah/http"
+	"github.com/xIceArcher/go-leah/utils"
+)
+
+const (
+	ajaxURL = "https://www.pixiv.net/ajax/"
+
+	// Ugoira archives are only frames of a short animation
+	maxUgoiraZipBytes = 100 * 1024 * 1024
+)
+
+var (
+	ErrNotFound error = fmt.Errorf("not found")
+	ErrTooLarge error = fmt.Errorf("too large")
+)
+
+type API struct {
+	// Images on pximg.net are only served with a pixiv Referer
+	client *retryablehttp.Client
+}
+
+func NewAPI(cfg *config.PixivConfig) *API {
+	headers := map[string]string{
+		"Referer":    "https://www.pixiv.net/",
+		"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	}
+	if cfg != nil && cfg.SessionID != "" {
+		headers["Cookie"] = fmt.Sprintf("PHPSESSID=%s", cfg.SessionID)
+	}
+
+	return &API{
+		client: httpclient.NewClientWithHeaders(headers),
+	}
+}
+
+func (a *API) GetArtwork(id string) (*Artwork, error) {
+	rawIllust := &RawIllust{}
+	if err := a.getAjax(fmt.Sprintf("illust/%s", id), rawIllust); err != nil {
+		return nil, err
+	}
+
+	rawPages := []*RawPage{
+		{
+			URLs:   rawIllust.URLs,
+			Width:  rawIllust.Width,
+			Height: rawIllust.Height,
+		},
+	}
+
+	if rawIllust.PageCount > 1 {
+		if err := a.getAjax(fmt.Sprintf("illust/%s/pages", id), &rawPages); err != nil {
+			return nil, err
+		}
+	}
+
+	return parseArtwork(rawIllust, rawPages), nil
+}
+
+func (a *API) GetUgoira(id string) (*Ugoira, error) {
+	rawUgoira := &RawUgoiraMeta{}
+	if err := a.getAjax(fmt.Sprintf("illust/%s/ugoira_meta", id), rawUgoira); err != nil {
+		return nil, err
+	}
+
+	return parseUgoira(rawUgoira), nil
+}
+
+// DownloadImage downloads an image from pximg.net, which Discord cannot do by itself
+func (a *API) DownloadImage(url string, maxBytes int64) ([]byte, error) {
+	resp, err := a.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP request to %s returned status %v", url, resp.StatusCode)
+	}
+	if resp.ContentLength > maxBytes {
+		return nil, ErrTooLarge
+	}
+
+	imageBytes, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(imageBytes)) > maxBytes {
+		return nil, ErrTooLarge
+	}
+
+	return imageBytes, nil
+}
+
+// DownloadUgoiraVideo converts the frames of the ugoira into an MP4 at outPath
+func (a *API) DownloadUgoiraVideo(ctx context.Context, ugoira *Ugoira, outPath string) error {
+	zipBytes, err := a.DownloadImage(ugoira.ZipURL, maxUgoiraZipBytes)
+	if err != nil {
+		return err
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	for _, f := range zipReader.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		if err := extractZipFile(f, dir); err != nil {
+			return err
+		}
+	}
+
+	frames := make([]*utils.VideoFrame, 0, len(ugoira.Frames))
+	for _, frame := range ugoira.Frames {
+		frames = append(frames, &utils.VideoFrame{
+			FilePath: filepath.Join(dir, filepath.Base(frame.FileName)),
+			Duration: frame.Delay,
+		})
+	}
+
+	return utils.FramesToVideo(ctx, frames, outPath)
+}
+
+// getAjax unmarshals the body of the response into v
+func (a *API) getAjax(path string, v any) error {
+	resp, err := a.client.Get(ajaxURL + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	rawResp := &RawAjaxResp{}
+	if err := json.Unmarshal(body, rawResp); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("HTTP request to %s returned status %v", path, resp.StatusCode)
+		}
+		return err
+	}
+
+	// Deleted artworks and R-18 artworks without a session are both returned as errors
+	if rawResp.Error || len(rawResp.Body) == 0 || string(rawResp.Body) == "null" {
+		return fmt.Errorf("%w: %s", ErrNotFound, rawResp.Message)
+	}
+
+	return json.Unmarshal(rawResp.Body, v)
+}
+
+func extractZipFile(f *zip.File, dir string) error {
+	// Only the base name is used so that the archive cannot write outside of dir
+	out, err := os.Create(filepath.Join(dir, filepath.Base(f.Name)))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	in, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+func parseArtwork(rawIllust *RawIllust, rawPages []*RawPage) *Artwork {
+	artwork := &Artwork{
+		ID:          rawIllust.IllustID,
+		Title:       rawIllust.IllustTitle,
+		Description: stripHTML(rawIllust.Description),
+		Type:        rawIllust.IllustType,
+		XRestrict:   rawIllust.XRestrict,
+		Timestamp:   rawIllust.CreateDate,
+
+		Artist: &Artist{
+			ID:   rawIllust.UserID,
+			Name: rawIllust.UserName,
+		},
+
+		PageCount: rawIllust.PageCount,
+	}
+
+	for _, tag := range rawIllust.Tags.Tags {
+		artwork.Tags = append(artwork.Tags, tag.Tag)
+	}
+
+	for _, rawPage := range rawPages {
+		if rawPage.URLs.Regular == "" {
+			continue
+		}
+
+		artwork.Pages = append(artwork.Pages, &Page{
+			URL:         rawPage.URLs.Regular,
+			OriginalURL: rawPage.URLs.Original,
+			Width:       rawPage.Width,
+			Height:      rawPage.Height,
+		})
+	}
+
+	return artwork
+}
+
+func parseUgoira(rawUgoira *RawUgoiraMeta) *Ugoira {
+	zipURL := rawUgoira.OriginalSrc
+	if zipURL == "" {
+		zipURL = rawUgoira.Src
+	}
+
+	ugoira := &Ugoira{
+		ZipURL: zipURL,
+	}
+
+	for _, frame := range rawUgoira.Frames {
+		ugoira.Frames = append(ugoira.Frames, &UgoiraFrame{
+			FileName: frame.File,
+			Delay:    time.Duration(frame.Delay) * time.Millisecond,
+		})
+	}
+
+	return ugoira
+}
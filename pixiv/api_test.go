@@ -0,0 +1,129 @@
+package pixiv
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rawIllustJSON = `{
+	"illustId": "123",
+	"illustTitle": "Title",
+	"description": "Line one<br />Line &amp; two <a href=\"https://example.com\">link</a>",
+	"illustType": 0,
+	"createDate": "2024-05-01T12:00:00+00:00",
+	"userId": "456",
+	"userName": "Artist",
+	"pageCount": 2,
+	"xRestrict": 1,
+	"width": 100,
+	"height": 200,
+	"urls": {
+		"original": "https://i.pximg.net/img-original/img/123_p0.png",
+		"regular": "https://i.pximg.net/img-master/img/123_p0_master1200.jpg"
+	},
+	"tags": {"tags": [{"tag": "オリジナル"}, {"tag": "girl"}]}
+}`
+
+const rawPagesJSON = `[
+	{"urls": {"original": "https://i.pximg.net/img-original/img/123_p0.png", "regular": "https://i.pximg.net/img-master/img/123_p0_master1200.jpg"}, "width": 100, "height": 200},
+	{"urls": {"original": "https://i.pximg.net/img-original/img/123_p1.png", "regular": "https://i.pximg.net/img-master/img/123_p1_master1200.jpg"}, "width": 300, "height": 400},
+	{"urls": {}, "width": 0, "height": 0}
+]`
+
+func TestParseArtwork(t *testing.T) {
+	rawIllust := &RawIllust{}
+	require.NoError(t, json.Unmarshal([]byte(rawIllustJSON), rawIllust))
+
+	rawPages := []*RawPage{}
+	require.NoError(t, json.Unmarshal([]byte(rawPagesJSON), &rawPages))
+
+	artwork := parseArtwork(rawIllust, rawPages)
+
+	assert.Equal(t, "123", artwork.ID)
+	assert.Equal(t, "Title", artwork.Title)
+	assert.Equal(t, "Line one\nLine & two link", artwork.Description)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), artwork.Timestamp.UTC())
+	assert.Equal(t, "https://www.pixiv.net/artworks/123", artwork.URL())
+	assert.Equal(t, "https://www.pixiv.net/users/456", artwork.Artist.URL())
+	assert.Equal(t, "Artist", artwork.Artist.Name)
+	assert.Equal(t, []string{"オリジナル", "girl"}, artwork.Tags)
+	assert.True(t, artwork.IsR18())
+	assert.False(t, artwork.IsUgoira())
+	assert.Equal(t, "R-18", artwork.Rating())
+
+	assert.Equal(t, 2, artwork.PageCount)
+	require.Len(t, artwork.Pages, 2)
+	assert.Equal(t, "https://i.pximg.net/img-master/img/123_p1_master1200.jpg", artwork.Pages[1].URL)
+	assert.Equal(t, "https://i.pximg.net/img-original/img/123_p1.png", artwork.Pages[1].OriginalURL)
+	assert.Equal(t, 300, artwork.Pages[1].Width)
+	assert.Equal(t, 400, artwork.Pages[1].Height)
+}
+
+func TestParseUgoira(t *testing.T) {
+	rawUgoira := &RawUgoiraMeta{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"src": "https://i.pximg.net/img-zip-ugoira/img/123_ugoira600x600.zip",
+		"originalSrc": "https://i.pximg.net/img-zip-ugoira/img/123_ugoira1920x1080.zip",
+		"frames": [{"file": "000000.jpg", "delay": 100}, {"file": "000001.jpg", "delay": 250}]
+	}`), rawUgoira))
+
+	ugoira := parseUgoira(rawUgoira)
+
+	assert.Equal(t, "https://i.pximg.net/img-zip-ugoira/img/123_ugoira1920x1080.zip", ugoira.ZipURL)
+	assert.Equal(t, []*UgoiraFrame{
+		{FileName: "000000.jpg", Delay: 100 * time.Millisecond},
+		{FileName: "000001.jpg", Delay: 250 * time.Millisecond},
+	}, ugoira.Frames)
+
+	rawUgoira.OriginalSrc = ""
+	assert.Equal(t, rawUgoira.Src, parseUgoira(rawUgoira).ZipURL)
+}
+
+func TestStripHTML(t *testing.T) {
+	assert.Equal(t, "", stripHTML(""))
+	assert.Equal(t, "a\nb\nc", stripHTML("a<br>b<BR/>c"))
+	assert.Equal(t, "<tag> & \"quote\"", stripHTML("&lt;tag&gt; &amp; &quot;quote&quot;"))
+	assert.Equal(t, "bold", stripHTML("  <strong>bold</strong>\n"))
+}
+
+func TestGetEmbeds(t *testing.T) {
+	artwork := &Artwork{
+		ID:        "123",
+		Title:     "Title",
+		XRestrict: XRestrictR18G,
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Artist:    &Artist{ID: "456", Name: "Artist"},
+		Tags:      []string{"a b"},
+		PageCount: 30,
+	}
+	for i := 0; i < artwork.PageCount; i++ {
+		artwork.Pages = append(artwork.Pages, &Page{URL: fmt.Sprintf("https://i.pximg.net/%v.jpg", i)})
+	}
+
+	embeds := artwork.GetEmbeds()
+	require.Len(t, embeds, MAX_PAGES_PER_ARTWORK)
+
+	assert.Equal(t, "[R-18G] Title", embeds[0].Title)
+	assert.Equal(t, "https://i.pximg.net/0.jpg", embeds[0].Image.URL)
+	assert.Equal(t, "[#a b](https://www.pixiv.net/tags/a%20b/artworks)", embeds[0].Fields[0].Value)
+	assert.Equal(t, "30", embeds[0].Fields[1].Value)
+
+	assert.Equal(t, "https://www.pixiv.net/artworks/123", embeds[3].URL)
+	assert.Equal(t, "https://www.pixiv.net/artworks/123?s=1", embeds[4].URL)
+	assert.Equal(t, "https://www.pixiv.net/artworks/123?s=4", embeds[19].URL)
+
+	// Only the last gallery has a footer
+	for i, embed := range embeds {
+		if i == 16 {
+			assert.NotNil(t, embed.Footer)
+			assert.Equal(t, "2024-05-01T12:00:00Z", embed.Timestamp)
+		} else {
+			assert.Nil(t, embed.Footer)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package pixiv
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/xIceArcher/go-leah/consts"
+	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/utils"
+)
+
+const (
+	MAX_EMBEDS_PER_POST = 4
+
+	// Artworks can have up to 200 pages, the rest are only linked
+	MAX_PAGES_PER_ARTWORK = 20
+)
+
+var (
+	pixivFooter = &discordgo.MessageEmbedFooter{
+		Text:    "pixiv",
+		IconURL: "https://s.pximg.net/common/images/apple-touch-icon.png",
+	}
+)
+
+// GetEmbed returns the embed of the artwork without any of its pages
+func (a *Artwork) GetEmbed() *discordgo.MessageEmbed {
+	title := a.Title
+	if rating := a.Rating(); rating != "" {
+		title = fmt.Sprintf("[%s] %s", rating, title)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		URL:   a.URL(),
+		Title: title,
+		Author: &discordgo.MessageEmbedAuthor{
+			Name: a.Artist.Name,
+			URL:  a.Artist.URL(),
+		},
+		Description: (&utils.TextWithEntities{Text: a.Description}).GetReplacedText(4096, 1)[0],
+		Color:       utils.ParseHexColor(consts.ColorPixiv),
+		Footer:      pixivFooter,
+	}
+
+	if !a.Timestamp.IsZero() {
+		embed.Timestamp = a.Timestamp.Format(time.RFC3339)
+	}
+
+	if tags := a.getTagsText(); tags != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Tags",
+			Value: tags,
+		})
+	}
+
+	if a.PageCount > 1 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Pages",
+			Value:  strconv.Itoa(a.PageCount),
+			Inline: true,
+		})
+	}
+
+	return embed
+}
+
+// GetEmbeds returns the embed of the artwork followed by a gallery of its pages
+func (a *Artwork) GetEmbeds() (embeds []*discordgo.MessageEmbed) {
+	embeds = append(embeds, a.GetEmbed())
+
+	footerEmbedIdx := 0
+	embeds[0].Footer = nil
+
+	for i, page := range a.Pages[:min(len(a.Pages), MAX_PAGES_PER_ARTWORK)] {
+		if i == 0 {
+			embeds[0].Image = &discordgo.MessageEmbedImage{
+				URL: page.URL,
+			}
+			continue
+		}
+
+		embedURL := a.URL()
+		if i >= MAX_EMBEDS_PER_POST {
+			embedURL += fmt.Sprintf("?s=%v", i/MAX_EMBEDS_PER_POST)
+		}
+
+		if i%MAX_EMBEDS_PER_POST == 0 {
+			footerEmbedIdx += MAX_EMBEDS_PER_POST
+		}
+
+		embeds = append(embeds, &discordgo.MessageEmbed{
+			URL: embedURL,
+			Image: &discordgo.MessageEmbedImage{
+				URL: page.URL,
+			},
+			Color: utils.ParseHexColor(consts.ColorPixiv),
+		})
+	}
+
+	embeds[footerEmbedIdx].Footer = pixivFooter
+	embeds[footerEmbedIdx].Timestamp = embeds[0].Timestamp
+
+	return embeds
+}
+
+// getTagsText links as many tags as fit in an embed field
+func (a *Artwork) getTagsText() string {
+	tagLinks := make([]string, 0, len(a.Tags))
+	length := 0
+
+	for _, tag := range a.Tags {
+		tagLink := discord.GetNamedLink("#"+tag, fmt.Sprintf("https://www.pixiv.net/tags/%s/artworks", url.PathEscape(tag)))
+
+		// Fields are limited to 1024 characters, including the spaces between tags
+		length += len([]rune(tagLink)) + 1
+		if length > 1024 {
+			break
+		}
+
+		tagLinks = append(tagLinks, tagLink)
+	}
+
+	return strings.Join(tagLinks, " ")
+}
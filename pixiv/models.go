@@ -0,0 +1,151 @@
+package pixiv
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type IllustType int
+
+const (
+	IllustTypeIllust IllustType = 0
+	IllustTypeManga  IllustType = 1
+	IllustTypeUgoira IllustType = 2
+)
+
+type XRestrict int
+
+const (
+	XRestrictAll  XRestrict = 0
+	XRestrictR18  XRestrict = 1
+	XRestrictR18G XRestrict = 2
+)
+
+type RawAjaxResp struct {
+	Error   bool            `json:"error"`
+	Message string          `json:"message"`
+	Body    json.RawMessage `json:"body"`
+}
+
+type RawIllust struct {
+	IllustID    string     `json:"illustId"`
+	IllustTitle string     `json:"illustTitle"`
+	Description string     `json:"description"`
+	IllustType  IllustType `json:"illustType"`
+	CreateDate  time.Time  `json:"createDate"`
+	UserID      string     `json:"userId"`
+	UserName    string     `json:"userName"`
+	PageCount   int        `json:"pageCount"`
+	XRestrict   XRestrict  `json:"xRestrict"`
+	Width       int        `json:"width"`
+	Height      int        `json:"height"`
+	URLs        RawURLs    `json:"urls"`
+
+	Tags struct {
+		Tags []struct {
+			Tag string `json:"tag"`
+		} `json:"tags"`
+	} `json:"tags"`
+}
+
+type RawURLs struct {
+	Original string `json:"original"`
+	Regular  string `json:"regular"`
+}
+
+type RawPage struct {
+	URLs   RawURLs `json:"urls"`
+	Width  int     `json:"width"`
+	Height int     `json:"height"`
+}
+
+type RawUgoiraMeta struct {
+	Src         string `json:"src"`
+	OriginalSrc string `json:"originalSrc"`
+	Frames      []struct {
+		File  string `json:"file"`
+		Delay int    `json:"delay"`
+	} `json:"frames"`
+}
+
+type Artwork struct {
+	ID          string
+	Title       string
+	Description string
+	Type        IllustType
+	XRestrict   XRestrict
+	Timestamp   time.Time
+
+	Artist *Artist
+	Tags   []string
+
+	PageCount int
+	Pages     []*Page
+}
+
+func (a *Artwork) URL() string {
+	return fmt.Sprintf("https://www.pixiv.net/artworks/%s", a.ID)
+}
+
+func (a *Artwork) IsR18() bool {
+	return a.XRestrict != XRestrictAll
+}
+
+func (a *Artwork) IsUgoira() bool {
+	return a.Type == IllustTypeUgoira
+}
+
+// Rating returns the age restriction shown on pixiv, or an empty string for all-ages artworks
+func (a *Artwork) Rating() string {
+	switch a.XRestrict {
+	case XRestrictR18:
+		return "R-18"
+	case XRestrictR18G:
+		return "R-18G"
+	default:
+		return ""
+	}
+}
+
+type Artist struct {
+	ID   string
+	Name string
+}
+
+func (a *Artist) URL() string {
+	return fmt.Sprintf("https://www.pixiv.net/users/%s", a.ID)
+}
+
+type Page struct {
+	// URL is the downscaled image shown on pixiv, which is small enough to be uploaded
+	URL         string
+	OriginalURL string
+	Width       int
+	Height      int
+}
+
+type Ugoira struct {
+	ZipURL string
+	Frames []*UgoiraFrame
+}
+
+type UgoiraFrame struct {
+	FileName string
+	Delay    time.Duration
+}
+
+var (
+	htmlLineBreakRegex = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlTagRegex       = regexp.MustCompile(`<[^>]+>`)
+)
+
+// stripHTML converts the HTML of a description to plain text
+func stripHTML(s string) string {
+	s = htmlLineBreakRegex.ReplaceAllString(s, "\n")
+	s = htmlTagRegex.ReplaceAllString(s, "")
+	return strings.TrimSpace(html.UnescapeString(s))
+}
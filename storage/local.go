@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/progress"
+	"golang.org/x/exp/slices"
+)
+
+type LocalStorage struct {
+	path string
+}
+
+func NewLocalStorage(cfg *config.LocalStorageConfig) (Storage, error) {
+	if err := os.MkdirAll(cfg.Path, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	return &LocalStorage{
+		path: cfg.Path,
+	}, nil
+}
+
+func (s *LocalStorage) Exists(fileName string) (bool, error) {
+	filePath, err := s.resolve(fileName)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.Stat(filePath)
+	if err == nil {
+		return true, nil
+	} else if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	return false, err
+}
+
+func (s *LocalStorage) CreateDir(dirName string) error {
+	dirPath, err := s.resolve(dirName)
+	if err != nil {
+		return err
+	}
+
+	return os.MkdirAll(dirPath, os.ModePerm)
+}
+
+func (s *LocalStorage) UploadMany(dirName string, filePaths []string, bar ...progress.Bar) error {
+	dirPath, err := s.resolve(dirName)
+	if err != nil {
+		return err
+	}
+
+	progressBar := getProgressBar(bar)
+
+	fileInfos, totalBytes, err := getFileSizes(filePaths)
+	if err != nil {
+		return err
+	}
+
+	if progressBar != nil {
+		progressBar.SetMax(totalBytes)
+	}
+
+	for _, fileInfo := range fileInfos {
+		if err := func() error {
+			in, err := os.Open(fileInfo.Path)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+
+			_, err = s.writeFile(filepath.Join(dirPath, filepath.Base(fileInfo.Path)), newProgressReader(in, progressBar))
+			return err
+		}(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *LocalStorage) UploadAndConcat(fileName string, filePaths []string, bar ...progress.Bar) (int64, error) {
+	filePath, err := s.resolve(fileName)
+	if err != nil {
+		return 0, err
+	}
+
+	progressBar := getProgressBar(bar)
+
+	fileInfos, totalBytes, err := getFileSizes(filePaths)
+	if err != nil {
+		return 0, err
+	}
+
+	if progressBar != nil {
+		progressBar.SetMax(totalBytes)
+	}
+
+	in := newMultiFileReader(fileInfos)
+	defer in.Close()
+
+	size, err := s.writeFile(filePath, newProgressReader(in, progressBar))
+	if err != nil {
+		return 0, err
+	}
+
+	if size != totalBytes {
+		return 0, ErrFailed
+	}
+
+	return size, nil
+}
+
+// resolve returns the path of name under the storage root.
+// Names come from users, so anything that could point outside of the root is rejected.
+func (s *LocalStorage) resolve(name string) (string, error) {
+	if filepath.IsAbs(name) || slices.Contains(strings.Split(filepath.ToSlash(name), "/"), "..") {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPath, name)
+	}
+
+	root := filepath.Clean(s.path)
+	resolved := filepath.Join(root, name)
+	if rel, err := filepath.Rel(root, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPath, name)
+	}
+
+	return resolved, nil
+}
+
+// writeFile writes to a temporary file first so that a failed copy never leaves a partial file behind
+func (s *LocalStorage) writeFile(filePath string, r io.Reader) (int64, error) {
+	out, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.part")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(out.Name())
+
+	size, err := io.Copy(out, r)
+	if err != nil {
+		out.Close()
+		return 0, err
+	}
+
+	if err := out.Close(); err != nil {
+		return 0, err
+	}
+
+	return size, os.Rename(out.Name(), filePath)
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xIceArcher/go-leah/config"
+)
+
+func TestLocalStorage(t *testing.T) {
+	srcDir := t.TempDir()
+	filePaths := make([]string, 0)
+	for i, content := range []string{"abc", "", "defg"} {
+		filePath := filepath.Join(srcDir, string(rune('a'+i))+".ts")
+		require.NoError(t, os.WriteFile(filePath, []byte(content), 0o644))
+		filePaths = append(filePaths, filePath)
+	}
+
+	s, err := NewLocalStorage(&config.LocalStorageConfig{Path: filepath.Join(t.TempDir(), "storage")})
+	require.NoError(t, err)
+
+	exists, err := s.Exists("out.ts")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	size, err := s.UploadAndConcat("out.ts", filePaths)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), size)
+
+	exists, err = s.Exists("out.ts")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, s.CreateDir("dir"))
+	require.NoError(t, s.UploadMany("dir", filePaths))
+
+	for _, fileName := range []string{"a.ts", "b.ts", "c.ts"} {
+		exists, err := s.Exists(filepath.Join("dir", fileName))
+		require.NoError(t, err)
+		assert.True(t, exists)
+	}
+}
+
+func TestLocalStorageRejectsPathsOutsideRoot(t *testing.T) {
+	srcDir := t.TempDir()
+	filePath := filepath.Join(srcDir, "a.ts")
+	require.NoError(t, os.WriteFile(filePath, []byte("abc"), 0o644))
+
+	parentDir := t.TempDir()
+	s, err := NewLocalStorage(&config.LocalStorageConfig{Path: filepath.Join(parentDir, "storage")})
+	require.NoError(t, err)
+
+	for _, name := range []string{"../out.ts", "dir/../../out.ts", "..", filepath.Join(parentDir, "out.ts")} {
+		_, err := s.UploadAndConcat(name, []string{filePath})
+		assert.ErrorIs(t, err, ErrInvalidPath, name)
+
+		assert.ErrorIs(t, s.CreateDir(name), ErrInvalidPath, name)
+		assert.ErrorIs(t, s.UploadMany(name, []string{filePath}), ErrInvalidPath, name)
+
+		_, err = s.Exists(name)
+		assert.ErrorIs(t, err, ErrInvalidPath, name)
+	}
+
+	_, err = os.Stat(filepath.Join(parentDir, "out.ts"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// Names that only look like they contain .. are fine
+	_, err = s.UploadAndConcat("a..b.ts", []string{filePath})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"path/filepath"
+
+	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/progress"
+	"github.com/xIceArcher/go-leah/qnap"
+	"go.uber.org/zap"
+)
+
+type QNAPStorage struct {
+	cfg    *config.QNAPConfig
+	logger *zap.SugaredLogger
+}
+
+func NewQNAPStorage(cfg *config.QNAPConfig, logger *zap.SugaredLogger) Storage {
+	return &QNAPStorage{
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+func (s *QNAPStorage) Exists(fileName string) (exists bool, err error) {
+	err = s.withAPI(func(api qnap.API) error {
+		exists, err = api.Exists(s.cfg.DownloadBasePath, fileName)
+		return err
+	})
+	return exists, err
+}
+
+func (s *QNAPStorage) CreateDir(dirName string) error {
+	return s.withAPI(func(api qnap.API) error {
+		return api.CreateDir(s.cfg.DownloadBasePath, dirName)
+	})
+}
+
+func (s *QNAPStorage) UploadMany(dirName string, filePaths []string, progressBar ...progress.Bar) error {
+	return s.withAPI(func(api qnap.API) error {
+		return api.UploadMany(filepath.Join(s.cfg.DownloadBasePath, dirName), filePaths, progressBar...)
+	})
+}
+
+func (s *QNAPStorage) UploadAndConcat(fileName string, filePaths []string, progressBar ...progress.Bar) (size int64, err error) {
+	err = s.withAPI(func(api qnap.API) error {
+		size, err = api.UploadAndConcat(s.cfg.DownloadBasePath, fileName, filePaths, progressBar...)
+		return err
+	})
+	return size, err
+}
+
+// withAPI logs in for the duration of f, since QNAP sessions expire during long downloads
+func (s *QNAPStorage) withAPI(f func(api qnap.API) error) error {
+	api, err := qnap.New(s.cfg.URL, s.logger)
+	if err != nil {
+		return err
+	}
+
+	if err := api.Login(s.cfg.Username, s.cfg.Password); err != nil {
+		return err
+	}
+	defer api.Logout()
+
+	return f(api)
+}
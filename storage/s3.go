@@ -0,0 +1,479 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/progress"
+	"go.uber.org/zap"
+)
+
+const (
+	// S3 requires every part except the last to be at least 5 MiB
+	s3PartSize       = 16 * units.MiB
+	s3MaxPartRetries = 5
+
+	s3DefaultRegion   = "us-east-1"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3TimeFormat      = "20060102T150405Z"
+	s3DateFormat      = "20060102"
+)
+
+// S3Storage stores files in a bucket of any S3-compatible service, such as AWS S3 or MinIO
+type S3Storage struct {
+	cfg      *config.S3StorageConfig
+	endpoint *url.URL
+	signer   *s3Signer
+
+	client *http.Client
+	logger *zap.SugaredLogger
+}
+
+func NewS3Storage(cfg *config.S3StorageConfig, logger *zap.SugaredLogger) (Storage, error) {
+	region := cfg.Region
+	if region == "" {
+		region = s3DefaultRegion
+	}
+
+	endpointStr := cfg.Endpoint
+	if endpointStr == "" {
+		endpointStr = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+
+	endpoint, err := url.Parse(endpointStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Storage{
+		cfg:      cfg,
+		endpoint: endpoint,
+		signer: &s3Signer{
+			accessKeyID:     cfg.AccessKeyID,
+			secretAccessKey: cfg.SecretAccessKey,
+			region:          region,
+		},
+
+		client: &http.Client{},
+		logger: logger,
+	}, nil
+}
+
+func (s *S3Storage) Exists(fileName string) (bool, error) {
+	resp, err := s.do(http.MethodHead, fileName, nil, nil, 0)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("HTTP request to %s returned status %v", resp.Request.URL.String(), resp.StatusCode)
+	}
+}
+
+// CreateDir does nothing since S3 has no directories, objects are created under their full key when uploaded
+func (s *S3Storage) CreateDir(dirName string) error {
+	return nil
+}
+
+func (s *S3Storage) UploadMany(dirName string, filePaths []string, bar ...progress.Bar) error {
+	progressBar := getProgressBar(bar)
+
+	fileInfos, totalBytes, err := getFileSizes(filePaths)
+	if err != nil {
+		return err
+	}
+
+	if progressBar != nil {
+		progressBar.SetMax(totalBytes)
+	}
+
+	logger := s.logger.With(zap.String("dirName", dirName))
+	logger.Infof("Uploading file of size %v...", units.HumanSize(float64(totalBytes)))
+
+	for i, fileInfo := range fileInfos {
+		if err := func() error {
+			f, err := os.Open(fileInfo.Path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			resp, err := s.do(http.MethodPut, path.Join(dirName, path.Base(fileInfo.Path)), nil, newProgressReader(f, progressBar), fileInfo.NumBytes)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+
+			return checkS3Response(resp)
+		}(); err != nil {
+			return err
+		}
+
+		logger.Infof("Uploaded file %v/%v", i+1, len(fileInfos))
+	}
+
+	return nil
+}
+
+func (s *S3Storage) UploadAndConcat(fileName string, filePaths []string, bar ...progress.Bar) (int64, error) {
+	progressBar := getProgressBar(bar)
+
+	fileInfos, totalBytes, err := getFileSizes(filePaths)
+	if err != nil {
+		return 0, err
+	}
+
+	if progressBar != nil {
+		progressBar.SetMax(totalBytes)
+	}
+
+	logger := s.logger.With(zap.String("fileName", fileName))
+	logger.Infof("Uploading file of size %v...", units.HumanSize(float64(totalBytes)))
+
+	uploadID, err := s.createMultipartUpload(fileName)
+	if err != nil {
+		return 0, err
+	}
+
+	parts, err := s.uploadParts(fileName, uploadID, newMultiFileReader(fileInfos), progressBar, logger)
+	if err != nil {
+		if abortErr := s.abortMultipartUpload(fileName, uploadID); abortErr != nil {
+			logger.With(zap.Error(abortErr)).Warn("Failed to abort multipart upload")
+		}
+		return 0, err
+	}
+
+	if err := s.completeMultipartUpload(fileName, uploadID, parts); err != nil {
+		return 0, err
+	}
+
+	return totalBytes, nil
+}
+
+type s3InitiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+	Parts   []*s3UploadedPart `xml:"Part"`
+}
+
+type s3UploadedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (s *S3Storage) createMultipartUpload(fileName string) (string, error) {
+	resp, err := s.do(http.MethodPost, fileName, url.Values{"uploads": {""}}, nil, 0)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if err := checkS3Response(resp); err != nil {
+		return "", err
+	}
+
+	result := &s3InitiateMultipartUploadResult{}
+	if err := xml.NewDecoder(resp.Body).Decode(result); err != nil {
+		return "", err
+	}
+
+	return result.UploadID, nil
+}
+
+func (s *S3Storage) uploadParts(fileName string, uploadID string, r *multiFileReader, progressBar progress.Bar, logger *zap.SugaredLogger) ([]*s3UploadedPart, error) {
+	defer r.Close()
+
+	parts := make([]*s3UploadedPart, 0)
+	buf := make([]byte, s3PartSize)
+
+	for partNumber := 1; ; partNumber++ {
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF && partNumber > 1 {
+			break
+		} else if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+
+		query := url.Values{
+			"partNumber": {strconv.Itoa(partNumber)},
+			"uploadId":   {uploadID},
+		}
+
+		var etag string
+		for numRetries := 0; ; numRetries++ {
+			etag, err = func() (string, error) {
+				resp, err := s.do(http.MethodPut, fileName, query, bytes.NewReader(buf[:n]), int64(n))
+				if err != nil {
+					return "", err
+				}
+				defer resp.Body.Close()
+
+				if err := checkS3Response(resp); err != nil {
+					return "", err
+				}
+				return resp.Header.Get("ETag"), nil
+			}()
+			if err == nil {
+				break
+			}
+
+			if numRetries >= s3MaxPartRetries {
+				return nil, err
+			}
+			logger.With(zap.Error(err), zap.Int("numRetries", numRetries)).Warn("Error uploading part")
+		}
+
+		parts = append(parts, &s3UploadedPart{
+			PartNumber: partNumber,
+			ETag:       etag,
+		})
+
+		if progressBar != nil {
+			progressBar.Add(int64(n))
+		}
+		logger.Infof("Uploaded part %v", partNumber)
+
+		if n < len(buf) {
+			break
+		}
+	}
+
+	return parts, nil
+}
+
+func (s *S3Storage) completeMultipartUpload(fileName string, uploadID string, parts []*s3UploadedPart) error {
+	body, err := xml.Marshal(&s3CompleteMultipartUpload{
+		Parts: parts,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(http.MethodPost, fileName, url.Values{"uploadId": {uploadID}}, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkS3Response(resp); err != nil {
+		return err
+	}
+
+	// Completing an upload can fail even after a 200 response, in which case the body is an error
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Contains(respBody, []byte("<Error>")) {
+		s3Err := &s3Error{}
+		if err := xml.Unmarshal(respBody, s3Err); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s: %s", ErrFailed, s3Err.Code, s3Err.Message)
+	}
+
+	return nil
+}
+
+func (s *S3Storage) abortMultipartUpload(fileName string, uploadID string) error {
+	resp, err := s.do(http.MethodDelete, fileName, url.Values{"uploadId": {uploadID}}, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkS3Response(resp)
+}
+
+func (s *S3Storage) do(method string, fileName string, query url.Values, body io.Reader, contentLength int64) (*http.Response, error) {
+	// Otherwise empty bodies would be sent chunked, which S3 does not accept
+	if contentLength == 0 {
+		body = nil
+	}
+
+	req, err := http.NewRequest(method, s.objectURL(fileName, query).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = contentLength
+
+	s.signer.sign(req, s3UnsignedPayload, time.Now())
+	return s.client.Do(req)
+}
+
+func (s *S3Storage) objectURL(fileName string, query url.Values) *url.URL {
+	u := *s.endpoint
+
+	objectKey := strings.TrimPrefix(path.Join(s.cfg.Prefix, fileName), "/")
+	if s.cfg.UsePathStyle {
+		u.Path = path.Join("/", u.Path, s.cfg.Bucket, objectKey)
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+		u.Path = path.Join("/", u.Path, objectKey)
+	}
+
+	// Go escapes fewer characters than S3 expects, so the path is escaped here to match the signature
+	u.RawPath = s3EscapePath(u.Path)
+	u.RawQuery = s3CanonicalQuery(query)
+	return &u
+}
+
+// s3Signer signs requests with AWS Signature Version 4
+type s3Signer struct {
+	accessKeyID     string
+	secretAccessKey string
+	region          string
+}
+
+func (s *s3Signer) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzTime := now.Format(s3TimeFormat)
+	amzDate := now.Format(s3DateFormat)
+
+	req.Header.Set("X-Amz-Date", amzTime)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{
+		"host": host,
+	}
+	for name, values := range req.Header {
+		trimmedValues := make([]string, 0, len(values))
+		for _, value := range values {
+			trimmedValues = append(trimmedValues, strings.Join(strings.Fields(value), " "))
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmedValues, ",")
+	}
+
+	headerNames := make([]string, 0, len(headers))
+	for name := range headers {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headerNames {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{amzDate, s.region, "s3", "aws4_request"}, "/")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzTime,
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretAccessKey), amzDate)
+	signingKey = hmacSHA256(signingKey, s.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s,SignedHeaders=%s,Signature=%s", s.accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	params := make([]string, 0, len(query))
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+
+		for _, value := range values {
+			params = append(params, s3Escape(key, false)+"="+s3Escape(value, false))
+		}
+	}
+
+	return strings.Join(params, "&")
+}
+
+func s3EscapePath(p string) string {
+	return s3Escape(p, true)
+}
+
+// s3Escape percent-encodes every byte except unreserved characters, and slashes if it is a path
+func s3Escape(s string, isPath bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (isPath && c == '/') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func checkS3Response(resp *http.Response) error {
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+
+	s3Err := &s3Error{}
+	if err := xml.Unmarshal(body, s3Err); err == nil && s3Err.Code != "" {
+		return fmt.Errorf("HTTP request to %s returned status %v: %s: %s", resp.Request.URL.String(), resp.StatusCode, s3Err.Code, s3Err.Message)
+	}
+
+	return fmt.Errorf("HTTP request to %s returned status %v", resp.Request.URL.String(), resp.StatusCode)
+}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/progress"
+	"go.uber.org/zap"
+)
+
+const (
+	TypeQNAP   = "qnap"
+	TypeLocal  = "local"
+	TypeS3     = "s3"
+	TypeWebDAV = "webdav"
+)
+
+var (
+	ErrNotConfigured error = fmt.Errorf("no storage configured")
+	ErrUnknownType   error = fmt.Errorf("unknown storage type")
+	ErrFailed        error = fmt.Errorf("failed")
+	ErrInvalidPath   error = fmt.Errorf("invalid path")
+)
+
+// Storage is where downloaded files end up. All paths are relative to the root of the storage.
+type Storage interface {
+	Exists(fileName string) (bool, error)
+	CreateDir(dirName string) error
+	UploadMany(dirName string, filePaths []string, progressBar ...progress.Bar) error
+	UploadAndConcat(fileName string, filePaths []string, progressBar ...progress.Bar) (int64, error)
+}
+
+// New returns the storage selected in the config.
+// If no storage is configured, QNAP is used if it is enabled, otherwise ErrNotConfigured is returned.
+func New(cfg *config.Config, logger *zap.SugaredLogger) (Storage, error) {
+	storageType := ""
+	if cfg.Storage != nil {
+		storageType = cfg.Storage.Type
+	}
+
+	if storageType == "" {
+		if cfg.QNAP == nil || !cfg.QNAP.IsEnabled {
+			return nil, ErrNotConfigured
+		}
+		storageType = TypeQNAP
+	}
+
+	switch storageType {
+	case TypeQNAP:
+		if cfg.QNAP == nil {
+			return nil, fmt.Errorf("%w: missing qnap config", ErrNotConfigured)
+		}
+		return NewQNAPStorage(cfg.QNAP, logger), nil
+	case TypeLocal:
+		if cfg.Storage.Local == nil {
+			return nil, fmt.Errorf("%w: missing local storage config", ErrNotConfigured)
+		}
+		return NewLocalStorage(cfg.Storage.Local)
+	case TypeS3:
+		if cfg.Storage.S3 == nil {
+			return nil, fmt.Errorf("%w: missing s3 storage config", ErrNotConfigured)
+		}
+		return NewS3Storage(cfg.Storage.S3, logger)
+	case TypeWebDAV:
+		if cfg.Storage.WebDAV == nil {
+			return nil, fmt.Errorf("%w: missing webdav storage config", ErrNotConfigured)
+		}
+		return NewWebDAVStorage(cfg.Storage.WebDAV, logger)
+	default:
+		return nil, fmt.Errorf("%w %s", ErrUnknownType, storageType)
+	}
+}
+
+type fileInfo struct {
+	Path     string
+	NumBytes int64
+}
+
+func getFileSizes(filePaths []string) (fileInfos []*fileInfo, totalBytes int64, err error) {
+	for _, filePath := range filePaths {
+		fi, err := os.Stat(filePath)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		fileInfos = append(fileInfos, &fileInfo{
+			Path:     filePath,
+			NumBytes: fi.Size(),
+		})
+
+		totalBytes += fi.Size()
+	}
+
+	return
+}
+
+func getProgressBar(bar []progress.Bar) progress.Bar {
+	if len(bar) > 0 {
+		return bar[0]
+	}
+	return nil
+}
+
+// progressReader adds every byte read to the progress bar
+type progressReader struct {
+	io.Reader
+	bar progress.Bar
+}
+
+func newProgressReader(r io.Reader, bar progress.Bar) io.Reader {
+	if bar == nil {
+		return r
+	}
+
+	return &progressReader{
+		Reader: r,
+		bar:    bar,
+	}
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.bar.Add(int64(n))
+	return n, err
+}
+
+// multiFileReader reads the files one after another as if they were a single file
+type multiFileReader struct {
+	filePaths []string
+	curr      *os.File
+}
+
+func newMultiFileReader(fileInfos []*fileInfo) *multiFileReader {
+	filePaths := make([]string, 0, len(fileInfos))
+	for _, fileInfo := range fileInfos {
+		filePaths = append(filePaths, fileInfo.Path)
+	}
+
+	return &multiFileReader{
+		filePaths: filePaths,
+	}
+}
+
+func (r *multiFileReader) Read(p []byte) (int, error) {
+	for {
+		if r.curr == nil {
+			if len(r.filePaths) == 0 {
+				return 0, io.EOF
+			}
+
+			f, err := os.Open(r.filePaths[0])
+			if err != nil {
+				return 0, err
+			}
+
+			r.curr = f
+			r.filePaths = r.filePaths[1:]
+		}
+
+		n, err := r.curr.Read(p)
+		if err == io.EOF {
+			r.curr.Close()
+			r.curr = nil
+
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+
+		return n, err
+	}
+}
+
+func (r *multiFileReader) Close() error {
+	if r.curr == nil {
+		return nil
+	}
+
+	err := r.curr.Close()
+	r.curr = nil
+	return err
+}
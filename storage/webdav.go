@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+
+	"github.com/docker/go-units"
+	"github.com/xIceArcher/go-leah/config"
+	"github.com/xIceArcher/go-leah/progress"
+	"go.uber.org/zap"
+)
+
+const (
+	methodMkcol = "MKCOL"
+)
+
+type WebDAVStorage struct {
+	cfg     *config.WebDAVStorageConfig
+	baseURL *url.URL
+
+	client *http.Client
+	logger *zap.SugaredLogger
+}
+
+func NewWebDAVStorage(cfg *config.WebDAVStorageConfig, logger *zap.SugaredLogger) (Storage, error) {
+	baseURL, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebDAVStorage{
+		cfg:     cfg,
+		baseURL: baseURL,
+
+		client: &http.Client{},
+		logger: logger,
+	}, nil
+}
+
+func (s *WebDAVStorage) Exists(fileName string) (bool, error) {
+	resp, err := s.do(http.MethodHead, fileName, nil, 0)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("HTTP request to %s returned status %v", resp.Request.URL.String(), resp.StatusCode)
+	}
+}
+
+func (s *WebDAVStorage) CreateDir(dirName string) error {
+	resp, err := s.do(methodMkcol, dirName+"/", nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 405 means that the directory already exists
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("HTTP request to %s returned status %v", resp.Request.URL.String(), resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (s *WebDAVStorage) UploadMany(dirName string, filePaths []string, bar ...progress.Bar) error {
+	progressBar := getProgressBar(bar)
+
+	fileInfos, totalBytes, err := getFileSizes(filePaths)
+	if err != nil {
+		return err
+	}
+
+	if progressBar != nil {
+		progressBar.SetMax(totalBytes)
+	}
+
+	logger := s.logger.With(zap.String("dirName", dirName))
+	logger.Infof("Uploading file of size %v...", units.HumanSize(float64(totalBytes)))
+
+	for i, fileInfo := range fileInfos {
+		if err := func() error {
+			f, err := os.Open(fileInfo.Path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			return s.put(path.Join(dirName, path.Base(fileInfo.Path)), newProgressReader(f, progressBar), fileInfo.NumBytes)
+		}(); err != nil {
+			return err
+		}
+
+		logger.Infof("Uploaded file %v/%v", i+1, len(fileInfos))
+	}
+
+	return nil
+}
+
+func (s *WebDAVStorage) UploadAndConcat(fileName string, filePaths []string, bar ...progress.Bar) (int64, error) {
+	progressBar := getProgressBar(bar)
+
+	fileInfos, totalBytes, err := getFileSizes(filePaths)
+	if err != nil {
+		return 0, err
+	}
+
+	if progressBar != nil {
+		progressBar.SetMax(totalBytes)
+	}
+
+	logger := s.logger.With(zap.String("fileName", fileName))
+	logger.Infof("Uploading file of size %v...", units.HumanSize(float64(totalBytes)))
+
+	// WebDAV has no way to append to a file, so all files are streamed in a single request
+	r := newMultiFileReader(fileInfos)
+	defer r.Close()
+
+	if err := s.put(fileName, newProgressReader(r, progressBar), totalBytes); err != nil {
+		return 0, err
+	}
+
+	return totalBytes, nil
+}
+
+func (s *WebDAVStorage) put(fileName string, body io.Reader, contentLength int64) error {
+	resp, err := s.do(http.MethodPut, fileName, body, contentLength)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("HTTP request to %s returned status %v", resp.Request.URL.String(), resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (s *WebDAVStorage) do(method string, fileName string, body io.Reader, contentLength int64) (*http.Response, error) {
+	// Otherwise empty bodies would be sent chunked, which some servers do not accept
+	if contentLength == 0 {
+		body = nil
+	}
+
+	u := s.baseURL.JoinPath(fileName)
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = contentLength
+
+	if s.cfg.Username != "" {
+		req.SetBasicAuth(s.cfg.Username, s.cfg.Password)
+	}
+
+	return s.client.Do(req)
+}
@@ -0,0 +1,121 @@
+package throttle
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+)
+
+// Limiter is a token bucket that limits the number of bytes per second shared by everything that reads through it.
+// A nil Limiter does not limit anything.
+type Limiter struct {
+	mu sync.Mutex
+
+	bytesPerSecond float64
+	tokens         float64
+	lastRefillTime time.Time
+}
+
+// NewLimiter returns a limiter that allows bursts of up to one second worth of bytes, or nil if bytesPerSecond is not positive
+func NewLimiter(bytesPerSecond int64) *Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+
+	return &Limiter{
+		bytesPerSecond: float64(bytesPerSecond),
+		tokens:         float64(bytesPerSecond),
+		lastRefillTime: time.Now(),
+	}
+}
+
+// ParseBytesPerSecond parses a human readable bandwidth such as 10MB or 500kB/s, or returns 0 for an empty string
+func ParseBytesPerSecond(s string) (int64, error) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), "/s")
+	if s == "" {
+		return 0, nil
+	}
+
+	return units.FromHumanSize(s)
+}
+
+// WaitN blocks until n bytes are allowed through the limiter or ctx is done.
+// Reads larger than the burst are allowed by going into debt, which later reads have to wait out.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+
+	wait := l.reserve(float64(n))
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		l.refund(float64(n))
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (l *Limiter) reserve(n float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.lastRefillTime).Seconds() * l.bytesPerSecond
+	if l.tokens > l.bytesPerSecond {
+		l.tokens = l.bytesPerSecond
+	}
+	l.lastRefillTime = now
+
+	l.tokens -= n
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.bytesPerSecond * float64(time.Second))
+}
+
+func (l *Limiter) refund(n float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens += n
+}
+
+type reader struct {
+	ctx      context.Context
+	r        io.Reader
+	limiters []*Limiter
+}
+
+// NewReader returns a reader that waits on every limiter after each read from r. Nil limiters are ignored.
+func NewReader(ctx context.Context, r io.Reader, limiters ...*Limiter) io.Reader {
+	return &reader{
+		ctx:      ctx,
+		r:        r,
+		limiters: limiters,
+	}
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+
+	for _, limiter := range r.limiters {
+		if waitErr := limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
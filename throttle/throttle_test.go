@@ -0,0 +1,76 @@
+package throttle
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLimiterUnlimited(t *testing.T) {
+	assert.Nil(t, NewLimiter(0))
+	assert.Nil(t, NewLimiter(-1))
+
+	var l *Limiter
+	assert.NoError(t, l.WaitN(context.Background(), 1<<30))
+}
+
+func TestParseBytesPerSecond(t *testing.T) {
+	for s, expected := range map[string]int64{
+		"":        0,
+		"10MB":    10_000_000,
+		"500kB/s": 500_000,
+		" 1.5MB ": 1_500_000,
+		"1024":    1024,
+	} {
+		actual, err := ParseBytesPerSecond(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, expected, actual, s)
+	}
+
+	_, err := ParseBytesPerSecond("fast")
+	assert.Error(t, err)
+}
+
+func TestLimiterWaitN(t *testing.T) {
+	l := NewLimiter(1000)
+
+	// The initial burst is allowed immediately
+	start := time.Now()
+	require.NoError(t, l.WaitN(context.Background(), 1000))
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+	start = time.Now()
+	require.NoError(t, l.WaitN(context.Background(), 100))
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+}
+
+func TestLimiterWaitNCancelled(t *testing.T) {
+	l := NewLimiter(1000)
+	require.NoError(t, l.WaitN(context.Background(), 1000))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, l.WaitN(ctx, 10000), context.Canceled)
+
+	// Cancelled waits do not count against later ones
+	start := time.Now()
+	require.NoError(t, l.WaitN(context.Background(), 100))
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestReader(t *testing.T) {
+	data := bytes.Repeat([]byte{1}, 1500)
+	r := NewReader(context.Background(), bytes.NewReader(data), nil, NewLimiter(1000))
+
+	start := time.Now()
+	read, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data, read)
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+}
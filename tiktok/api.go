@@ -12,6 +12,8 @@ import (
 	"go.uber.org/zap"
 )
 
+var ErrNotPhotoPost error = fmt.Errorf("not a photo post")
+
 type API struct{}
 
 func NewAPI() (*API, error) {
@@ -129,3 +131,74 @@ func (API) GetUser(userID string) (*User, error) {
 		AvatarURL: rawUser.AvatarLarger,
 	}, nil
 }
+
+// GetPhotoPost returns ErrNotPhotoPost if the post is a video, which has to be fetched with GetVideo instead
+func (API) GetPhotoPost(postID string) (*PhotoPost, error) {
+	// TikTok redirects to the right URL regardless of the username and post type in it
+	resp, err := soup.Get(fmt.Sprintf("https://www.tiktok.com/@a/video/%s", postID))
+	if err != nil {
+		return nil, err
+	}
+
+	element := soup.HTMLParse(resp).Find("script", "id", "__UNIVERSAL_DATA_FOR_REHYDRATION__")
+	if element.Pointer == nil {
+		return nil, fmt.Errorf("could not find element")
+	}
+
+	if element.Pointer.FirstChild == nil {
+		return nil, fmt.Errorf("could not find child of element")
+	}
+
+	rawItemDetail := &RawItemDetail{}
+	if err := json.Unmarshal([]byte(element.Pointer.FirstChild.Data), rawItemDetail); err != nil {
+		return nil, err
+	}
+
+	return parsePhotoPost(rawItemDetail.DefaultScope.VideoDetail.ItemInfo.ItemStruct)
+}
+
+func parsePhotoPost(rawItem *RawItem) (*PhotoPost, error) {
+	if rawItem == nil {
+		return nil, fmt.Errorf("could not find post")
+	}
+
+	if rawItem.ImagePost == nil || len(rawItem.ImagePost.Images) == 0 {
+		return nil, ErrNotPhotoPost
+	}
+
+	photoURLs := make([]string, 0, len(rawItem.ImagePost.Images))
+	for _, image := range rawItem.ImagePost.Images {
+		// The first URL is the original quality, the rest are fallbacks
+		if len(image.ImageURL.URLList) > 0 {
+			photoURLs = append(photoURLs, image.ImageURL.URLList[0])
+		}
+	}
+
+	createTime, err := rawItem.CreateTime.Int64()
+	if err != nil {
+		createTime = 0
+	}
+
+	return &PhotoPost{
+		ID:          rawItem.ID,
+		Description: rawItem.Description,
+		PhotoURLs:   photoURLs,
+		Music: &Music{
+			ID:         rawItem.Music.ID,
+			Album:      rawItem.Music.Album,
+			AuthorName: rawItem.Music.AuthorName,
+			Title:      rawItem.Music.Title,
+			AudioURL:   rawItem.Music.PlayURL,
+		},
+		Author: &User{
+			ID:        rawItem.Author.ID,
+			UniqueID:  rawItem.Author.UniqueID,
+			Nickname:  rawItem.Author.Nickname,
+			AvatarURL: rawItem.Author.AvatarLarger,
+		},
+		LikeCount:    rawItem.Stats.DiggCount,
+		CommentCount: rawItem.Stats.CommentCount,
+		ShareCount:   rawItem.Stats.ShareCount,
+		CreateTime:   time.Unix(createTime, 0),
+	}, nil
+}
@@ -0,0 +1,70 @@
+package tiktok
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPhotoItemDetail = `{
+	"__DEFAULT_SCOPE__": {
+		"webapp.video-detail": {
+			"statusCode": 0,
+			"itemInfo": {
+				"itemStruct": {
+					"id": "7300000000000000000",
+					"desc": "Photos",
+					"createTime": "1700000000",
+					"author": {"id": "1", "uniqueId": "user", "nickname": "User", "avatarLarger": "https://p16.tiktokcdn.com/avatar.jpeg"},
+					"music": {"id": "2", "title": "Song", "authorName": "Artist", "playUrl": "https://sf16.tiktokcdn.com/song.mp3"},
+					"stats": {"diggCount": 10, "shareCount": 2, "commentCount": 3},
+					"imagePost": {
+						"images": [
+							{"imageURL": {"urlList": ["https://p16.tiktokcdn.com/1.jpeg", "https://p19.tiktokcdn.com/1.jpeg"]}},
+							{"imageURL": {"urlList": ["https://p16.tiktokcdn.com/2.jpeg"]}},
+							{"imageURL": {"urlList": []}},
+							{"imageURL": {"urlList": ["https://p16.tiktokcdn.com/3.jpeg"]}},
+							{"imageURL": {"urlList": ["https://p16.tiktokcdn.com/4.jpeg"]}},
+							{"imageURL": {"urlList": ["https://p16.tiktokcdn.com/5.jpeg"]}}
+						]
+					}
+				}
+			}
+		}
+	}
+}`
+
+func TestParsePhotoPost(t *testing.T) {
+	rawItemDetail := &RawItemDetail{}
+	require.NoError(t, json.Unmarshal([]byte(testPhotoItemDetail), rawItemDetail))
+
+	post, err := parsePhotoPost(rawItemDetail.DefaultScope.VideoDetail.ItemInfo.ItemStruct)
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://www.tiktok.com/@user/photo/7300000000000000000", post.URL())
+	assert.Equal(t, []string{
+		"https://p16.tiktokcdn.com/1.jpeg",
+		"https://p16.tiktokcdn.com/2.jpeg",
+		"https://p16.tiktokcdn.com/3.jpeg",
+		"https://p16.tiktokcdn.com/4.jpeg",
+		"https://p16.tiktokcdn.com/5.jpeg",
+	}, post.PhotoURLs)
+	assert.Equal(t, "https://sf16.tiktokcdn.com/song.mp3", post.Music.AudioURL)
+	assert.Equal(t, time.Unix(1700000000, 0), post.CreateTime)
+	assert.Equal(t, uint64(10), post.LikeCount)
+
+	embeds := post.GetEmbeds()
+	require.Len(t, embeds, 5)
+	assert.Equal(t, "https://p16.tiktokcdn.com/1.jpeg", embeds[0].Image.URL)
+	assert.Equal(t, post.URL(), embeds[3].URL)
+	assert.Equal(t, post.URL()+"?s=1", embeds[4].URL)
+	assert.NotNil(t, embeds[4].Footer)
+}
+
+func TestParsePhotoPostVideo(t *testing.T) {
+	_, err := parsePhotoPost(&RawItem{ID: "1"})
+	assert.ErrorIs(t, err, ErrNotPhotoPost)
+}
@@ -7,48 +7,116 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/xIceArcher/go-leah/consts"
+	"github.com/xIceArcher/go-leah/discord"
 	"github.com/xIceArcher/go-leah/utils"
 )
 
+const (
+	MAX_EMBEDS_PER_POST = 4
+)
+
+var tiktokFooter = &discordgo.MessageEmbedFooter{
+	Text:    "Tiktok",
+	IconURL: "https://cdn4.iconfinder.com/data/icons/social-media-flat-7/64/Social-media_Tiktok-512.png",
+}
+
 func (v *Video) GetEmbed() *discordgo.MessageEmbed {
 	textWithEntities := &utils.TextWithEntities{Text: v.Description}
 	segmentedText := textWithEntities.GetReplacedText(4096, 1)
 
 	return &discordgo.MessageEmbed{
-		URL:   v.URL(),
-		Title: fmt.Sprintf("Video by %s", v.Author.Nickname),
-		Author: &discordgo.MessageEmbedAuthor{
-			Name:    fmt.Sprintf("%s (@%s)", v.Author.Nickname, v.Author.UniqueID),
-			URL:     v.Author.URL(),
-			IconURL: v.Author.AvatarURL,
-		},
+		URL:         v.URL(),
+		Title:       fmt.Sprintf("Video by %s", v.Author.Nickname),
+		Author:      v.Author.GetEmbed(),
+		Description: segmentedText[0],
+		Color:       utils.ParseHexColor(consts.ColorTiktok),
+		Fields:      getStatsFields(v.Music.String(), v.LikeCount, v.CommentCount, v.ShareCount),
+		Footer:      tiktokFooter,
+		Timestamp:   v.CreateTime.Format(time.RFC3339),
+	}
+}
+
+// GetEmbeds returns the photos as a gallery, with Discord showing up to 4 photos per embed URL
+func (p *PhotoPost) GetEmbeds() (embeds []*discordgo.MessageEmbed) {
+	textWithEntities := &utils.TextWithEntities{Text: p.Description}
+	segmentedText := textWithEntities.GetReplacedText(4096, 1)
+
+	music := p.Music.String()
+	if p.Music.AudioURL != "" {
+		music = discord.GetNamedLink(music, p.Music.AudioURL)
+	}
+
+	embeds = append(embeds, &discordgo.MessageEmbed{
+		URL:         p.URL(),
+		Title:       fmt.Sprintf("Photos by %s", p.Author.Nickname),
+		Author:      p.Author.GetEmbed(),
 		Description: segmentedText[0],
 		Color:       utils.ParseHexColor(consts.ColorTiktok),
-		Fields: []*discordgo.MessageEmbedField{
-			{
-				Name:  "Music",
-				Value: v.Music.String(),
-			},
-			{
-				Name:   "Likes",
-				Value:  strconv.FormatUint(v.LikeCount, 10),
-				Inline: true,
-			},
-			{
-				Name:   "Comments",
-				Value:  strconv.FormatUint(v.CommentCount, 10),
-				Inline: true,
-			},
-			{
-				Name:   "Shares",
-				Value:  strconv.FormatUint(v.ShareCount, 10),
-				Inline: true,
-			},
+		Fields:      getStatsFields(music, p.LikeCount, p.CommentCount, p.ShareCount),
+	})
+
+	footerEmbedIdx := len(embeds) - 1
+	for i, photoURL := range p.PhotoURLs {
+		if i == 0 {
+			embeds[len(embeds)-1].Image = &discordgo.MessageEmbedImage{
+				URL: p.PhotoURLs[0],
+			}
+		} else {
+			embedURL := p.URL()
+			if i >= MAX_EMBEDS_PER_POST {
+				embedURL += fmt.Sprintf("?s=%v", i/MAX_EMBEDS_PER_POST)
+			}
+
+			if i%MAX_EMBEDS_PER_POST == 0 {
+				footerEmbedIdx += MAX_EMBEDS_PER_POST
+			}
+
+			embeds = append(embeds, &discordgo.MessageEmbed{
+				URL: embedURL,
+				Image: &discordgo.MessageEmbedImage{
+					URL: photoURL,
+				},
+				Color: utils.ParseHexColor(consts.ColorTiktok),
+			})
+		}
+	}
+
+	embeds[footerEmbedIdx].Footer = tiktokFooter
+	if p.CreateTime.Unix() != 0 {
+		embeds[footerEmbedIdx].Timestamp = p.CreateTime.Format(time.RFC3339)
+	}
+
+	return embeds
+}
+
+func (u *User) GetEmbed() *discordgo.MessageEmbedAuthor {
+	return &discordgo.MessageEmbedAuthor{
+		Name:    fmt.Sprintf("%s (@%s)", u.Nickname, u.UniqueID),
+		URL:     u.URL(),
+		IconURL: u.AvatarURL,
+	}
+}
+
+func getStatsFields(music string, likeCount uint64, commentCount uint64, shareCount uint64) []*discordgo.MessageEmbedField {
+	return []*discordgo.MessageEmbedField{
+		{
+			Name:  "Music",
+			Value: music,
+		},
+		{
+			Name:   "Likes",
+			Value:  strconv.FormatUint(likeCount, 10),
+			Inline: true,
+		},
+		{
+			Name:   "Comments",
+			Value:  strconv.FormatUint(commentCount, 10),
+			Inline: true,
 		},
-		Footer: &discordgo.MessageEmbedFooter{
-			Text:    "Tiktok",
-			IconURL: "https://cdn4.iconfinder.com/data/icons/social-media-flat-7/64/Social-media_Tiktok-512.png",
+		{
+			Name:   "Shares",
+			Value:  strconv.FormatUint(shareCount, 10),
+			Inline: true,
 		},
-		Timestamp: v.CreateTime.Format(time.RFC3339),
 	}
 }
@@ -2,6 +2,7 @@ package tiktok
 
 import (
 	"cmp"
+	"encoding/json"
 	"fmt"
 	"io"
 	"slices"
@@ -102,6 +103,53 @@ type RawUser struct {
 	} `json:"__DEFAULT_SCOPE__"`
 }
 
+type RawItemDetail struct {
+	DefaultScope struct {
+		VideoDetail struct {
+			StatusCode int `json:"statusCode"`
+			ItemInfo   struct {
+				ItemStruct *RawItem `json:"itemStruct"`
+			} `json:"itemInfo"`
+		} `json:"webapp.video-detail"`
+	} `json:"__DEFAULT_SCOPE__"`
+}
+
+type RawItem struct {
+	ID          string      `json:"id"`
+	Description string      `json:"desc"`
+	CreateTime  json.Number `json:"createTime"`
+
+	Author struct {
+		ID           string `json:"id"`
+		UniqueID     string `json:"uniqueId"`
+		Nickname     string `json:"nickname"`
+		AvatarLarger string `json:"avatarLarger"`
+	} `json:"author"`
+
+	Music struct {
+		ID         string `json:"id"`
+		Title      string `json:"title"`
+		AuthorName string `json:"authorName"`
+		Album      string `json:"album"`
+		PlayURL    string `json:"playUrl"`
+	} `json:"music"`
+
+	Stats struct {
+		DiggCount    uint64 `json:"diggCount"`
+		ShareCount   uint64 `json:"shareCount"`
+		CommentCount uint64 `json:"commentCount"`
+	} `json:"stats"`
+
+	// Only set for photo posts
+	ImagePost *struct {
+		Images []struct {
+			ImageURL struct {
+				URLList []string `json:"urlList"`
+			} `json:"imageURL"`
+		} `json:"images"`
+	} `json:"imagePost"`
+}
+
 type Video struct {
 	ID          string
 	Description string
@@ -124,11 +172,34 @@ func (v *Video) URL() string {
 	return fmt.Sprintf("https://www.tiktok.com/@%s/video/%s", v.Author.UniqueID, v.ID)
 }
 
+// PhotoPost is a slideshow of photos set to music
+type PhotoPost struct {
+	ID          string
+	Description string
+	PhotoURLs   []string
+
+	Music  *Music
+	Author *User
+
+	LikeCount    uint64
+	CommentCount uint64
+	ShareCount   uint64
+
+	CreateTime time.Time
+}
+
+func (p *PhotoPost) URL() string {
+	return fmt.Sprintf("https://www.tiktok.com/@%s/photo/%s", p.Author.UniqueID, p.ID)
+}
+
 type Music struct {
 	ID         string
 	Album      string
 	AuthorName string
 	Title      string
+
+	// Only known for photo posts, where the music is the only audio
+	AudioURL string
 }
 
 func (m *Music) String() string {
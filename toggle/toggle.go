@@ -0,0 +1,123 @@
+package toggle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xIceArcher/go-leah/cache"
+)
+
+const (
+	CacheKeyTogglePrefix        = "go-leah/toggle/"
+	CacheKeyToggleGuildFormat   = CacheKeyTogglePrefix + "guild/%s/%s"
+	CacheKeyToggleChannelFormat = CacheKeyTogglePrefix + "channel/%s/%s"
+)
+
+type Scope string
+
+const (
+	ScopeGuild   Scope = "guild"
+	ScopeChannel Scope = "channel"
+)
+
+var ErrUnknownScope error = fmt.Errorf("unknown scope")
+
+func ParseScope(s string) (Scope, error) {
+	switch Scope(strings.ToLower(s)) {
+	case ScopeGuild, "server":
+		return ScopeGuild, nil
+	case ScopeChannel:
+		return ScopeChannel, nil
+	default:
+		return "", fmt.Errorf("%w %s", ErrUnknownScope, s)
+	}
+}
+
+// Source describes where the effective state of a feature comes from
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceGuild   Source = "guild"
+	SourceChannel Source = "channel"
+)
+
+// Store keeps per-guild and per-channel overrides of which features are enabled.
+// Channel overrides take precedence over guild overrides, which take precedence over the default.
+type Store struct {
+	cache cache.Cache
+}
+
+func New(c cache.Cache) *Store {
+	return &Store{
+		cache: c,
+	}
+}
+
+func (t *Store) IsEnabled(ctx context.Context, guildID string, channelID string, feature string, defaultEnabled bool) (bool, error) {
+	isEnabled, _, err := t.GetState(ctx, guildID, channelID, feature, defaultEnabled)
+	return isEnabled, err
+}
+
+func (t *Store) GetState(ctx context.Context, guildID string, channelID string, feature string, defaultEnabled bool) (bool, Source, error) {
+	if isEnabled, err := t.get(ctx, ScopeChannel, channelID, feature); err == nil {
+		return isEnabled, SourceChannel, nil
+	} else if !errors.Is(err, cache.ErrNotFound) {
+		return defaultEnabled, SourceDefault, err
+	}
+
+	if guildID != "" {
+		if isEnabled, err := t.get(ctx, ScopeGuild, guildID, feature); err == nil {
+			return isEnabled, SourceGuild, nil
+		} else if !errors.Is(err, cache.ErrNotFound) {
+			return defaultEnabled, SourceDefault, err
+		}
+	}
+
+	return defaultEnabled, SourceDefault, nil
+}
+
+func (t *Store) Set(ctx context.Context, scope Scope, id string, feature string, isEnabled bool) error {
+	cacheKey, err := getCacheKey(scope, id, feature)
+	if err != nil {
+		return err
+	}
+
+	return t.cache.Set(ctx, cacheKey, isEnabled)
+}
+
+func (t *Store) Unset(ctx context.Context, scope Scope, id string, feature string) error {
+	cacheKey, err := getCacheKey(scope, id, feature)
+	if err != nil {
+		return err
+	}
+
+	return t.cache.Clear(ctx, cacheKey)
+}
+
+func (t *Store) get(ctx context.Context, scope Scope, id string, feature string) (bool, error) {
+	cacheKey, err := getCacheKey(scope, id, feature)
+	if err != nil {
+		return false, err
+	}
+
+	val, err := t.cache.Get(ctx, cacheKey)
+	if err != nil {
+		return false, err
+	}
+
+	return fmt.Sprintf("%v", val) == "1", nil
+}
+
+func getCacheKey(scope Scope, id string, feature string) (string, error) {
+	switch scope {
+	case ScopeGuild:
+		return fmt.Sprintf(CacheKeyToggleGuildFormat, id, feature), nil
+	case ScopeChannel:
+		return fmt.Sprintf(CacheKeyToggleChannelFormat, id, feature), nil
+	default:
+		return "", fmt.Errorf("%w %s", ErrUnknownScope, scope)
+	}
+}
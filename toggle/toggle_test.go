@@ -0,0 +1,59 @@
+package toggle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xIceArcher/go-leah/cache"
+	"github.com/xIceArcher/go-leah/config"
+)
+
+func TestStorePrecedence(t *testing.T) {
+	c, err := cache.NewMemoryCache(&config.CacheConfig{})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	store := New(c)
+
+	isEnabled, source, err := store.GetState(ctx, "guild", "channel", "feature", true)
+	require.NoError(t, err)
+	assert.True(t, isEnabled)
+	assert.Equal(t, SourceDefault, source)
+
+	require.NoError(t, store.Set(ctx, ScopeGuild, "guild", "feature", false))
+	isEnabled, source, err = store.GetState(ctx, "guild", "channel", "feature", true)
+	require.NoError(t, err)
+	assert.False(t, isEnabled)
+	assert.Equal(t, SourceGuild, source)
+
+	require.NoError(t, store.Set(ctx, ScopeChannel, "channel", "feature", true))
+	isEnabled, source, err = store.GetState(ctx, "guild", "channel", "feature", true)
+	require.NoError(t, err)
+	assert.True(t, isEnabled)
+	assert.Equal(t, SourceChannel, source)
+
+	// Other channels in the guild still follow the guild override
+	isEnabled, err = store.IsEnabled(ctx, "guild", "otherChannel", "feature", true)
+	require.NoError(t, err)
+	assert.False(t, isEnabled)
+
+	require.NoError(t, store.Unset(ctx, ScopeChannel, "channel", "feature"))
+	require.NoError(t, store.Unset(ctx, ScopeGuild, "guild", "feature"))
+	isEnabled, source, err = store.GetState(ctx, "guild", "channel", "feature", false)
+	require.NoError(t, err)
+	assert.False(t, isEnabled)
+	assert.Equal(t, SourceDefault, source)
+}
+
+func TestParseScope(t *testing.T) {
+	for input, expected := range map[string]Scope{"guild": ScopeGuild, "Server": ScopeGuild, "channel": ScopeChannel} {
+		scope, err := ParseScope(input)
+		require.NoError(t, err)
+		assert.Equal(t, expected, scope)
+	}
+
+	_, err := ParseScope("user")
+	assert.ErrorIs(t, err, ErrUnknownScope)
+}
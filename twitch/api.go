@@ -60,7 +60,9 @@ func (a *API) GetStream(loginName string) (*Stream, error) {
 
 	stream := streams.Data.Streams[0]
 	return &Stream{
+		LoginName:    stream.UserLogin,
 		Title:        stream.Title,
+		GameName:     stream.GameName,
 		ThumbnailURL: a.FormatThumbnailURL(stream.ThumbnailURL, 1920, 1080),
 
 		User: user,
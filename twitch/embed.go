@@ -9,7 +9,32 @@ import (
 	"github.com/xIceArcher/go-leah/utils"
 )
 
+var twitchEmbedFooter = &discordgo.MessageEmbedFooter{
+	Text:    "Twitch",
+	IconURL: "https://cdn4.iconfinder.com/data/icons/logos-and-brands/512/343_Twitch_logo-512.png",
+}
+
 func (s *Stream) GetEmbed() *discordgo.MessageEmbed {
+	fields := make([]*discordgo.MessageEmbedField, 0)
+	if s.GameName != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Game",
+			Value:  s.GameName,
+			Inline: true,
+		})
+	}
+
+	fields = append(fields, &discordgo.MessageEmbedField{
+		Name:   "Started",
+		Value:  utils.FormatDiscordRelativeTime(s.StartedAt),
+		Inline: true,
+	})
+	fields = append(fields, &discordgo.MessageEmbedField{
+		Name:   "Viewers",
+		Value:  fmt.Sprint(s.ViewerCount),
+		Inline: true,
+	})
+
 	return &discordgo.MessageEmbed{
 		URL:   s.URL(),
 		Title: s.Title,
@@ -18,26 +43,40 @@ func (s *Stream) GetEmbed() *discordgo.MessageEmbed {
 		},
 		Author:    s.User.GetEmbed(),
 		Timestamp: s.StartedAt.Format(time.RFC3339),
-		Fields: []*discordgo.MessageEmbedField{
-			{
-				Name:   "Started",
-				Value:  utils.FormatDiscordRelativeTime(s.StartedAt),
-				Inline: true,
-			},
-			{
-				Name:   "Viewers",
-				Value:  fmt.Sprint(s.ViewerCount),
-				Inline: true,
-			},
-		},
-		Color: utils.ParseHexColor(consts.ColorTwitch),
-		Footer: &discordgo.MessageEmbedFooter{
-			Text:    "Twitch",
-			IconURL: "https://cdn4.iconfinder.com/data/icons/logos-and-brands/512/343_Twitch_logo-512.png",
-		},
+		Fields:    fields,
+		Color:     utils.ParseHexColor(consts.ColorTwitch),
+		Footer:    twitchEmbedFooter,
 	}
 }
 
+// SetEmbedEnded marks a stream embed as ended, approximating the end time with the last time the stream was seen live
+func SetEmbedEnded(embed *discordgo.MessageEmbed, lastLiveTime time.Time) {
+	fields := make([]*discordgo.MessageEmbedField, 0)
+	for _, field := range embed.Fields {
+		if field.Name == "Game" {
+			fields = append(fields, field)
+		}
+	}
+
+	fields = append(fields, &discordgo.MessageEmbedField{
+		Name:   "Ended",
+		Value:  "~" + utils.FormatDiscordRelativeTime(lastLiveTime),
+		Inline: true,
+	})
+
+	if startTime, err := time.Parse(time.RFC3339, embed.Timestamp); err == nil {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Duration",
+			Value:  "~" + utils.FormatDurationSimple(lastLiveTime.Sub(startTime)),
+			Inline: true,
+		})
+	}
+
+	embed.Fields = fields
+	embed.Thumbnail = nil
+	embed.Color = utils.ParseHexColor(consts.ColorNone)
+}
+
 func (u *User) GetEmbed() *discordgo.MessageEmbedAuthor {
 	return &discordgo.MessageEmbedAuthor{
 		Name:    u.Name,
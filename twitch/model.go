@@ -6,7 +6,9 @@ import (
 )
 
 type Stream struct {
+	LoginName    string
 	Title        string
+	GameName     string
 	ThumbnailURL string
 
 	User *User
@@ -16,7 +18,7 @@ type Stream struct {
 }
 
 func (s *Stream) URL() string {
-	return s.User.URL()
+	return fmt.Sprintf("https://twitch.tv/%s", s.LoginName)
 }
 
 type User struct {
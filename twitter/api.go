@@ -20,12 +20,23 @@ const (
 	CacheKeyTwitterAPITweetFormat = "go-leah/twitterAPI/tweet/%s"
 )
 
+const (
+	// Public bearer token used by the Twitter web client
+	webBearerToken = "AAAAAAAAAAAAAAAAAAAAANRILgAAAAAAnNwIzUejRCOuH5E6I8xnZz4puTs%3D1Zv7ttfk8LF81IUq16cHjhLTvJu4FA33AGWWjCpTnA"
+
+	audioSpaceByIDURL      = "https://twitter.com/i/api/graphql/HPEisOmj1epUNLCWTYhUWw/AudioSpaceById"
+	audioSpaceByIDFeatures = `{"spaces_2022_h2_clipping":true,"spaces_2022_h2_spaces_communities":true,"verified_phone_label_enabled":false,"tweetypie_unmention_optimization_enabled":true,"responsive_web_graphql_exclude_directive_enabled":true,"responsive_web_graphql_skip_user_profile_image_extensions_enabled":false,"responsive_web_graphql_timeline_navigation_enabled":true}`
+)
+
 var URLRegex *regexp.Regexp = regexp.MustCompile(`(?:http[s]?://)?(?:(?:twitter)|(?:x))\.com/[^/]*/status/([0-9]*)(?:\?[^ \r\n]*)?`)
 var ErrNotFound = errors.New("not found")
 var ErrInternalServerError = errors.New("internal server error")
+var errGuestTokenExpired = errors.New("guest token expired")
 
 type API interface {
 	GetTweet(id string) (*Tweet, error)
+	GetUserTweets(screenName string) ([]*Tweet, error)
+	GetSpace(id string) (*Space, error)
 }
 
 type CachedAPI struct {
@@ -93,6 +104,9 @@ var (
 	client *retryablehttp.Client
 
 	apiSetupOnce sync.Once
+
+	guestToken   string
+	guestTokenMu sync.Mutex
 )
 
 func NewBaseAPI() *BaseAPI {
@@ -142,3 +156,157 @@ func (a *BaseAPI) GetTweet(id string) (*Tweet, error) {
 
 	return rawResp.Tweet.ToDTO(), nil
 }
+
+func (a *BaseAPI) GetUserTweets(screenName string) ([]*Tweet, error) {
+	resp, err := client.Get(fmt.Sprintf("https://api.fxtwitter.com/2/profile/%s/statuses", screenName))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	bytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	rawResp := &getUserTweetsResponse{}
+	if err := json.Unmarshal(bytes, rawResp); err != nil {
+		return nil, err
+	}
+	if rawResp.Code == http.StatusNotFound || rawResp.Code == http.StatusUnauthorized {
+		return nil, ErrNotFound
+	}
+	if rawResp.Code == http.StatusInternalServerError {
+		return nil, ErrInternalServerError
+	}
+
+	tweets := make([]*Tweet, 0, len(rawResp.Results))
+	for _, rawTweet := range rawResp.Results {
+		tweets = append(tweets, rawTweet.ToDTO())
+	}
+
+	return tweets, nil
+}
+
+func (a *BaseAPI) GetSpace(id string) (*Space, error) {
+	space, err := a.getSpace(id)
+	if errors.Is(err, errGuestTokenExpired) {
+		// Retry once with a fresh guest token
+		space, err = a.getSpace(id)
+	}
+
+	return space, err
+}
+
+func (a *BaseAPI) getSpace(id string) (*Space, error) {
+	token, err := a.getGuestToken()
+	if err != nil {
+		return nil, err
+	}
+
+	variables, err := json.Marshal(map[string]any{
+		"id":                          id,
+		"isMetatagsQuery":             false,
+		"withSuperFollowsUserFields":  true,
+		"withDownvotePerspective":     false,
+		"withReactionsMetadata":       false,
+		"withReactionsPerspective":    false,
+		"withSuperFollowsTweetFields": true,
+		"withReplays":                 true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := retryablehttp.NewRequest(http.MethodGet, audioSpaceByIDURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	query := req.URL.Query()
+	query.Set("variables", string(variables))
+	query.Set("features", audioSpaceByIDFeatures)
+	req.URL.RawQuery = query.Encode()
+
+	req.Header.Set("Authorization", "Bearer "+webBearerToken)
+	req.Header.Set("X-Guest-Token", token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		a.clearGuestToken(token)
+		return nil, errGuestTokenExpired
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP request to %s returned status %v", resp.Request.URL.String(), resp.StatusCode)
+	}
+
+	bytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	rawResp := &getSpaceResponse{}
+	if err := json.Unmarshal(bytes, rawResp); err != nil {
+		return nil, err
+	}
+	if rawResp.Data.AudioSpace.Metadata == nil {
+		return nil, ErrNotFound
+	}
+
+	return rawResp.Data.AudioSpace.ToDTO(), nil
+}
+
+func (a *BaseAPI) getGuestToken() (string, error) {
+	guestTokenMu.Lock()
+	defer guestTokenMu.Unlock()
+
+	if guestToken != "" {
+		return guestToken, nil
+	}
+
+	req, err := retryablehttp.NewRequest(http.MethodPost, "https://api.twitter.com/1.1/guest/activate.json", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+webBearerToken)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP request to %s returned status %v", resp.Request.URL.String(), resp.StatusCode)
+	}
+
+	rawResp := &activateGuestResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(rawResp); err != nil {
+		return "", err
+	}
+	if rawResp.GuestToken == "" {
+		return "", fmt.Errorf("empty guest token")
+	}
+
+	guestToken = rawResp.GuestToken
+	return guestToken, nil
+}
+
+func (a *BaseAPI) clearGuestToken(token string) {
+	guestTokenMu.Lock()
+	defer guestTokenMu.Unlock()
+
+	// Another goroutine may have already replaced the token
+	if guestToken == token {
+		guestToken = ""
+	}
+}
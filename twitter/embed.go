@@ -162,7 +162,17 @@ func (s *Space) GetEmbed() *discordgo.MessageEmbed {
 	var color string
 	fields := make([]*discordgo.MessageEmbedField, 0)
 
-	if s.State == SpaceStateLive {
+	timestamp := s.StartTime
+
+	if s.State == SpaceStateScheduled {
+		color = consts.ColorGreen
+		timestamp = s.ScheduledStartTime
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Starts",
+			Value:  utils.FormatDiscordRelativeTime(s.ScheduledStartTime),
+			Inline: true,
+		})
+	} else if s.State == SpaceStateLive {
 		color = consts.ColorTwitter
 		fields = append(fields, &discordgo.MessageEmbedField{
 			Name:   "Started",
@@ -192,7 +202,7 @@ func (s *Space) GetEmbed() *discordgo.MessageEmbed {
 		URL:       s.URL(),
 		Title:     s.Title,
 		Author:    s.Creator.GetEmbed(),
-		Timestamp: s.StartTime.Format(time.RFC3339),
+		Timestamp: timestamp.Format(time.RFC3339),
 		Fields:    fields,
 		Color:     utils.ParseHexColor(color),
 		Footer:    twitterEmbedFooter,
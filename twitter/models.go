@@ -2,6 +2,7 @@ package twitter
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/xIceArcher/go-leah/utils"
@@ -44,6 +45,21 @@ func (t *Tweet) URL() string {
 	return fmt.Sprintf("https://twitter.com/%s/status/%s", t.User.ScreenName, t.ID)
 }
 
+// IsNewerThan compares tweet IDs, which increase over time
+func (t *Tweet) IsNewerThan(id string) bool {
+	currID, err := strconv.ParseUint(t.ID, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	otherID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return true
+	}
+
+	return currID > otherID
+}
+
 func (t *Tweet) DisplayText() string {
 	if t.RetweetedStatus != nil {
 		return t.RetweetedStatus.Text
@@ -112,8 +128,9 @@ type Media struct {
 type SpaceState string
 
 const (
-	SpaceStateLive  SpaceState = "live"
-	SpaceStateEnded SpaceState = "ended"
+	SpaceStateScheduled SpaceState = "scheduled"
+	SpaceStateLive      SpaceState = "live"
+	SpaceStateEnded     SpaceState = "ended"
 )
 
 type Poll struct {
@@ -138,8 +155,13 @@ type Space struct {
 	Creator          *User
 	ParticipantCount int
 
-	StartTime time.Time
-	EndTime   time.Time
+	ScheduledStartTime time.Time
+	StartTime          time.Time
+	EndTime            time.Time
+}
+
+func (s *Space) IsEnded() bool {
+	return s.State == SpaceStateEnded
 }
 
 func (s *Space) URL() string {
@@ -1,6 +1,8 @@
 package twitter
 
 import (
+	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -10,6 +12,12 @@ type getTweetResponse struct {
 	Tweet   rawTweet `json:"tweet"`
 }
 
+type getUserTweetsResponse struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Results []*rawTweet `json:"results"`
+}
+
 type rawTweet struct {
 	ID               string     `json:"id"`
 	URL              string     `json:"url"`
@@ -113,3 +121,91 @@ func (t *rawTweet) ToDTO() *Tweet {
 		Poll: poll,
 	}
 }
+
+type activateGuestResponse struct {
+	GuestToken string `json:"guest_token"`
+}
+
+type getSpaceResponse struct {
+	Data struct {
+		AudioSpace rawAudioSpace `json:"audioSpace"`
+	} `json:"data"`
+}
+
+type rawAudioSpace struct {
+	Metadata     *rawAudioSpaceMetadata `json:"metadata"`
+	Participants struct {
+		Total int `json:"total"`
+	} `json:"participants"`
+}
+
+type rawAudioSpaceMetadata struct {
+	RestID             string `json:"rest_id"`
+	State              string `json:"state"`
+	Title              string `json:"title"`
+	ScheduledStart     int64  `json:"scheduled_start"`
+	StartedAt          int64  `json:"started_at"`
+	EndedAt            string `json:"ended_at"`
+	TotalLiveListeners int    `json:"total_live_listeners"`
+	CreatorResults     struct {
+		Result struct {
+			RestID string `json:"rest_id"`
+			Legacy struct {
+				Name                 string `json:"name"`
+				ScreenName           string `json:"screen_name"`
+				ProfileImageURLHTTPS string `json:"profile_image_url_https"`
+			} `json:"legacy"`
+		} `json:"result"`
+	} `json:"creator_results"`
+}
+
+const (
+	rawSpaceStateRunning    = "Running"
+	rawSpaceStateNotStarted = "NotStarted"
+)
+
+func (s *rawAudioSpace) ToDTO() *Space {
+	metadata := s.Metadata
+	creator := metadata.CreatorResults.Result
+
+	space := &Space{
+		ID:    metadata.RestID,
+		Title: metadata.Title,
+		Creator: &User{
+			ID:              creator.RestID,
+			Name:            creator.Legacy.Name,
+			ScreenName:      creator.Legacy.ScreenName,
+			ProfileImageURL: creator.Legacy.ProfileImageURLHTTPS,
+		},
+		ParticipantCount: s.Participants.Total,
+	}
+
+	switch metadata.State {
+	case rawSpaceStateRunning:
+		space.State = SpaceStateLive
+	case rawSpaceStateNotStarted:
+		space.State = SpaceStateScheduled
+	default:
+		space.State = SpaceStateEnded
+	}
+
+	if space.Title == "" {
+		space.Title = fmt.Sprintf("%s's Space", creator.Legacy.Name)
+	}
+
+	if metadata.ScheduledStart != 0 {
+		space.ScheduledStartTime = time.UnixMilli(metadata.ScheduledStart)
+	}
+	if metadata.StartedAt != 0 {
+		space.StartTime = time.UnixMilli(metadata.StartedAt)
+	}
+	if endedAt, err := strconv.ParseInt(metadata.EndedAt, 10, 64); err == nil && endedAt != 0 {
+		space.EndTime = time.UnixMilli(endedAt)
+	}
+
+	if space.State == SpaceStateLive && metadata.TotalLiveListeners > 0 {
+		space.ParticipantCount = metadata.TotalLiveListeners
+	}
+
+	return space
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -66,10 +67,13 @@ func ConvertMP4ToGIFLossless(video []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	ffprobeOutput, err := ffprobeFile(tmpVideoIn.Name())
+	ffprobeOutput, err := ffprobeFile(context.Background(), tmpVideoIn.Name())
 	if err != nil {
 		return nil, err
 	}
+	if ffprobeOutput.NumFrames == 0 || ffprobeOutput.Duration == 0 {
+		return nil, fmt.Errorf("unknown frame rate")
+	}
 
 	frameOutFormat := fmt.Sprintf("%s/%%04d.png", dir)
 
@@ -119,7 +123,7 @@ func ConvertMP4ToGIFCompressed(video []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	ffprobeOutput, err := ffprobeFile(tmpVideoIn.Name())
+	ffprobeOutput, err := ffprobeFile(context.Background(), tmpVideoIn.Name())
 	if err != nil {
 		return nil, err
 	}
@@ -154,13 +158,17 @@ func createAndCloseTempFile(pattern string) (f *os.File, remove func() error, er
 }
 
 type FFProbeOutput struct {
+	// Of the first stream, zero if ffprobe does not know them
 	Width     int
 	Duration  time.Duration
 	NumFrames int
+
+	// Of the whole file, which unlike the duration of a stream is known for every container
+	FormatDuration time.Duration
 }
 
-func ffprobeFile(fileName string) (*FFProbeOutput, error) {
-	ffprobeRawOutputBytes, err := exec.Command("ffprobe", "-v", "quiet", "-print_format", "json", "-show_streams", fileName).Output()
+func ffprobeFile(ctx context.Context, fileName string) (*FFProbeOutput, error) {
+	ffprobeRawOutputBytes, err := exec.CommandContext(ctx, "ffprobe", "-v", "quiet", "-print_format", "json", "-show_streams", "-show_format", fileName).Output()
 	if err != nil {
 		return nil, err
 	}
@@ -171,6 +179,9 @@ func ffprobeFile(fileName string) (*FFProbeOutput, error) {
 			NBFrames string `json:"nb_frames"`
 			Duration string `json:"duration"`
 		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
 	}
 
 	var ffprobeRawOutput *FFProbeRawOutput
@@ -182,19 +193,37 @@ func ffprobeFile(fileName string) (*FFProbeOutput, error) {
 		return nil, fmt.Errorf("no streams detected")
 	}
 
-	numFrames, err := strconv.Atoi(ffprobeRawOutput.Streams[0].NBFrames)
-	if err != nil {
+	output := &FFProbeOutput{
+		Width: ffprobeRawOutput.Streams[0].Width,
+	}
+
+	if nbFrames := ffprobeRawOutput.Streams[0].NBFrames; nbFrames != "" {
+		if output.NumFrames, err = strconv.Atoi(nbFrames); err != nil {
+			return nil, err
+		}
+	}
+
+	if output.Duration, err = parseFFProbeDuration(ffprobeRawOutput.Streams[0].Duration); err != nil {
 		return nil, err
 	}
 
-	durationSecs, err := strconv.ParseFloat(ffprobeRawOutput.Streams[0].Duration, 64)
-	if err != nil {
+	if output.FormatDuration, err = parseFFProbeDuration(ffprobeRawOutput.Format.Duration); err != nil {
 		return nil, err
 	}
 
-	return &FFProbeOutput{
-		Width:     ffprobeRawOutput.Streams[0].Width,
-		Duration:  time.Duration(durationSecs*1000000000) * time.Nanosecond,
-		NumFrames: numFrames,
-	}, nil
+	return output, nil
+}
+
+// parseFFProbeDuration parses a duration in seconds, or returns 0 for an empty string
+func parseFFProbeDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	durationSecs, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(durationSecs * float64(time.Second)), nil
 }
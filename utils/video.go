@@ -0,0 +1,286 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConcatFiles appends the files into outPath byte by byte, which is valid for MPEG-TS segments
+func ConcatFiles(outPath string, filePaths []string) error {
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	for _, filePath := range filePaths {
+		if err := func() error {
+			in, err := os.Open(filePath)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+
+			_, err = io.Copy(out, in)
+			return err
+		}(); err != nil {
+			return err
+		}
+	}
+
+	return out.Close()
+}
+
+// RemuxVideo copies the streams of inPath into the container given by the extension of outPath.
+// Timestamps are regenerated so that streams recorded from the middle of a broadcast start at zero.
+func RemuxVideo(ctx context.Context, inPath string, outPath string) error {
+	args := []string{"-fflags", "+genpts+discardcorrupt", "-i", inPath}
+	args = append(args, remuxOutputArgs(outPath)...)
+
+	return runFFmpeg(ctx, args...)
+}
+
+// MuxVideoAndAudio combines the video streams of videoPath and the audio streams of audioPath into outPath
+func MuxVideoAndAudio(ctx context.Context, videoPath string, audioPath string, outPath string) error {
+	args := []string{"-fflags", "+genpts", "-i", videoPath, "-i", audioPath, "-map", "0:v", "-map", "1:a", "-c", "copy"}
+	if strings.EqualFold(filepath.Ext(outPath), ".mp4") {
+		args = append(args, "-movflags", "+faststart")
+	}
+	args = append(args, "-y", outPath)
+
+	return runFFmpeg(ctx, args...)
+}
+
+type VideoChapter struct {
+	Title    string
+	FilePath string
+}
+
+// MergeVideosWithChapters joins the videos into outPath with one chapter per video
+func MergeVideosWithChapters(ctx context.Context, chapters []*VideoChapter, outPath string) error {
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	var concatList, metadata strings.Builder
+	metadata.WriteString(";FFMETADATA1\n")
+
+	var start time.Duration
+	for _, chapter := range chapters {
+		absPath, err := filepath.Abs(chapter.FilePath)
+		if err != nil {
+			return err
+		}
+
+		duration, err := GetVideoDuration(ctx, chapter.FilePath)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&concatList, "file '%s'\n", strings.ReplaceAll(absPath, "'", `'\''`))
+		fmt.Fprintf(&metadata, "[CHAPTER]\nTIMEBASE=1/1000\nSTART=%v\nEND=%v\ntitle=%s\n", start.Milliseconds(), (start + duration).Milliseconds(), escapeFFMetadata(chapter.Title))
+
+		start += duration
+	}
+
+	concatListPath := filepath.Join(dir, "list.txt")
+	if err := os.WriteFile(concatListPath, []byte(concatList.String()), 0644); err != nil {
+		return err
+	}
+
+	metadataPath := filepath.Join(dir, "metadata.txt")
+	if err := os.WriteFile(metadataPath, []byte(metadata.String()), 0644); err != nil {
+		return err
+	}
+
+	args := []string{"-fflags", "+genpts+discardcorrupt", "-f", "concat", "-safe", "0", "-i", concatListPath, "-i", metadataPath, "-map_chapters", "1"}
+	args = append(args, remuxOutputArgs(outPath)...)
+
+	return runFFmpeg(ctx, args...)
+}
+
+func GetVideoDuration(ctx context.Context, filePath string) (time.Duration, error) {
+	ffprobeOutput, err := ffprobeFile(ctx, filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	if ffprobeOutput.FormatDuration == 0 {
+		return 0, fmt.Errorf("unknown duration")
+	}
+
+	return ffprobeOutput.FormatDuration, nil
+}
+
+func remuxOutputArgs(outPath string) []string {
+	// Only video and audio are kept, since timed metadata in HLS streams cannot be put in most containers
+	args := []string{"-map", "0:v?", "-map", "0:a?", "-c", "copy", "-avoid_negative_ts", "make_zero"}
+
+	if strings.EqualFold(filepath.Ext(outPath), ".mp4") {
+		args = append(args, "-movflags", "+faststart")
+	}
+
+	return append(args, "-y", outPath)
+}
+
+func runFFmpeg(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg", append([]string{"-hide_banner", "-loglevel", "error"}, args...)...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+func escapeFFMetadata(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", `\`+"\n")
+	return replacer.Replace(s)
+}
+
+const (
+	compressAudioBitrate    = 96_000
+	compressMinVideoBitrate = 100_000
+	compressMaxAttempts     = 3
+
+	// Leaves room for the container overhead and bitrate overshoot of single pass encoding
+	compressSizeMargin = 0.9
+)
+
+var ErrCannotCompress error = fmt.Errorf("video cannot be compressed enough")
+
+// CompressVideo re-encodes inPath into an MP4 at outPath that is at most maxBytes.
+// The bitrate is computed from the duration of the video, which is downscaled if the bitrate is too low for its resolution.
+// Encoding is retried with a lower bitrate until the output fits.
+func CompressVideo(ctx context.Context, inPath string, outPath string, maxBytes int64) error {
+	duration, err := GetVideoDuration(ctx, inPath)
+	if err != nil {
+		return err
+	}
+
+	targetBytes := int64(float64(maxBytes) * compressSizeMargin)
+	for attempt := 0; attempt < compressMaxAttempts; attempt++ {
+		videoBitrate := getCompressVideoBitrate(targetBytes, duration)
+		if videoBitrate < compressMinVideoBitrate {
+			return ErrCannotCompress
+		}
+
+		if err := runFFmpeg(ctx,
+			"-i", inPath,
+			"-c:v", "libx264", "-preset", "veryfast",
+			"-b:v", strconv.FormatInt(videoBitrate, 10), "-maxrate", strconv.FormatInt(videoBitrate, 10), "-bufsize", strconv.FormatInt(2*videoBitrate, 10),
+			"-vf", fmt.Sprintf("scale=-2:'min(ih,%v)'", getCompressMaxHeight(videoBitrate)),
+			"-c:a", "aac", "-b:a", strconv.Itoa(compressAudioBitrate),
+			"-movflags", "+faststart",
+			"-y", outPath,
+		); err != nil {
+			return err
+		}
+
+		info, err := os.Stat(outPath)
+		if err != nil {
+			return err
+		}
+
+		if info.Size() <= maxBytes {
+			return nil
+		}
+
+		// Aim lower by how much this attempt overshot
+		targetBytes = int64(float64(targetBytes) * float64(maxBytes) / float64(info.Size()) * compressSizeMargin)
+	}
+
+	return ErrCannotCompress
+}
+
+// getCompressVideoBitrate returns the video bitrate in bits per second that makes a video of the duration targetBytes long
+func getCompressVideoBitrate(targetBytes int64, duration time.Duration) int64 {
+	if duration <= 0 {
+		return 0
+	}
+
+	totalBitrate := float64(targetBytes*8) / duration.Seconds()
+	return int64(totalBitrate) - compressAudioBitrate
+}
+
+// getCompressMaxHeight returns the highest resolution that still looks acceptable at the video bitrate
+func getCompressMaxHeight(videoBitrate int64) int {
+	switch {
+	case videoBitrate < 400_000:
+		return 360
+	case videoBitrate < 800_000:
+		return 480
+	case videoBitrate < 1_500_000:
+		return 720
+	default:
+		return 1080
+	}
+}
+
+type VideoFrame struct {
+	FilePath string
+	Duration time.Duration
+}
+
+// FramesToVideo encodes the images into an MP4 at outPath, showing each image for the duration of its frame
+func FramesToVideo(ctx context.Context, frames []*VideoFrame, outPath string) error {
+	concatList, err := getFramesConcatList(frames)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	concatListPath := filepath.Join(dir, "list.txt")
+	if err := os.WriteFile(concatListPath, []byte(concatList), 0644); err != nil {
+		return err
+	}
+
+	return runFFmpeg(ctx,
+		"-f", "concat", "-safe", "0", "-i", concatListPath,
+		// H.264 needs even dimensions, and most players only support yuv420p
+		"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2,format=yuv420p",
+		"-c:v", "libx264", "-vsync", "vfr",
+		"-movflags", "+faststart",
+		"-y", outPath,
+	)
+}
+
+func getFramesConcatList(frames []*VideoFrame) (string, error) {
+	if len(frames) == 0 {
+		return "", fmt.Errorf("no frames")
+	}
+
+	var concatList strings.Builder
+	concatList.WriteString("ffconcat version 1.0\n")
+
+	var absPath string
+	for _, frame := range frames {
+		var err error
+		absPath, err = filepath.Abs(frame.FilePath)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(&concatList, "file '%s'\nduration %.3f\n", strings.ReplaceAll(absPath, "'", `'\''`), frame.Duration.Seconds())
+	}
+
+	// The duration of the last file is ignored unless it is followed by another file
+	fmt.Fprintf(&concatList, "file '%s'\n", strings.ReplaceAll(absPath, "'", `'\''`))
+
+	return concatList.String(), nil
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCompressVideoBitrate(t *testing.T) {
+	// 10 MB over 100 seconds is 800 kbps in total, minus the audio
+	assert.Equal(t, int64(800_000-compressAudioBitrate), getCompressVideoBitrate(10_000_000, 100*time.Second))
+	assert.Equal(t, int64(0), getCompressVideoBitrate(10_000_000, 0))
+}
+
+func TestGetCompressMaxHeight(t *testing.T) {
+	assert.Equal(t, 360, getCompressMaxHeight(200_000))
+	assert.Equal(t, 480, getCompressMaxHeight(400_000))
+	assert.Equal(t, 720, getCompressMaxHeight(1_000_000))
+	assert.Equal(t, 1080, getCompressMaxHeight(5_000_000))
+}
+
+func TestGetFramesConcatList(t *testing.T) {
+	concatList, err := getFramesConcatList([]*VideoFrame{
+		{FilePath: "/tmp/0.jpg", Duration: 100 * time.Millisecond},
+		{FilePath: "/tmp/it's.jpg", Duration: 1500 * time.Millisecond},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ffconcat version 1.0\nfile '/tmp/0.jpg'\nduration 0.100\nfile '/tmp/it'\\''s.jpg'\nduration 1.500\nfile '/tmp/it'\\''s.jpg'\n", concatList)
+
+	_, err = getFramesConcatList(nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,134 @@
+package weibo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/xIceArcher/go-leah/consts"
+	"github.com/xIceArcher/go-leah/discord"
+	"github.com/xIceArcher/go-leah/utils"
+)
+
+const (
+	MAX_EMBEDS_PER_POST = 4
+
+	// Discord rejects fields with values longer than this
+	maxFieldValueLength = 1024
+)
+
+var weiboEmbedFooter = &discordgo.MessageEmbedFooter{
+	Text:    "Weibo",
+	IconURL: "https://weibo.com/favicon.ico",
+}
+
+func (p *Post) GetEmbeds() []*discordgo.MessageEmbed {
+	mainEmbed := &discordgo.MessageEmbed{
+		URL:         p.URL(),
+		Title:       fmt.Sprintf("Weibo by %s", p.authorName()),
+		Description: (&utils.TextWithEntities{Text: strings.TrimSpace(p.Text)}).GetReplacedText(4096, 1)[0],
+		Color:       utils.ParseHexColor(consts.ColorWeibo),
+		Author:      p.Author.GetEmbed(),
+	}
+
+	if p.IsRepost() {
+		mainEmbed.Title = fmt.Sprintf("Repost by %s", p.authorName())
+		mainEmbed.Fields = []*discordgo.MessageEmbedField{p.repostField()}
+	}
+
+	relevantPhotos := p.MediaPost().Photos
+
+	embeds := []*discordgo.MessageEmbed{mainEmbed}
+	footerEmbedIdx := 0
+
+	for i, photo := range relevantPhotos {
+		variant := photo.BestVariant()
+		if variant == nil {
+			continue
+		}
+
+		if i == 0 {
+			mainEmbed.Image = &discordgo.MessageEmbedImage{
+				URL: variant.URL,
+			}
+			continue
+		}
+
+		// Discord only groups up to 4 images with the same URL into one embed
+		embedURL := p.URL()
+		if i >= MAX_EMBEDS_PER_POST {
+			embedURL += fmt.Sprintf("?s=%v", i/MAX_EMBEDS_PER_POST)
+		}
+
+		if i%MAX_EMBEDS_PER_POST == 0 {
+			footerEmbedIdx += MAX_EMBEDS_PER_POST
+		}
+
+		embeds = append(embeds, &discordgo.MessageEmbed{
+			URL: embedURL,
+			Image: &discordgo.MessageEmbedImage{
+				URL: variant.URL,
+			},
+			Color: utils.ParseHexColor(consts.ColorWeibo),
+		})
+	}
+
+	if footerEmbedIdx >= len(embeds) {
+		footerEmbedIdx = len(embeds) - 1
+	}
+
+	embeds[footerEmbedIdx].Footer = weiboEmbedFooter
+	if p.CreateTime.Unix() != 0 {
+		embeds[footerEmbedIdx].Timestamp = p.CreateTime.Format(time.RFC3339)
+	}
+
+	return embeds
+}
+
+// MediaPost returns the post whose photos and videos are shown, which is the reposted post if a repost has none of its own
+func (p *Post) MediaPost() *Post {
+	if p.IsRepost() && !p.HasPhotos() && !p.HasVideos() {
+		return p.RepostedPost
+	}
+	return p
+}
+
+func (p *Post) repostField() *discordgo.MessageEmbedField {
+	original := p.RepostedPost
+	if original.Author == nil {
+		return &discordgo.MessageEmbedField{
+			Name:  "Repost",
+			Value: "The original post has been deleted",
+		}
+	}
+
+	value := discord.GetNamedLink(fmt.Sprintf("Original post by %s", original.authorName()), original.URL()) + "\n" + strings.TrimSpace(original.Text)
+	if len([]rune(value)) > maxFieldValueLength {
+		value = string([]rune(value)[:maxFieldValueLength-1]) + "…"
+	}
+
+	return &discordgo.MessageEmbedField{
+		Name:  "Repost",
+		Value: value,
+	}
+}
+
+func (p *Post) authorName() string {
+	if p.Author == nil {
+		return "unknown user"
+	}
+	return p.Author.Name
+}
+
+func (u *User) GetEmbed() *discordgo.MessageEmbedAuthor {
+	if u == nil {
+		return nil
+	}
+
+	return &discordgo.MessageEmbedAuthor{
+		Name:    u.Name,
+		URL:     u.URL(),
+		IconURL: u.AvatarURL,
+	}
+}
@@ -2,13 +2,105 @@ package weibo
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
+	"time"
 )
 
+const rawTimeLayout = "Mon Jan 02 15:04:05 -0700 2006"
+
 type RawGetPostResp struct {
-	ID       string                 `json:"idstr"`
-	PicIDs   []string               `json:"pic_ids"`
-	PicInfos map[string]RawPhotoMap `json:"pic_infos"`
+	ID         string `json:"idstr"`
+	MblogID    string `json:"mblogid"`
+	CreatedAt  string `json:"created_at"`
+	Text       string `json:"text_raw"`
+	IsLongText bool   `json:"isLongText"`
+
+	// Nil if the post was deleted
+	User *RawUser `json:"user"`
+
+	// Set if this post is a repost, which quotes the original post
+	RetweetedStatus *RawGetPostResp `json:"retweeted_status"`
+
+	PicIDs   []string                 `json:"pic_ids"`
+	PicInfos map[string]*RawPhotoInfo `json:"pic_infos"`
+
+	// Set if the post has a video
+	PageInfo *RawPageInfo `json:"page_info"`
+}
+
+func (r *RawGetPostResp) CreateTime() time.Time {
+	ret, err := time.Parse(rawTimeLayout, r.CreatedAt)
+	if err != nil {
+		return time.Unix(0, 0)
+	}
+	return ret
+}
+
+type RawUser struct {
+	ID              string `json:"idstr"`
+	ScreenName      string `json:"screen_name"`
+	ProfileImageURL string `json:"profile_image_url"`
+	AvatarHD        string `json:"avatar_hd"`
+}
+
+const RawPageInfoObjectTypeVideo = "video"
+
+type RawPageInfo struct {
+	ObjectType string        `json:"object_type"`
+	MediaInfo  *RawMediaInfo `json:"media_info"`
+}
+
+type RawMediaInfo struct {
+	PlaybackList []*RawPlayback `json:"playback_list"`
+
+	// Fallbacks from best to worst for videos without a playback list
+	MP4720pURL  string `json:"mp4_720p_mp4"`
+	MP4HDURL    string `json:"mp4_hd_url"`
+	MP4SDURL    string `json:"mp4_sd_url"`
+	StreamURLHD string `json:"stream_url_hd"`
+	StreamURL   string `json:"stream_url"`
+}
+
+type RawPlayback struct {
+	PlayInfo *struct {
+		URL     string `json:"url"`
+		Width   int    `json:"width"`
+		Height  int    `json:"height"`
+		Bitrate int    `json:"bitrate"`
+	} `json:"play_info"`
+}
+
+type RawGetLongTextResp struct {
+	Data struct {
+		LongTextContent string `json:"longTextContent"`
+	} `json:"data"`
+}
+
+// RawPhotoInfo has a key for each variant of the photo alongside its other fields
+type RawPhotoInfo struct {
+	Type string `json:"type"`
+
+	// Only set for live photos
+	VideoURL string `json:"video"`
+
+	Variants RawPhotoMap `json:"-"`
+}
+
+func (i *RawPhotoInfo) UnmarshalJSON(bytes []byte) error {
+	type rawPhotoInfo RawPhotoInfo
+
+	info := &rawPhotoInfo{}
+	if err := json.Unmarshal(bytes, info); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(bytes, &info.Variants); err != nil {
+		return err
+	}
+
+	*i = RawPhotoInfo(*info)
+	return nil
 }
 
 type RawPhotoMap map[string]*RawPhoto
@@ -46,13 +138,61 @@ type RawPhoto struct {
 }
 
 type Post struct {
-	ID     string
+	ID      string
+	MblogID string
+
+	Text       string
+	CreateTime time.Time
+
+	// Nil if the post was deleted
+	Author *User
+
+	// Nil unless this post is a repost
+	RepostedPost *Post
+
 	Photos []*Photo
+	Videos []*Video
+}
+
+func (p *Post) URL() string {
+	if p.Author == nil || p.MblogID == "" {
+		return fmt.Sprintf("https://m.weibo.cn/detail/%s", p.ID)
+	}
+	return fmt.Sprintf("https://weibo.com/%s/%s", p.Author.ID, p.MblogID)
+}
+
+func (p *Post) IsRepost() bool {
+	return p.RepostedPost != nil
+}
+
+func (p *Post) HasPhotos() bool {
+	return len(p.Photos) > 0
+}
+
+func (p *Post) HasVideos() bool {
+	return len(p.Videos) > 0
+}
+
+type User struct {
+	ID        string
+	Name      string
+	AvatarURL string
+}
+
+func (u *User) URL() string {
+	return fmt.Sprintf("https://weibo.com/u/%s", u.ID)
 }
 
 type Photo struct {
 	ID       string
 	Variants []*PhotoVariant
+
+	// Empty unless this is a live photo
+	LivePhotoVideoURL string
+}
+
+func (p *Photo) IsLivePhoto() bool {
+	return p.LivePhotoVideoURL != ""
 }
 
 func (p *Photo) BestVariant() *PhotoVariant {
@@ -75,3 +215,22 @@ type PhotoVariant struct {
 func (v *PhotoVariant) Resolution() int {
 	return v.Height * v.Width
 }
+
+type Video struct {
+	URL     string
+	Width   int
+	Height  int
+	Bitrate int
+}
+
+func (v *Video) Resolution() int {
+	return v.Height * v.Width
+}
+
+// isBetterThan prefers higher resolutions, then higher bitrates
+func (v *Video) isBetterThan(other *Video) bool {
+	if v.Resolution() != other.Resolution() {
+		return v.Resolution() > other.Resolution()
+	}
+	return v.Bitrate > other.Bitrate
+}
@@ -1,9 +1,19 @@
 package weibo
 
 import (
+	"fmt"
+	"net/url"
+	"regexp"
+
 	"github.com/go-resty/resty/v2"
 )
 
+var (
+	ErrNotFound error = fmt.Errorf("not found")
+
+	postURLRegex = regexp.MustCompile(`http[s]?://(?:(?:w{3}\.)?weibo\.com/[0-9]+|m\.weibo\.cn/(?:detail|status))/([A-Za-z0-9]+)`)
+)
+
 type API struct {
 	client *resty.Client
 }
@@ -16,10 +26,19 @@ func NewAPI() *API {
 	}
 }
 
+// ParsePostID returns the ID of the post a Weibo link points to
+func ParsePostID(link string) (string, bool) {
+	matches := postURLRegex.FindStringSubmatch(link)
+	if len(matches) <= 1 {
+		return "", false
+	}
+	return matches[1], true
+}
+
 func (a *API) GetPost(id string) (*Post, error) {
 	resp := &RawGetPostResp{}
 
-	_, err := a.client.R().
+	httpResp, err := a.client.R().
 		SetQueryParam("id", id).
 		SetResult(resp).
 		Get("https://weibo.com/ajax/statuses/show")
@@ -27,22 +46,83 @@ func (a *API) GetPost(id string) (*Post, error) {
 		return nil, err
 	}
 
+	if httpResp.IsError() {
+		return nil, fmt.Errorf("http error %v", httpResp.StatusCode())
+	}
+
+	if resp.ID == "" {
+		return nil, ErrNotFound
+	}
+
+	ret := parsePost(resp)
+	ret.ID = id
+
+	if resp.IsLongText && resp.MblogID != "" {
+		// The truncated text is good enough if the full text cannot be fetched
+		if text, err := a.getLongText(resp.MblogID); err == nil && text != "" {
+			ret.Text = text
+		}
+	}
+
+	return ret, nil
+}
+
+func (a *API) getLongText(mblogID string) (string, error) {
+	resp := &RawGetLongTextResp{}
+
+	httpResp, err := a.client.R().
+		SetQueryParam("id", mblogID).
+		SetResult(resp).
+		Get("https://weibo.com/ajax/statuses/longtext")
+	if err != nil {
+		return "", err
+	}
+
+	if httpResp.IsError() {
+		return "", fmt.Errorf("http error %v", httpResp.StatusCode())
+	}
+
+	return resp.Data.LongTextContent, nil
+}
+
+func parsePost(resp *RawGetPostResp) *Post {
 	ret := &Post{
-		ID:     id,
-		Photos: make([]*Photo, 0, len(resp.PicInfos)),
+		ID:         resp.ID,
+		MblogID:    resp.MblogID,
+		Text:       resp.Text,
+		CreateTime: resp.CreateTime(),
+		Photos:     make([]*Photo, 0, len(resp.PicInfos)),
+	}
+
+	if resp.User != nil {
+		avatarURL := resp.User.AvatarHD
+		if avatarURL == "" {
+			avatarURL = resp.User.ProfileImageURL
+		}
+
+		ret.Author = &User{
+			ID:        resp.User.ID,
+			Name:      resp.User.ScreenName,
+			AvatarURL: avatarURL,
+		}
+	}
+
+	if resp.RetweetedStatus != nil {
+		ret.RepostedPost = parsePost(resp.RetweetedStatus)
 	}
 
 	for _, picID := range resp.PicIDs {
-		rawPhotoMap, ok := resp.PicInfos[picID]
+		rawPhotoInfo, ok := resp.PicInfos[picID]
 		if !ok {
 			continue
 		}
 
 		photo := &Photo{
-			ID: picID,
+			ID:                picID,
+			LivePhotoVideoURL: parseLivePhotoVideoURL(rawPhotoInfo.VideoURL),
 		}
 
-		for variantName, variant := range rawPhotoMap {
+		for variantName, variant := range rawPhotoInfo.Variants {
 			photo.Variants = append(photo.Variants, &PhotoVariant{
 				VariantName: variantName,
 				URL:         variant.URL,
@@ -54,19 +134,72 @@ func (a *API) GetPost(id string) (*Post, error) {
 		ret.Photos = append(ret.Photos, photo)
 	}
 
-	return ret, nil
+	if resp.PageInfo != nil && resp.PageInfo.ObjectType == RawPageInfoObjectTypeVideo && resp.PageInfo.MediaInfo != nil {
+		if video := bestVideo(resp.PageInfo.MediaInfo); video != nil {
+			ret.Videos = append(ret.Videos, video)
+		}
+	}
+
+	return ret
+}
+
+// bestVideo returns the highest quality stream of the video, or nil if there are none
+func bestVideo(mediaInfo *RawMediaInfo) *Video {
+	var best *Video
+	for _, playback := range mediaInfo.PlaybackList {
+		if playback.PlayInfo == nil || playback.PlayInfo.URL == "" {
+			continue
+		}
+
+		video := &Video{
+			URL:     playback.PlayInfo.URL,
+			Width:   playback.PlayInfo.Width,
+			Height:  playback.PlayInfo.Height,
+			Bitrate: playback.PlayInfo.Bitrate,
+		}
+
+		if best == nil || video.isBetterThan(best) {
+			best = video
+		}
+	}
+
+	if best != nil {
+		return best
+	}
+
+	for _, videoURL := range []string{mediaInfo.MP4720pURL, mediaInfo.MP4HDURL, mediaInfo.MP4SDURL, mediaInfo.StreamURLHD, mediaInfo.StreamURL} {
+		if videoURL != "" {
+			return &Video{URL: videoURL}
+		}
+	}
+
+	return nil
+}
+
+// parseLivePhotoVideoURL unwraps the video of a live photo from the Weibo player URL it is usually given as
+func parseLivePhotoVideoURL(videoURL string) string {
+	u, err := url.Parse(videoURL)
+	if err != nil {
+		return videoURL
+	}
+
+	if livePhotoURL := u.Query().Get("livephoto"); livePhotoURL != "" {
+		return livePhotoURL
+	}
+
+	return videoURL
 }
 
-func (a *API) GetPhotoVariantSize(photoVariant *PhotoVariant) (int64, error) {
-	resp, err := a.client.R().Head(photoVariant.URL)
+func (a *API) GetMediaSize(mediaURL string) (int64, error) {
+	resp, err := a.client.R().Head(mediaURL)
 	if err != nil {
 		return 0, err
 	}
 	return resp.RawResponse.ContentLength, nil
 }
 
-func (a *API) DownloadPhotoVariant(photoVariant *PhotoVariant) ([]byte, error) {
-	resp, err := a.client.R().Get(photoVariant.URL)
+func (a *API) DownloadMedia(mediaURL string) ([]byte, error) {
+	resp, err := a.client.R().Get(mediaURL)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,136 @@
+package weibo
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePostID(t *testing.T) {
+	for link, expected := range map[string]string{
+		"https://weibo.com/1234567890/NqXyZ1a2b":       "NqXyZ1a2b",
+		"https://www.weibo.com/1234567890/NqXyZ1a2b?x": "NqXyZ1a2b",
+		"https://m.weibo.cn/detail/4950000000000000":   "4950000000000000",
+		"https://m.weibo.cn/status/NqXyZ1a2b":          "NqXyZ1a2b",
+	} {
+		postID, ok := ParsePostID(link)
+		assert.True(t, ok, link)
+		assert.Equal(t, expected, postID, link)
+	}
+
+	_, ok := ParsePostID("https://weibo.com/u/1234567890")
+	assert.False(t, ok)
+}
+
+const rawRepost = `{
+	"idstr": "2",
+	"mblogid": "Repost",
+	"created_at": "Sat Oct 14 20:03:15 +0800 2023",
+	"text_raw": "Look at this",
+	"user": {"idstr": "100", "screen_name": "Reposter", "avatar_hd": "https://tvax1.sinaimg.cn/avatar.jpg"},
+	"pic_ids": [],
+	"pic_infos": {},
+	"retweeted_status": {
+		"idstr": "1",
+		"mblogid": "Original",
+		"created_at": "Fri Oct 13 10:00:00 +0800 2023",
+		"text_raw": "Original text",
+		"user": {"idstr": "200", "screen_name": "Author", "profile_image_url": "https://tvax1.sinaimg.cn/small.jpg"},
+		"pic_ids": ["a", "b"],
+		"pic_infos": {
+			"a": {
+				"pic_id": "a",
+				"thumbnail": {"url": "https://wx1.sinaimg.cn/thumbnail/a.jpg", "width": 180, "height": 120},
+				"largest": {"url": "https://wx1.sinaimg.cn/large/a.jpg", "width": 1800, "height": 1200}
+			},
+			"b": {
+				"largest": {"url": "https://wx1.sinaimg.cn/large/b.jpg", "width": 1800, "height": 1200}
+			}
+		}
+	}
+}`
+
+func TestGetEmbedsRepost(t *testing.T) {
+	resp := &RawGetPostResp{}
+	require.NoError(t, json.Unmarshal([]byte(rawRepost), resp))
+
+	post := parsePost(resp)
+	require.True(t, post.IsRepost())
+	assert.Equal(t, "https://weibo.com/100/Repost", post.URL())
+	assert.Equal(t, time.Date(2023, 10, 14, 12, 3, 15, 0, time.UTC), post.CreateTime.UTC())
+	assert.Equal(t, "https://tvax1.sinaimg.cn/small.jpg", post.RepostedPost.Author.AvatarURL)
+
+	embeds := post.GetEmbeds()
+	require.Len(t, embeds, 2)
+
+	assert.Equal(t, "Repost by Reposter", embeds[0].Title)
+	assert.Equal(t, "Look at this", embeds[0].Description)
+	assert.Equal(t, "https://tvax1.sinaimg.cn/avatar.jpg", embeds[0].Author.IconURL)
+	assert.Contains(t, embeds[0].Fields[0].Value, "https://weibo.com/200/Original")
+	assert.Contains(t, embeds[0].Fields[0].Value, "Original text")
+
+	// Photos of the original post are shown since the repost has none
+	assert.Equal(t, "https://wx1.sinaimg.cn/large/a.jpg", embeds[0].Image.URL)
+	assert.Equal(t, "https://wx1.sinaimg.cn/large/b.jpg", embeds[1].Image.URL)
+	assert.Equal(t, post.URL(), embeds[1].URL)
+	assert.NotNil(t, embeds[0].Footer)
+
+	// Long text is truncated to fit in the description
+	post.Text = strings.Repeat("長", 5000)
+	assert.LessOrEqual(t, len([]rune(post.GetEmbeds()[0].Description)), 4096)
+}
+
+const rawVideoPost = `{
+	"idstr": "3",
+	"mblogid": "Video",
+	"text_raw": "Video",
+	"user": {"idstr": "100", "screen_name": "Author"},
+	"pic_ids": ["live"],
+	"pic_infos": {
+		"live": {
+			"type": "livephoto",
+			"video": "https://video.weibo.com/media/play?livephoto=https%3A%2F%2Flivephoto.us.sinaimg.cn%2Flive.mov",
+			"largest": {"url": "https://wx1.sinaimg.cn/large/live.jpg", "width": 1800, "height": 1200}
+		}
+	},
+	"page_info": {
+		"object_type": "video",
+		"media_info": {
+			"mp4_sd_url": "https://f.video.weibocdn.com/sd.mp4",
+			"playback_list": [
+				{"play_info": {"url": "https://f.video.weibocdn.com/720.mp4", "width": 1280, "height": 720, "bitrate": 2000}},
+				{"play_info": {"url": "https://f.video.weibocdn.com/1080_low.mp4", "width": 1920, "height": 1080, "bitrate": 3000}},
+				{"play_info": {"url": "https://f.video.weibocdn.com/1080.mp4", "width": 1920, "height": 1080, "bitrate": 5000}}
+			]
+		}
+	}
+}`
+
+func TestParsePostVideos(t *testing.T) {
+	resp := &RawGetPostResp{}
+	require.NoError(t, json.Unmarshal([]byte(rawVideoPost), resp))
+
+	post := parsePost(resp)
+	require.Len(t, post.Videos, 1)
+	assert.Equal(t, "https://f.video.weibocdn.com/1080.mp4", post.Videos[0].URL)
+
+	require.Len(t, post.Photos, 1)
+	assert.True(t, post.Photos[0].IsLivePhoto())
+	assert.Equal(t, "https://livephoto.us.sinaimg.cn/live.mov", post.Photos[0].LivePhotoVideoURL)
+	assert.Equal(t, "https://wx1.sinaimg.cn/large/live.jpg", post.Photos[0].BestVariant().URL)
+}
+
+func TestBestVideoFallback(t *testing.T) {
+	video := bestVideo(&RawMediaInfo{
+		MP4HDURL: "https://f.video.weibocdn.com/hd.mp4",
+		MP4SDURL: "https://f.video.weibocdn.com/sd.mp4",
+	})
+	require.NotNil(t, video)
+	assert.Equal(t, "https://f.video.weibocdn.com/hd.mp4", video.URL)
+
+	assert.Nil(t, bestVideo(&RawMediaInfo{}))
+}
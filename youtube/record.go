@@ -0,0 +1,74 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/xIceArcher/go-leah/cache"
+)
+
+const (
+	CacheKeyRecordRequestFormat = "go-leah/youtubeRecord/request/%s"
+	CacheKeyRecordingFormat     = "go-leah/youtubeRecord/recording/%s"
+
+	// Requests for streams that never go live are eventually forgotten
+	recordRequestExpiry = 7 * 24 * time.Hour
+)
+
+var (
+	ErrNoHLSURL error = fmt.Errorf("no HLS URL found")
+
+	videoIDRegex = regexp.MustCompile(`^(?:(?:http[s]?://)?(?:w{3}\.|m\.)?(?:youtube\.com/(?:watch\?v=|live/)|youtu\.be/))?([A-Za-z0-9_\-]{11})`)
+)
+
+// ParseVideoID returns the video ID of a YouTube URL, or the string itself if it is already a video ID
+func ParseVideoID(s string) (string, bool) {
+	matches := videoIDRegex.FindStringSubmatch(s)
+	if len(matches) <= 1 {
+		return "", false
+	}
+
+	return matches[1], true
+}
+
+// RequestRecording marks the video to be recorded once it is live and tracked by the live stream matcher
+func RequestRecording(ctx context.Context, c cache.Cache, videoID string) error {
+	return c.SetWithExpiry(ctx, fmt.Sprintf(CacheKeyRecordRequestFormat, videoID), true, recordRequestExpiry)
+}
+
+func IsRecordingRequested(ctx context.Context, c cache.Cache, videoID string) (bool, error) {
+	_, err := c.Get(ctx, fmt.Sprintf(CacheKeyRecordRequestFormat, videoID))
+	if errors.Is(err, cache.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func ClearRecordingRequest(ctx context.Context, c cache.Cache, videoID string) error {
+	return c.Clear(ctx, fmt.Sprintf(CacheKeyRecordRequestFormat, videoID))
+}
+
+// GetHLSURL returns the URL of the media playlist of the best quality of a live stream
+func GetHLSURL(ctx context.Context, videoID string) (string, error) {
+	video := &Video{ID: videoID}
+
+	output, err := exec.CommandContext(ctx, "yt-dlp", "-g", "-f", "best", video.URL()).Output()
+	if err != nil {
+		return "", err
+	}
+
+	hlsURL, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	if hlsURL == "" {
+		return "", ErrNoHLSURL
+	}
+
+	return hlsURL, nil
+}
@@ -0,0 +1,23 @@
+package youtube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVideoID(t *testing.T) {
+	for _, s := range []string{
+		"dQw4w9WgXcQ",
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+		"https://youtube.com/live/dQw4w9WgXcQ?feature=share",
+		"youtu.be/dQw4w9WgXcQ",
+	} {
+		videoID, ok := ParseVideoID(s)
+		assert.True(t, ok, s)
+		assert.Equal(t, "dQw4w9WgXcQ", videoID, s)
+	}
+
+	_, ok := ParseVideoID("https://example.com/watch")
+	assert.False(t, ok)
+}
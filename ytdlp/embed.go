@@ -0,0 +1,71 @@
+package ytdlp
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/xIceArcher/go-leah/utils"
+)
+
+// EmbedOptions customizes the embed of a site, empty fields are left out
+type EmbedOptions struct {
+	// Defaults to the name of the yt-dlp extractor
+	SiteName string
+	IconURL  string
+	Color    string
+}
+
+func (i *Info) GetEmbed(opts *EmbedOptions) *discordgo.MessageEmbed {
+	textWithEntities := &utils.TextWithEntities{Text: i.Description}
+	segmentedText := textWithEntities.GetReplacedText(4096, 1)
+
+	embed := &discordgo.MessageEmbed{
+		URL:         i.WebpageURL,
+		Title:       i.Title,
+		Description: segmentedText[0],
+		Footer: &discordgo.MessageEmbedFooter{
+			Text:    i.ExtractorKey,
+			IconURL: opts.IconURL,
+		},
+	}
+
+	if opts.SiteName != "" {
+		embed.Footer.Text = opts.SiteName
+	}
+
+	if opts.Color != "" {
+		embed.Color = utils.ParseHexColor(opts.Color)
+	}
+
+	if i.Uploader != "" {
+		uploaderURL := i.UploaderURL
+		if uploaderURL == "" {
+			uploaderURL = i.ChannelURL
+		}
+
+		embed.Author = &discordgo.MessageEmbedAuthor{
+			Name: i.Uploader,
+			URL:  uploaderURL,
+		}
+	}
+
+	if i.Thumbnail != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{
+			URL: i.Thumbnail,
+		}
+	}
+
+	if i.Duration > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Duration",
+			Value:  utils.FormatDurationSimple(i.GetDuration()),
+			Inline: true,
+		})
+	}
+
+	if i.Timestamp != 0 {
+		embed.Timestamp = time.Unix(i.Timestamp, 0).Format(time.RFC3339)
+	}
+
+	return embed
+}
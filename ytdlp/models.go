@@ -0,0 +1,161 @@
+package ytdlp
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const codecNone = "none"
+
+// Info is the metadata printed by yt-dlp -j
+type Info struct {
+	ID           string  `json:"id"`
+	Title        string  `json:"title"`
+	Description  string  `json:"description"`
+	WebpageURL   string  `json:"webpage_url"`
+	Thumbnail    string  `json:"thumbnail"`
+	Duration     float64 `json:"duration"`
+	Timestamp    int64   `json:"timestamp"`
+	ExtractorKey string  `json:"extractor_key"`
+
+	Uploader    string `json:"uploader"`
+	UploaderID  string `json:"uploader_id"`
+	UploaderURL string `json:"uploader_url"`
+	ChannelURL  string `json:"channel_url"`
+
+	Formats []*Format `json:"formats"`
+}
+
+func (i *Info) GetDuration() time.Duration {
+	return time.Duration(i.Duration * float64(time.Second))
+}
+
+type Format struct {
+	FormatID string `json:"format_id"`
+	Ext      string `json:"ext"`
+
+	VideoCodec string `json:"vcodec"`
+	AudioCodec string `json:"acodec"`
+
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	// Total bitrate in kbit/s
+	TotalBitrate   float64 `json:"tbr"`
+	FileSize       int64   `json:"filesize"`
+	FileSizeApprox int64   `json:"filesize_approx"`
+}
+
+func (f *Format) HasVideo() bool {
+	return f.VideoCodec != codecNone
+}
+
+func (f *Format) HasAudio() bool {
+	return f.AudioCodec != codecNone
+}
+
+func (f *Format) Resolution() int {
+	return f.Width * f.Height
+}
+
+// EstimateSize returns the size of the format, estimating it from its bitrate if yt-dlp does not know it.
+// It returns 0 if the size cannot be estimated.
+func (f *Format) EstimateSize(duration time.Duration) int64 {
+	if f.FileSize > 0 {
+		return f.FileSize
+	} else if f.FileSizeApprox > 0 {
+		return f.FileSizeApprox
+	}
+
+	return int64(f.TotalBitrate * 1000 / 8 * duration.Seconds())
+}
+
+// FormatSelection is either a single format with both video and audio, or a video format that is merged with an audio format
+type FormatSelection struct {
+	Video *Format
+
+	// Nil if Video already has audio
+	Audio *Format
+}
+
+// ID returns the format selector that downloads the selection
+func (s *FormatSelection) ID() string {
+	if s.Audio == nil {
+		return s.Video.FormatID
+	}
+	return fmt.Sprintf("%s+%s", s.Video.FormatID, s.Audio.FormatID)
+}
+
+func (s *FormatSelection) IsMerged() bool {
+	return s.Audio != nil
+}
+
+// Ext returns the extension of the downloaded file. Merged formats are always merged into an MP4.
+func (s *FormatSelection) Ext() string {
+	if s.IsMerged() {
+		return "mp4"
+	}
+	return s.Video.Ext
+}
+
+func (s *FormatSelection) estimateSize(duration time.Duration) int64 {
+	size := s.Video.EstimateSize(duration)
+	if s.Audio == nil || size <= 0 {
+		return size
+	}
+
+	audioSize := s.Audio.EstimateSize(duration)
+	if audioSize <= 0 {
+		return 0
+	}
+	return size + audioSize
+}
+
+func (s *FormatSelection) totalBitrate() float64 {
+	if s.Audio == nil {
+		return s.Video.TotalBitrate
+	}
+	return s.Video.TotalBitrate + s.Audio.TotalBitrate
+}
+
+// BestFormat returns the highest resolution format, or pair of separate video and audio formats, that is at most maxBytes.
+// It returns nil if none of them fit. Formats of unknown size are assumed not to fit.
+func (i *Info) BestFormat(maxBytes int64) *FormatSelection {
+	videoFormats := make([]*Format, 0, len(i.Formats))
+	audioFormats := make([]*Format, 0, len(i.Formats))
+	candidates := make([]*FormatSelection, 0, len(i.Formats))
+	for _, format := range i.Formats {
+		if format.HasVideo() && format.HasAudio() {
+			candidates = append(candidates, &FormatSelection{Video: format})
+		} else if format.HasVideo() {
+			videoFormats = append(videoFormats, format)
+		} else if format.HasAudio() {
+			audioFormats = append(audioFormats, format)
+		}
+	}
+
+	// Most DASH sites only have separate video and audio formats
+	for _, videoFormat := range videoFormats {
+		for _, audioFormat := range audioFormats {
+			candidates = append(candidates, &FormatSelection{Video: videoFormat, Audio: audioFormat})
+		}
+	}
+
+	candidates = slices.DeleteFunc(candidates, func(candidate *FormatSelection) bool {
+		size := candidate.estimateSize(i.GetDuration())
+		return size <= 0 || size > maxBytes
+	})
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	return slices.MaxFunc(candidates, func(a, b *FormatSelection) int {
+		if a.Video.Resolution() != b.Video.Resolution() {
+			return cmp.Compare(a.Video.Resolution(), b.Video.Resolution())
+		}
+		return cmp.Compare(a.totalBitrate(), b.totalBitrate())
+	})
+}
@@ -0,0 +1,55 @@
+package ytdlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// GetInfo returns the metadata of the video at url. args are passed to yt-dlp before the URL.
+func GetInfo(ctx context.Context, url string, args ...string) (*Info, error) {
+	cmdArgs := append([]string{"-j", "--no-playlist"}, args...)
+	cmd := exec.CommandContext(ctx, "yt-dlp", append(cmdArgs, url)...)
+
+	out := &bytes.Buffer{}
+	cmd.Stdout = out
+
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	info := &Info{}
+	if err := json.NewDecoder(out).Decode(info); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// Download downloads the format of the video at url into a temporary file, which is already removed from disk
+// and only lives as long as the returned file is open. args are passed to yt-dlp before the URL.
+func Download(ctx context.Context, url string, format *FormatSelection, args ...string) (*os.File, error) {
+	file, err := os.CreateTemp("", fmt.Sprintf("*.%s", format.Ext()))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	cmdArgs := []string{"-f", format.ID(), "-o", file.Name(), "--force-overwrites", "--no-playlist"}
+	if format.IsMerged() {
+		cmdArgs = append(cmdArgs, "--merge-output-format", "mp4")
+	}
+	cmdArgs = append(cmdArgs, args...)
+	if err := exec.CommandContext(ctx, "yt-dlp", append(cmdArgs, url)...).Run(); err != nil {
+		return nil, err
+	}
+
+	return os.Open(file.Name())
+}
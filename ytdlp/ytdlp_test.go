@@ -0,0 +1,66 @@
+package ytdlp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInfo = `{
+	"id": "abc",
+	"title": "Title",
+	"description": "Description",
+	"webpage_url": "https://example.com/video/abc",
+	"thumbnail": "https://example.com/abc.jpg",
+	"duration": 100,
+	"timestamp": 1700000000,
+	"extractor_key": "Example",
+	"uploader": "Uploader",
+	"channel_url": "https://example.com/uploader",
+	"formats": [
+		{"format_id": "audio", "ext": "m4a", "vcodec": "none", "acodec": "mp4a", "filesize": 1000},
+		{"format_id": "video", "ext": "mp4", "vcodec": "avc1", "acodec": "none", "width": 2560, "height": 1440, "filesize": 60000000},
+		{"format_id": "360p", "ext": "mp4", "vcodec": "avc1", "acodec": "mp4a", "width": 640, "height": 360, "filesize": 2000000},
+		{"format_id": "720p", "ext": "mp4", "vcodec": "avc1", "acodec": "mp4a", "width": 1280, "height": 720, "tbr": 800},
+		{"format_id": "1080p", "ext": "mp4", "vcodec": "avc1", "acodec": "mp4a", "width": 1920, "height": 1080, "filesize_approx": 50000000},
+		{"format_id": "unknown", "ext": "mp4", "vcodec": "avc1", "acodec": "mp4a", "width": 3840, "height": 2160}
+	]
+}`
+
+func TestBestFormat(t *testing.T) {
+	info := &Info{}
+	require.NoError(t, json.Unmarshal([]byte(testInfo), info))
+
+	// Separate video and audio formats are merged
+	format := info.BestFormat(100_000_000)
+	assert.Equal(t, "video+audio", format.ID())
+	assert.True(t, format.IsMerged())
+	assert.Equal(t, "mp4", format.Ext())
+
+	// The 720p format is estimated to be 800 kbit/s * 100 s = 10 MB
+	assert.Equal(t, "1080p", info.BestFormat(60_000_000).ID())
+	assert.Equal(t, "720p", info.BestFormat(25_000_000).ID())
+	assert.Equal(t, "360p", info.BestFormat(5_000_000).ID())
+	assert.False(t, info.BestFormat(5_000_000).IsMerged())
+	assert.Nil(t, info.BestFormat(1000))
+}
+
+func TestGetEmbed(t *testing.T) {
+	info := &Info{}
+	require.NoError(t, json.Unmarshal([]byte(testInfo), info))
+
+	embed := info.GetEmbed(&EmbedOptions{})
+	assert.Equal(t, "Title", embed.Title)
+	assert.Equal(t, "https://example.com/video/abc", embed.URL)
+	assert.Equal(t, "Description", embed.Description)
+	assert.Equal(t, "Example", embed.Footer.Text)
+	assert.Equal(t, "https://example.com/uploader", embed.Author.URL)
+	assert.Equal(t, "https://example.com/abc.jpg", embed.Thumbnail.URL)
+	assert.Equal(t, "0:01:40", embed.Fields[0].Value)
+
+	embed = info.GetEmbed(&EmbedOptions{SiteName: "Site", Color: "FF0000"})
+	assert.Equal(t, "Site", embed.Footer.Text)
+	assert.Equal(t, 0xFF0000, embed.Color)
+}